@@ -0,0 +1,319 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package achievement provides the achievements of the game: goals
+// such as catching 100 bacon or playing 7 days in a row, defined in
+// JSON and evaluated against the stats of the game, and the progress
+// of each profile, kept between runs. It is shared by the SDL and wasm
+// versions of the game, which notify the achievements unlocked.
+package achievement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// DefaultFile is the achievements file, relative to the game.
+const DefaultFile = "assets/achievements.json"
+
+// File is the name of the progress of the achievements in the game's
+// store.
+const File = "achievements.json"
+
+// Version is the version of the progress format.
+const Version = 1
+
+// Kinds of achievements, by what the goal counts.
+const (
+	Catch  = "catch"  // drops caught, in a game or in all games
+	Points = "points" // points in a game
+	Combo  = "combo"  // longest combo in a game
+	Games  = "games"  // games played
+	Streak = "streak" // days played in a row
+)
+
+// Def is the definition of an achievement.
+type Def struct {
+	// ID identifies the achievement in the progress, e.g.
+	// bacon_lover. It must not change once released.
+	ID string `json:"id"`
+
+	// Name and Description are shown to the players.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Kind is what the goal counts, e.g. catch.
+	Kind string `json:"kind"`
+
+	// Goal is the count that unlocks the achievement.
+	Goal int64 `json:"goal"`
+
+	// Drop is the name of the drop to catch, or empty for any
+	// good drop. Catch only.
+	Drop string `json:"drop,omitempty"`
+
+	// Lifetime counts the drops caught in all games rather than
+	// in a single game. Catch only.
+	Lifetime bool `json:"lifetime,omitempty"`
+
+	// Avoid is the name of a drop that must not be caught in the
+	// game. Points only.
+	Avoid string `json:"avoid,omitempty"`
+}
+
+// Defs is the list of achievements of the game, in the order shown.
+type Defs []*Def
+
+// Parse reads and validates achievements in JSON format.
+func Parse(r io.Reader) (Defs, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var ds Defs
+	if err := dec.Decode(&ds); err != nil {
+		return nil, fmt.Errorf("achievements: %v", err)
+	}
+	if err := ds.Validate(); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Validate returns an error if any of the achievements is invalid.
+func (ds Defs) Validate() error {
+	ids := make(map[string]bool)
+	for i, d := range ds {
+		if d == nil || d.ID == "" || d.Name == "" {
+			return fmt.Errorf("achievement %d: no id or name", i+1)
+		}
+		if ids[d.ID] {
+			return fmt.Errorf("achievement %q: id already used", d.ID)
+		}
+		ids[d.ID] = true
+		switch d.Kind {
+		case Catch, Points, Combo, Games, Streak:
+		default:
+			return fmt.Errorf("achievement %q: unknown kind %q", d.ID, d.Kind)
+		}
+		switch {
+		case d.Goal <= 0:
+			return fmt.Errorf("achievement %q: no goal", d.ID)
+		case (d.Drop != "" || d.Lifetime) && d.Kind != Catch:
+			return fmt.Errorf("achievement %q: drop or lifetime not of kind %s", d.ID, Catch)
+		case d.Avoid != "" && d.Kind != Points:
+			return fmt.Errorf("achievement %q: avoid not of kind %s", d.ID, Points)
+		}
+	}
+	return nil
+}
+
+// Check returns an error if any of the achievements has drops that are
+// not in the given list of drop names.
+func (ds Defs) Check(names []string) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	for _, d := range ds {
+		for _, name := range []string{d.Drop, d.Avoid} {
+			if name != "" && !known[name] {
+				return fmt.Errorf("achievement %q: unknown drop %q", d.ID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Cumulative returns true if the count of the achievement's goal
+// carries over games, rather than starting over every game.
+func (d *Def) Cumulative() bool {
+	return d.Kind == Games || d.Kind == Streak || d.Lifetime
+}
+
+// Value returns the count of the achievement's goal, for the game of
+// the session s, which is not in the lifetime stats l yet, and the
+// progress p. The game of the session counts as played. The session is
+// nil when no game is being played.
+func (d *Def) Value(s *stats.Session, l *stats.Lifetime, p *Progress) int64 {
+	games := l.Games
+	if s == nil {
+		s = stats.NewSession()
+	} else {
+		games++
+	}
+	switch d.Kind {
+	case Catch:
+		n := caught(s, d.Drop)
+		if d.Lifetime {
+			n += caught(&l.Session, d.Drop)
+		}
+		return int64(n)
+	case Points:
+		if d.Avoid != "" && caught(s, d.Avoid) > 0 {
+			return 0
+		}
+		return s.Points
+	case Combo:
+		return int64(s.Combo)
+	case Games:
+		return int64(games)
+	case Streak:
+		return int64(p.Streak)
+	}
+	return 0
+}
+
+// caught returns the number of the named drops caught, power-ups
+// included, or of all good drops if name is empty.
+func caught(s *stats.Session, name string) int {
+	if name == "" {
+		return s.Caught.Total()
+	}
+	return s.Caught[name] + s.BadHits[name] + s.PowerUps[name]
+}
+
+// Progress is the progress of the achievements of a profile.
+type Progress struct {
+	Unlocked map[string]time.Time `json:"unlocked"` // by id
+	Day      string               `json:"day"`      // last day played, e.g. 2017-12-31
+	Streak   int                  `json:"streak"`   // days played in a row, up to Day
+}
+
+// NewProgress returns the progress of a profile with no achievements.
+func NewProgress() *Progress {
+	return &Progress{Unlocked: make(map[string]time.Time)}
+}
+
+// Play records a game played at now, for the streak of days played.
+func (p *Progress) Play(now time.Time) {
+	const layout = "2006-01-02"
+	today := now.Format(layout)
+	switch p.Day {
+	case today:
+		return
+	case now.AddDate(0, 0, -1).Format(layout):
+		p.Streak++
+	default:
+		p.Streak = 1
+	}
+	p.Day = today
+}
+
+// Check unlocks the achievements whose goals are met, as of now, and
+// returns the ones newly unlocked. See Def.Value for s and l.
+func (p *Progress) Check(ds Defs, now time.Time, s *stats.Session, l *stats.Lifetime) []*Def {
+	var unlocked []*Def
+	for _, d := range ds {
+		if _, ok := p.Unlocked[d.ID]; ok {
+			continue
+		}
+		if d.Value(s, l, p) >= d.Goal {
+			p.Unlocked[d.ID] = now
+			unlocked = append(unlocked, d)
+		}
+	}
+	return unlocked
+}
+
+// Status is the status of an achievement, for the gallery.
+type Status struct {
+	*Def
+	Unlocked time.Time // zero if locked
+	Count    int64     // count of the goal, up to the goal
+}
+
+// Status returns the status of the achievements, unlocked first in
+// the order unlocked, then the locked ones in the order defined. See
+// Def.Value for s and l.
+func (p *Progress) Status(ds Defs, s *stats.Session, l *stats.Lifetime) []Status {
+	st := make([]Status, len(ds))
+	for i, d := range ds {
+		v := d.Value(s, l, p)
+		if v > d.Goal {
+			v = d.Goal
+		}
+		st[i] = Status{Def: d, Unlocked: p.Unlocked[d.ID], Count: v}
+		if !st[i].Unlocked.IsZero() {
+			st[i].Count = d.Goal
+		}
+	}
+	sort.SliceStable(st, func(i, j int) bool {
+		a, b := st[i].Unlocked, st[j].Unlocked
+		return !a.IsZero() && (b.IsZero() || a.Before(b))
+	})
+	return st
+}
+
+// Achievements is the progress of the achievements of each profile.
+type Achievements struct {
+	Version  int                  `json:"version"`
+	Profiles map[string]*Progress `json:"profiles"`
+}
+
+// New returns the progress of no profiles.
+func New() *Achievements {
+	return &Achievements{Version: Version, Profiles: make(map[string]*Progress)}
+}
+
+// Load loads the progress from the store. Missing progress is empty.
+// Progress that fails to load is kept in the store as File.bad for
+// inspection, and replaced by empty progress returned with an error,
+// so the game can go on.
+func Load(st store.Store) (*Achievements, error) {
+	b, err := st.Load(File)
+	switch {
+	case errors.Is(err, store.ErrNotExist):
+		return New(), nil
+	case err != nil:
+		return New(), err
+	}
+	a := New()
+	err = json.Unmarshal(b, a)
+	if err == nil && a.Version > Version {
+		err = fmt.Errorf("unsupported version %d", a.Version)
+	}
+	if err != nil {
+		st.Save(File+".bad", b)
+		return New(), fmt.Errorf("achievements: %v", err)
+	}
+	a.Version = Version
+	if a.Profiles == nil {
+		a.Profiles = make(map[string]*Progress)
+	}
+	for name, p := range a.Profiles {
+		switch {
+		case p == nil:
+			delete(a.Profiles, name)
+		case p.Unlocked == nil:
+			p.Unlocked = make(map[string]time.Time)
+		}
+	}
+	return a, nil
+}
+
+// Save saves the progress to the store.
+func (a *Achievements) Save(st store.Store) error {
+	b, err := json.MarshalIndent(a, "", "\t")
+	if err != nil {
+		return err
+	}
+	return st.Save(File, b)
+}
+
+// Profile returns the progress of the named profile.
+func (a *Achievements) Profile(name string) *Progress {
+	p, ok := a.Profiles[name]
+	if !ok {
+		p = NewProgress()
+		a.Profiles[name] = p
+	}
+	return p
+}
@@ -0,0 +1,285 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package achievement
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"achievements", `[{"id": "a", "name": "A", "kind": "catch", "drop": "bacon", "lifetime": true, "goal": 10}, {"id": "b", "name": "B", "kind": "points", "avoid": "pepper", "goal": 100}]`, true},
+		{"none", `[]`, true},
+		{"no id", `[{"name": "A", "kind": "games", "goal": 1}]`, false},
+		{"no name", `[{"id": "a", "kind": "games", "goal": 1}]`, false},
+		{"same id", `[{"id": "a", "name": "A", "kind": "games", "goal": 1}, {"id": "a", "name": "B", "kind": "combo", "goal": 1}]`, false},
+		{"unknown kind", `[{"id": "a", "name": "A", "kind": "dance", "goal": 1}]`, false},
+		{"no goal", `[{"id": "a", "name": "A", "kind": "games"}]`, false},
+		{"drop not caught", `[{"id": "a", "name": "A", "kind": "points", "drop": "bacon", "goal": 1}]`, false},
+		{"lifetime not caught", `[{"id": "a", "name": "A", "kind": "games", "lifetime": true, "goal": 1}]`, false},
+		{"avoid not points", `[{"id": "a", "name": "A", "kind": "catch", "avoid": "bacon", "goal": 1}]`, false},
+		{"unknown field", `[{"id": "a", "name": "A", "kind": "games", "goal": 1, "secret": true}]`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ds := Defs{
+		{ID: "a", Kind: Catch, Drop: "bacon"},
+		{ID: "b", Kind: Points, Avoid: "pepper"},
+	}
+	for _, tc := range []struct {
+		name  string
+		names []string
+		ok    bool
+	}{
+		{"known", []string{"bacon", "pepper"}, true},
+		{"unknown drop", []string{"pepper"}, false},
+		{"unknown avoid", []string{"bacon"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ds.Check(tc.names); (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestDefValue(t *testing.T) {
+	s := stats.NewSession()
+	s.Catch("bacon", false)
+	s.Catch("pepper", true)
+	s.SetCombo(7)
+	s.Points = 120
+	l := stats.NewLifetime()
+	l.Add(s)
+	p := NewProgress()
+	p.Streak = 3
+	for _, tc := range []struct {
+		name string
+		def  Def
+		s    *stats.Session
+		want int64
+	}{
+		{"points", Def{Kind: Points}, s, 120},
+		{"points avoided", Def{Kind: Points, Avoid: "donut"}, s, 120},
+		{"points not avoided", Def{Kind: Points, Avoid: "pepper"}, s, 0},
+		{"combo", Def{Kind: Combo}, s, 7},
+		{"games", Def{Kind: Games}, s, 2},
+		{"games not playing", Def{Kind: Games}, nil, 1},
+		{"streak", Def{Kind: Streak}, s, 3},
+		{"caught not playing", Def{Kind: Catch, Lifetime: true}, nil, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := tc.def.Value(tc.s, l, p); v != tc.want {
+				t.Fatalf("got %d, want %d", v, tc.want)
+			}
+		})
+	}
+}
+
+func TestProgressPlay(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		plays  []string // times played, in order
+		streak int
+		day    string
+	}{
+		{"first", []string{"2017-12-30 10:00"}, 1, "2017-12-30"},
+		{"same day", []string{"2017-12-30 10:00", "2017-12-30 23:59"}, 1, "2017-12-30"},
+		{"next day", []string{"2017-12-30 23:59", "2017-12-31 00:01"}, 2, "2017-12-31"},
+		{"new year", []string{"2017-12-30 10:00", "2017-12-31 10:00", "2018-01-01 10:00"}, 3, "2018-01-01"},
+		{"leap day", []string{"2016-02-28 10:00", "2016-02-29 10:00", "2016-03-01 10:00"}, 3, "2016-03-01"},
+		{"day missed", []string{"2017-12-29 10:00", "2017-12-30 10:00", "2018-01-01 10:00"}, 1, "2018-01-01"},
+		{"again", []string{"2017-12-28 10:00", "2017-12-30 10:00", "2017-12-31 10:00"}, 2, "2017-12-31"},
+		{"clock back", []string{"2017-12-31 10:00", "2017-12-30 10:00"}, 1, "2017-12-30"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewProgress()
+			for _, s := range tc.plays {
+				now, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.Play(now)
+			}
+			if p.Streak != tc.streak || p.Day != tc.day {
+				t.Fatalf("got streak %d on %s, want %d on %s", p.Streak, p.Day, tc.streak, tc.day)
+			}
+		})
+	}
+}
+
+func TestDefValueCatch(t *testing.T) {
+	s := stats.NewSession()
+	s.Catch("bacon", false)
+	s.Catch("bacon", false)
+	s.Catch("tomato", true)
+	s.CatchPowerUp("magnet")
+	l := stats.NewLifetime()
+	l.Add(s)
+	for _, tc := range []struct {
+		name string
+		def  Def
+		want int64
+	}{
+		{"good drops", Def{Kind: Catch}, 2},
+		{"good drops in all", Def{Kind: Catch, Lifetime: true}, 4},
+		{"named", Def{Kind: Catch, Drop: "bacon"}, 2},
+		{"bad", Def{Kind: Catch, Drop: "tomato"}, 1},
+		{"power-up", Def{Kind: Catch, Drop: "magnet", Lifetime: true}, 2},
+		{"none", Def{Kind: Catch, Drop: "fish"}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := tc.def.Value(s, l, NewProgress()); v != tc.want {
+				t.Fatalf("got %d, want %d", v, tc.want)
+			}
+		})
+	}
+}
+
+func TestProgressCheck(t *testing.T) {
+	ds := Defs{
+		{ID: "first", Kind: Catch, Goal: 1},
+		{ID: "games", Kind: Games, Goal: 3},
+		{ID: "points", Kind: Points, Goal: 100},
+	}
+	s := stats.NewSession()
+	l := stats.NewLifetime()
+	p := NewProgress()
+	t0 := time.Date(2017, 12, 31, 10, 0, 0, 0, time.UTC)
+	if got := p.Check(ds, t0, s, l); len(got) != 0 {
+		t.Fatalf("got %d unlocked, want none", len(got))
+	}
+	s.Catch("bacon", false)
+	s.Points = 150
+	if got := p.Check(ds, t0, s, l); !reflect.DeepEqual(got, []*Def{ds[0], ds[2]}) {
+		t.Fatalf("got unlocked %v, want first and points", got)
+	}
+	if got := p.Check(ds, t0.Add(time.Minute), s, l); len(got) != 0 {
+		t.Fatalf("got %d unlocked again, want none", len(got))
+	}
+	l.Add(s)
+	st := p.Status(ds, nil, l)
+	for i, want := range []struct {
+		id    string
+		count int64
+	}{
+		{"first", 1},
+		{"points", 100},
+		{"games", 1},
+	} {
+		if st[i].ID != want.id || st[i].Count != want.count {
+			t.Fatalf("got status %d %s %d, want %s %d", i, st[i].ID, st[i].Count, want.id, want.count)
+		}
+	}
+	if !st[0].Unlocked.Equal(t0) || !st[2].Unlocked.IsZero() {
+		t.Fatalf("got unlocked %v and %v, want %v and locked", st[0].Unlocked, st[2].Unlocked, t0)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     string // saved progress, empty for none
+		profiles []string
+		err      bool
+	}{
+		{"missing", "", nil, false},
+		{"saved", `{"version": 1, "profiles": {"Tom": {"unlocked": {"first": "2017-12-31T10:00:00Z"}, "day": "2017-12-31", "streak": 2}, "Ana": {}, "Bob": null}}`, []string{"Ana", "Tom"}, false},
+		{"corrupt", `{"profiles": `, nil, true},
+		{"newer version", `{"version": 2}`, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := store.Dir(t.TempDir())
+			if tc.data != "" {
+				st.Save(File, []byte(tc.data))
+			}
+			a, err := Load(st)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			var profiles []string
+			for name, p := range a.Profiles {
+				profiles = append(profiles, name)
+				// progress is never nil, so the game can unlock
+				p.Unlocked["x"] = time.Time{}
+			}
+			sort.Strings(profiles)
+			if !reflect.DeepEqual(profiles, tc.profiles) {
+				t.Fatalf("got profiles %v, want %v", profiles, tc.profiles)
+			}
+			if _, err := st.Load(File + ".bad"); tc.err != (err == nil) {
+				t.Fatalf("got error %v loading the bad progress, want it kept %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	st := store.Dir(t.TempDir())
+	want := New()
+	p := want.Profile("Tom")
+	p.Play(time.Date(2017, 12, 31, 10, 0, 0, 0, time.Local))
+	p.Unlocked["first"] = time.Date(2017, 12, 31, 10, 0, 0, 0, time.UTC)
+	if err := want.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	ds, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf, err := os.Open("../" + catalog.DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cf.Close()
+	c, err := catalog.Parse(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range c {
+		names = append(names, d.Name)
+	}
+	if err = ds.Check(names); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package catalog provides the drop catalog: the definitions of the
+// drops of the game, such as their names and movement behaviors. It
+// is shared by the SDL and wasm versions.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fiorix/cat-o-licious/motion"
+)
+
+// DefaultFile is the drop catalog file, relative to the game.
+const DefaultFile = "assets/drops.json"
+
+// Def is the definition of a drop.
+type Def struct {
+	// Name is the unique name of the drop, e.g. bacon.
+	Name string `json:"name"`
+
+	// Motion is the list of movement behaviors of the drop. Drops
+	// without motions fall straight down.
+	Motion []motion.Def `json:"motion"`
+
+	motions []motion.Motion
+}
+
+// Motions returns the movement behaviors of the drop.
+func (d *Def) Motions() []motion.Motion {
+	return d.motions
+}
+
+// Catalog is the drop catalog, indexed by sprite name, which is the
+// name of the drop's image file without extension (e.g. drop_good_3).
+type Catalog map[string]*Def
+
+// Parse reads and validates a drop catalog in JSON format.
+func Parse(r io.Reader) (Catalog, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var c Catalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("drop catalog: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if any of the definitions is invalid,
+// and prepares the movement behaviors of valid definitions.
+func (c Catalog) Validate() error {
+	names := make(map[string]string)
+	for sprite, d := range c {
+		if d == nil || d.Name == "" {
+			return fmt.Errorf("drop %q: no name", sprite)
+		}
+		if other, ok := names[d.Name]; ok {
+			return fmt.Errorf("drop %q: name %q already used by %q", sprite, d.Name, other)
+		}
+		names[d.Name] = sprite
+		d.motions = d.motions[:0]
+		for i, md := range d.Motion {
+			m, err := motion.New(md)
+			if err != nil {
+				return fmt.Errorf("drop %q: motion %d: %v", d.Name, i, err)
+			}
+			d.motions = append(d.motions, m)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the definition of the drop with the given sprite
+// name. Drops without definition are named after their sprite and
+// fall straight down.
+func (c Catalog) Lookup(sprite string) *Def {
+	if d, ok := c[sprite]; ok {
+		return d
+	}
+	return &Def{Name: sprite}
+}
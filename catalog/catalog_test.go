@@ -0,0 +1,79 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package catalog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fiorix/cat-o-licious/motion"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		json    string
+		motions int // of drop_good_1
+		ok      bool
+	}{
+		{"empty", `{}`, 0, true},
+		{"straight", `{"drop_good_1": {"name": "bacon"}}`, 0, true},
+		{"motions", `{"drop_good_1": {"name": "bacon", "motion": [
+			{"type": "zigzag", "amplitude": 20, "period": 60},
+			{"type": "drift", "wind": 1}
+		]}}`, 2, true},
+		{"no name", `{"drop_good_1": {}}`, 0, false},
+		{"no definition", `{"drop_good_1": null}`, 0, false},
+		{"name taken", `{"drop_good_1": {"name": "bacon"}, "drop_good_2": {"name": "bacon"}}`, 0, false},
+		{"bad motion", `{"drop_good_1": {"name": "bacon", "motion": [{"type": "teleport"}]}}`, 0, false},
+		{"unknown field", `{"drop_good_1": {"name": "bacon", "points": 5}}`, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+			if err != nil {
+				return
+			}
+			if n := len(c.Lookup("drop_good_1").Motions()); n != tc.motions {
+				t.Fatalf("got %d motions, want %d", n, tc.motions)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c := Catalog{"drop_good_1": {Name: "bacon", Motion: []motion.Def{{Type: "drift", Wind: 1}}}}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sprite  string
+		name    string
+		motions int
+	}{
+		{"drop_good_1", "bacon", 1},
+		{"drop_good_2", "drop_good_2", 0},
+	} {
+		d := c.Lookup(tc.sprite)
+		if d.Name != tc.name || len(d.Motions()) != tc.motions {
+			t.Fatalf("%s: got %q with %d motions, want %q with %d", tc.sprite, d.Name, len(d.Motions()), tc.name, tc.motions)
+		}
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
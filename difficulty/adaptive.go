@@ -0,0 +1,137 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package difficulty
+
+import (
+	"fmt"
+	"time"
+)
+
+// Outcome is the outcome of a drop for the player.
+type Outcome int
+
+// Outcomes tracked by the adaptive difficulty.
+const (
+	Caught Outcome = iota // good drop caught
+	BadHit                // bad drop caught
+	Missed                // good drop hit the floor
+)
+
+// Adaptive adjusts the difficulty to the player's performance. It
+// tracks the outcomes of recent drops, and nudges the rain
+// parameters of the difficulty curve to keep the player's success
+// rate within a target band: harder when the player is doing well,
+// easier when the player is struggling.
+type Adaptive struct {
+	Window  time.Duration // time window of recent outcomes
+	Low     float64       // lower bound of the target success rate
+	High    float64       // upper bound of the target success rate
+	Step    float64       // adjustment per update
+	Samples int           // minimum outcomes in the window to adjust
+
+	outcomes []outcome
+	adjust   float64 // from -1 (easiest) to 1 (hardest)
+}
+
+type outcome struct {
+	t time.Time
+	o Outcome
+}
+
+// NewAdaptive creates an adaptive difficulty with default settings.
+func NewAdaptive() *Adaptive {
+	return &Adaptive{
+		Window:  20 * time.Second,
+		Low:     .6,
+		High:    .85,
+		Step:    .1,
+		Samples: 5,
+	}
+}
+
+// Record records the outcome of a drop.
+func (a *Adaptive) Record(now time.Time, o Outcome) {
+	a.outcomes = append(a.outcomes, outcome{now, o})
+}
+
+// Reset forgets the outcomes recorded and the adjustment, for a new
+// game or a new player.
+func (a *Adaptive) Reset() {
+	a.outcomes = a.outcomes[:0]
+	a.adjust = 0
+}
+
+// Success returns the success rate and the number of outcomes in
+// the window. The success rate is the ratio of good drops caught to
+// all outcomes.
+func (a *Adaptive) Success(now time.Time) (rate float64, n int) {
+	// drain outcomes older than the window
+	i := 0
+	for i < len(a.outcomes) && now.Sub(a.outcomes[i].t) > a.Window {
+		i++
+	}
+	a.outcomes = append(a.outcomes[:0], a.outcomes[i:]...)
+	caught := 0
+	for _, o := range a.outcomes {
+		if o.o == Caught {
+			caught++
+		}
+	}
+	n = len(a.outcomes)
+	if n == 0 {
+		return 0, 0
+	}
+	return float64(caught) / float64(n), n
+}
+
+// Update nudges the adjustment toward the target band, and returns
+// true if the adjustment has changed.
+func (a *Adaptive) Update(now time.Time) bool {
+	rate, n := a.Success(now)
+	if n < a.Samples {
+		return false
+	}
+	prev := a.adjust
+	switch {
+	case rate > a.High:
+		a.adjust += a.Step
+	case rate < a.Low:
+		a.adjust -= a.Step
+	}
+	if a.adjust > 1 {
+		a.adjust = 1
+	} else if a.adjust < -1 {
+		a.adjust = -1
+	}
+	return a.adjust != prev
+}
+
+// Adjustment returns the current adjustment, from -1 (easiest) to
+// 1 (hardest).
+func (a *Adaptive) Adjustment() float64 {
+	return a.adjust
+}
+
+// Apply returns the rain parameters adjusted to the player: up to
+// 40% shorter or longer intervals between drops, and up to 15% more
+// or less bad drops.
+func (a *Adaptive) Apply(p Params) Params {
+	p.Interval = time.Duration(float64(p.Interval) * (1 - .4*a.adjust))
+	p.BadRatio += .15 * a.adjust
+	if p.BadRatio < 0 {
+		p.BadRatio = 0
+	} else if p.BadRatio > .9 {
+		p.BadRatio = .9
+	}
+	return p
+}
+
+// Status returns a description of the adjustment, for display or
+// logging.
+func (a *Adaptive) Status(now time.Time) string {
+	rate, n := a.Success(now)
+	return fmt.Sprintf("adaptive %+.1f success %.0f%% of %d", a.adjust, rate*100, n)
+}
@@ -0,0 +1,114 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package difficulty
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSuccess(t *testing.T) {
+	a := NewAdaptive()
+	now := time.Now()
+	a.Record(now.Add(-time.Minute), Missed) // out of the window
+	a.Record(now, Caught)
+	a.Record(now, Caught)
+	a.Record(now, BadHit)
+	a.Record(now, Missed)
+	rate, n := a.Success(now)
+	if rate != .5 || n != 4 {
+		t.Fatalf("got rate %v of %d, want .5 of 4", rate, n)
+	}
+	if rate, n := a.Success(now.Add(time.Hour)); rate != 0 || n != 0 {
+		t.Fatalf("got rate %v of %d after the window, want none", rate, n)
+	}
+}
+
+func TestAdaptiveUpdate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		outcomes []Outcome
+		changed  bool
+		adjust   float64
+	}{
+		{"too few", []Outcome{Caught, Caught}, false, 0},
+		{"doing well", []Outcome{Caught, Caught, Caught, Caught, Caught}, true, .1},
+		{"in the band", []Outcome{Caught, Caught, Caught, Caught, Missed}, false, 0},
+		{"struggling", []Outcome{Caught, BadHit, Missed, Missed, Caught}, true, -.1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAdaptive()
+			now := time.Now()
+			for _, o := range tc.outcomes {
+				a.Record(now, o)
+			}
+			if changed := a.Update(now); changed != tc.changed || math.Abs(a.Adjustment()-tc.adjust) > 1e-9 {
+				t.Fatalf("got changed %v to %v, want %v to %v", changed, a.Adjustment(), tc.changed, tc.adjust)
+			}
+		})
+	}
+}
+
+func TestAdaptiveUpdateLimit(t *testing.T) {
+	a := NewAdaptive()
+	now := time.Now()
+	for range a.Samples {
+		a.Record(now, Caught)
+	}
+	for range 100 {
+		a.Update(now)
+	}
+	if a.Adjustment() != 1 {
+		t.Fatalf("got adjustment %v, want at most 1", a.Adjustment())
+	}
+}
+
+func TestAdaptiveApply(t *testing.T) {
+	p := Params{Interval: time.Second, BadRatio: .5}
+	for _, tc := range []struct {
+		name     string
+		adjust   float64
+		interval time.Duration
+		bad      float64
+	}{
+		{"none", 0, time.Second, .5},
+		{"hardest", 1, 600 * time.Millisecond, .65},
+		{"easiest", -1, 1400 * time.Millisecond, .35},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAdaptive()
+			a.adjust = tc.adjust
+			got := a.Apply(p)
+			if got.Interval != tc.interval || math.Abs(got.BadRatio-tc.bad) > 1e-9 {
+				t.Fatalf("got interval %v and bad ratio %v, want %v and %v", got.Interval, got.BadRatio, tc.interval, tc.bad)
+			}
+		})
+	}
+	a := NewAdaptive()
+	a.adjust = 1
+	if got := a.Apply(Params{BadRatio: .85}); got.BadRatio != .9 {
+		t.Fatalf("got bad ratio %v, want at most .9", got.BadRatio)
+	}
+}
+
+func TestAdaptiveReset(t *testing.T) {
+	a := NewAdaptive()
+	now := time.Now()
+	for range a.Samples {
+		a.Record(now, Caught)
+	}
+	if !a.Update(now) || a.Adjustment() == 0 {
+		t.Fatalf("got adjustment %v, want harder", a.Adjustment())
+	}
+	a.Reset()
+	if a.Adjustment() != 0 {
+		t.Fatalf("got adjustment %v after reset, want 0", a.Adjustment())
+	}
+	if _, n := a.Success(now); n != 0 {
+		t.Fatalf("got %d outcomes after reset, want 0", n)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package difficulty provides the difficulty of the game as curves
+// of the player's progress, and named presets of those curves. It is
+// shared by the SDL and wasm versions.
+package difficulty
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Params are the rain parameters at a given difficulty.
+type Params struct {
+	Interval time.Duration // time between new drops
+	MinSpeed int           // minimum fall speed, in pixels per frame
+	MaxSpeed int           // maximum fall speed, in pixels per frame
+	BadRatio float64       // chance of a new drop being bad
+}
+
+// Speed returns a fall speed within the range for the given
+// random number in [0, 1).
+func (p Params) Speed(rnd float64) int {
+	return p.MinSpeed + int(rnd*float64(p.MaxSpeed-p.MinSpeed+1))
+}
+
+// lerp interpolates params a and b by t in [0, 1].
+func lerp(a, b Params, t float64) Params {
+	f := func(x, y float64) float64 { return x + (y-x)*t }
+	return Params{
+		Interval: time.Duration(f(float64(a.Interval), float64(b.Interval))),
+		MinSpeed: int(math.Round(f(float64(a.MinSpeed), float64(b.MinSpeed)))),
+		MaxSpeed: int(math.Round(f(float64(a.MaxSpeed), float64(b.MaxSpeed)))),
+		BadRatio: f(a.BadRatio, b.BadRatio),
+	}
+}
+
+// Progress is the player's progress in the game.
+type Progress struct {
+	Points  int64         // current score
+	Elapsed time.Duration // time since the game started
+}
+
+// Curve maps the player's progress to a difficulty in [0, 1].
+type Curve interface {
+	At(p Progress) float64
+}
+
+// clamp clamps t to [0, 1].
+func clamp(t float64) float64 {
+	return math.Max(0, math.Min(1, t))
+}
+
+// Linear increases the difficulty linearly with points, reaching
+// the maximum at the given points.
+type Linear struct {
+	Points int64
+}
+
+// At implements the Curve interface.
+func (c Linear) At(p Progress) float64 {
+	return clamp(float64(p.Points) / float64(c.Points))
+}
+
+// Stepped increases the difficulty every step of points, reaching
+// the maximum after the given number of steps.
+type Stepped struct {
+	Step  int64
+	Steps int
+}
+
+// At implements the Curve interface.
+func (c Stepped) At(p Progress) float64 {
+	if p.Points < 0 {
+		return 0
+	}
+	return clamp(float64(p.Points/c.Step) / float64(c.Steps))
+}
+
+// Exponential increases the difficulty quickly at first and slowly
+// later, getting about 2/3 of the way at the given points.
+type Exponential struct {
+	Points int64
+}
+
+// At implements the Curve interface.
+func (c Exponential) At(p Progress) float64 {
+	return clamp(1 - math.Exp(-float64(p.Points)/float64(c.Points)))
+}
+
+// Timed increases the difficulty linearly with the time played,
+// regardless of points, reaching the maximum at the given duration.
+type Timed struct {
+	Duration time.Duration
+}
+
+// At implements the Curve interface.
+func (c Timed) At(p Progress) float64 {
+	return clamp(float64(p.Elapsed) / float64(c.Duration))
+}
+
+// Preset is a named difficulty: a curve between the rain parameters
+// at the start of the game and at the maximum difficulty.
+type Preset struct {
+	Name  string
+	Curve Curve
+	Start Params
+	End   Params
+}
+
+// At returns the rain parameters for the player's progress.
+func (p Preset) At(pr Progress) Params {
+	return lerp(p.Start, p.End, p.Curve.At(pr))
+}
+
+// Presets are the difficulty presets, from the easiest to the hardest.
+var Presets = []Preset{
+	{
+		Name:  "toddler",
+		Curve: Timed{Duration: 10 * time.Minute},
+		Start: Params{Interval: 1600 * time.Millisecond, MinSpeed: 3, MaxSpeed: 6, BadRatio: .2},
+		End:   Params{Interval: 800 * time.Millisecond, MinSpeed: 4, MaxSpeed: 8, BadRatio: .3},
+	},
+	{
+		Name:  "easy",
+		Curve: Linear{Points: 10000},
+		Start: Params{Interval: 1200 * time.Millisecond, MinSpeed: 4, MaxSpeed: 10, BadRatio: .35},
+		End:   Params{Interval: 400 * time.Millisecond, MinSpeed: 5, MaxSpeed: 12, BadRatio: .45},
+	},
+	{
+		// the original difficulty: 100ms less between drops
+		// every 1000 points
+		Name:  "normal",
+		Curve: Stepped{Step: 1000, Steps: 9},
+		Start: Params{Interval: time.Second, MinSpeed: 5, MaxSpeed: 14, BadRatio: .5},
+		End:   Params{Interval: 100 * time.Millisecond, MinSpeed: 5, MaxSpeed: 14, BadRatio: .5},
+	},
+	{
+		Name:  "hard",
+		Curve: Exponential{Points: 3000},
+		Start: Params{Interval: 700 * time.Millisecond, MinSpeed: 7, MaxSpeed: 16, BadRatio: .5},
+		End:   Params{Interval: 80 * time.Millisecond, MinSpeed: 10, MaxSpeed: 20, BadRatio: .65},
+	},
+}
+
+// Default is the name of the default preset.
+const Default = "normal"
+
+// Names returns the names of the presets.
+func Names() []string {
+	names := make([]string, len(Presets))
+	for i, p := range Presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Lookup returns the preset with the given name.
+func Lookup(name string) (Preset, error) {
+	for _, p := range Presets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("unknown difficulty %q, want one of: %s", name, strings.Join(Names(), ", "))
+}
@@ -0,0 +1,99 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package difficulty
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCurves(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		c    Curve
+		p    Progress
+		want float64
+	}{
+		{"linear start", Linear{Points: 1000}, Progress{}, 0},
+		{"linear half", Linear{Points: 1000}, Progress{Points: 500}, .5},
+		{"linear max", Linear{Points: 1000}, Progress{Points: 5000}, 1},
+		{"linear negative", Linear{Points: 1000}, Progress{Points: -100}, 0},
+		{"stepped within a step", Stepped{Step: 100, Steps: 4}, Progress{Points: 199}, .25},
+		{"stepped max", Stepped{Step: 100, Steps: 4}, Progress{Points: 1000}, 1},
+		{"stepped negative", Stepped{Step: 100, Steps: 4}, Progress{Points: -150}, 0},
+		{"exponential start", Exponential{Points: 100}, Progress{}, 0},
+		{"exponential", Exponential{Points: 100}, Progress{Points: 100}, 1 - math.Exp(-1)},
+		{"timed half", Timed{Duration: time.Minute}, Progress{Points: 1000, Elapsed: 30 * time.Second}, .5},
+		{"timed max", Timed{Duration: time.Minute}, Progress{Elapsed: time.Hour}, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if d := tc.c.At(tc.p); math.Abs(d-tc.want) > 1e-9 {
+				t.Fatalf("got %v, want %v", d, tc.want)
+			}
+		})
+	}
+}
+
+func TestPresetAt(t *testing.T) {
+	p := Preset{
+		Curve: Linear{Points: 100},
+		Start: Params{Interval: time.Second, MinSpeed: 2, MaxSpeed: 10, BadRatio: .2},
+		End:   Params{Interval: 200 * time.Millisecond, MinSpeed: 4, MaxSpeed: 20, BadRatio: .6},
+	}
+	for _, tc := range []struct {
+		name   string
+		points int64
+		want   Params
+	}{
+		{"start", 0, p.Start},
+		{"half", 50, Params{Interval: 600 * time.Millisecond, MinSpeed: 3, MaxSpeed: 15, BadRatio: .4}},
+		{"end", 100, p.End},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.At(Progress{Points: tc.points})
+			if got.Interval != tc.want.Interval || got.MinSpeed != tc.want.MinSpeed ||
+				got.MaxSpeed != tc.want.MaxSpeed || math.Abs(got.BadRatio-tc.want.BadRatio) > 1e-9 {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParamsSpeed(t *testing.T) {
+	p := Params{MinSpeed: 5, MaxSpeed: 14}
+	for _, tc := range []struct {
+		rnd  float64
+		want int
+	}{
+		{0, 5},
+		{.5, 10},
+		{.999, 14},
+	} {
+		if s := p.Speed(tc.rnd); s != tc.want {
+			t.Fatalf("got speed %d for %v, want %d", s, tc.rnd, tc.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range Names() {
+		p, err := Lookup(name)
+		if err != nil || p.Name != name {
+			t.Fatalf("got %q, %v for %q", p.Name, err, name)
+		}
+		start, end := p.At(Progress{}), p.At(Progress{Points: 1e9, Elapsed: 24 * time.Hour})
+		if end.Interval > start.Interval {
+			t.Fatalf("%s: got interval %v at the end, want at most %v", name, end.Interval, start.Interval)
+		}
+	}
+	if _, err := Lookup(Default); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Lookup("impossible"); err == nil {
+		t.Fatal("got no error for an unknown difficulty")
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package event provides the event bus of the game: subsystems such as
+// the score, sound, effects, stats and achievements publish what
+// happens in the game, e.g. a drop caught, and subscribe to what they
+// react to, rather than calling each other. Events are typed by their
+// Go type, and defined by the SDL and wasm versions of the game.
+package event
+
+import "reflect"
+
+// Bus delivers the events published to the handlers subscribed to
+// their type, in the order subscribed, before Publish returns. Handlers
+// may publish events too, which are delivered right away. The bus is
+// not safe for concurrent use: the game publishes from its main loop.
+type Bus struct {
+	handlers map[reflect.Type][]any
+}
+
+// NewBus returns a bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]any)}
+}
+
+// Subscribe subscribes f to the events of type E published to the bus.
+func Subscribe[E any](b *Bus, f func(E)) {
+	t := reflect.TypeFor[E]()
+	b.handlers[t] = append(b.handlers[t], f)
+}
+
+// Publish delivers the event e to the handlers of its type.
+func Publish[E any](b *Bus, e E) {
+	for _, h := range b.handlers[reflect.TypeFor[E]()] {
+		h.(func(E))(e)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package event
+
+import (
+	"reflect"
+	"testing"
+)
+
+type caught struct{ name string }
+
+type scored struct{ points int64 }
+
+type named string
+
+func TestBus(t *testing.T) {
+	b := NewBus()
+	var got []string
+	Subscribe(b, func(e caught) { got = append(got, "first "+e.name) })
+	Subscribe(b, func(e caught) {
+		got = append(got, "second "+e.name)
+		Publish(b, scored{10})
+	})
+	Subscribe(b, func(e scored) { got = append(got, "scored") })
+	Subscribe(b, func(e named) { got = append(got, "named "+string(e)) })
+	for _, tc := range []struct {
+		name    string
+		publish func()
+		want    []string
+	}{
+		{"in order", func() { Publish(b, caught{"bacon"}) }, []string{"first bacon", "second bacon", "scored"}},
+		{"by type", func() { Publish(b, scored{5}) }, []string{"scored"}},
+		{"named type", func() { Publish(b, named("fish")) }, []string{"named fish"}},
+		{"underlying type", func() { Publish(b, "fish") }, nil},
+		{"pointer", func() { Publish(b, &caught{"bacon"}) }, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got = nil
+			tc.publish()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package fx provides visual effects for the game, shared by the
+// SDL and wasm versions. Effects are simulated here and drawn by
+// each version's renderer.
+package fx
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Color is an RGBA color.
+type Color struct{ R, G, B, A uint8 }
+
+// Particle is a single particle of an effect.
+type Particle struct {
+	X, Y    float64       // position, in pixels
+	VX, VY  float64       // velocity, in pixels per second
+	Gravity float64       // vertical acceleration, in pixels per second²
+	Size    float64       // width, in pixels
+	Color   Color         // color for particles without sprite
+	Sprite  interface{}   // optional image of the game's renderer
+	Fade    bool          // fade out over the particle's lifetime
+	Age     time.Duration // time since the particle was emitted
+	Life    time.Duration // lifetime of the particle
+}
+
+// Alpha returns the opacity of the particle, from 0 to 1.
+func (p *Particle) Alpha() float64 {
+	if !p.Fade || p.Life <= 0 {
+		return 1
+	}
+	a := 1 - float64(p.Age)/float64(p.Life)
+	if a < 0 {
+		return 0
+	}
+	return a
+}
+
+// Emitter describes a burst of particles.
+type Emitter struct {
+	Count       int           // number of particles per burst
+	Life        time.Duration // lifetime of each particle
+	Speed       float64       // initial speed, in pixels per second
+	SpeedJitter float64       // random variation of the speed
+	Angle       float64       // direction in radians, -π/2 is up
+	Spread      float64       // random variation of the angle
+	Gravity     float64       // vertical acceleration of particles
+	Size        float64       // width of particles, in pixels
+	SizeJitter  float64       // random variation of the size
+	Colors      []Color       // particle colors, picked at random
+	Sprite      interface{}   // optional image instead of colors
+	Fade        bool          // fade particles out
+}
+
+// WithSprite returns a copy of the emitter that emits the given sprite.
+func (e Emitter) WithSprite(sprite interface{}) Emitter {
+	e.Sprite = sprite
+	return e
+}
+
+// Emitters used by the game.
+var (
+	// Sparkles are emitted on good catches.
+	Sparkles = Emitter{
+		Count:       16,
+		Life:        600 * time.Millisecond,
+		Speed:       180,
+		SpeedJitter: 80,
+		Angle:       -math.Pi / 2,
+		Spread:      2 * math.Pi,
+		Gravity:     150,
+		Size:        6,
+		SizeJitter:  3,
+		Colors: []Color{
+			{255, 215, 0, 255},
+			{255, 255, 255, 255},
+			{255, 250, 150, 255},
+		},
+		Fade: true,
+	}
+
+	// Splat is emitted on bad catches.
+	Splat = Emitter{
+		Count:       20,
+		Life:        500 * time.Millisecond,
+		Speed:       220,
+		SpeedJitter: 100,
+		Angle:       -math.Pi / 2,
+		Spread:      math.Pi,
+		Gravity:     900,
+		Size:        8,
+		SizeJitter:  4,
+		Colors: []Color{
+			{60, 140, 40, 255},
+			{120, 170, 30, 255},
+			{200, 40, 30, 255},
+		},
+		Fade: true,
+	}
+
+	// Shielded is emitted when the shield absorbs a bad drop.
+	Shielded = Emitter{
+		Count:       24,
+		Life:        500 * time.Millisecond,
+		Speed:       240,
+		SpeedJitter: 40,
+		Angle:       -math.Pi / 2,
+		Spread:      2 * math.Pi,
+		Size:        6,
+		SizeJitter:  2,
+		Colors: []Color{
+			{80, 160, 255, 255},
+			{200, 230, 255, 255},
+		},
+		Fade: true,
+	}
+
+	// Crumbs are emitted when drops hit the floor. The game sets
+	// the sprite to the drop's image.
+	Crumbs = Emitter{
+		Count:       8,
+		Life:        700 * time.Millisecond,
+		Speed:       150,
+		SpeedJitter: 60,
+		Angle:       -math.Pi / 2,
+		Spread:      math.Pi * .6,
+		Gravity:     800,
+		Size:        12,
+		SizeJitter:  4,
+		Colors:      []Color{{160, 110, 60, 255}},
+		Fade:        true,
+	}
+)
+
+// System simulates particles emitted by emitters.
+type System struct {
+	particles []Particle
+}
+
+// Emit emits a burst of particles at x, y.
+func (s *System) Emit(e Emitter, x, y float64) {
+	for i := 0; i < e.Count; i++ {
+		angle := e.Angle + (rand.Float64()-.5)*e.Spread
+		speed := e.Speed + (rand.Float64()-.5)*e.SpeedJitter
+		p := Particle{
+			X:       x,
+			Y:       y,
+			VX:      math.Cos(angle) * speed,
+			VY:      math.Sin(angle) * speed,
+			Gravity: e.Gravity,
+			Size:    e.Size + (rand.Float64()-.5)*e.SizeJitter,
+			Sprite:  e.Sprite,
+			Fade:    e.Fade,
+			Life:    e.Life,
+		}
+		if len(e.Colors) > 0 {
+			p.Color = e.Colors[rand.Intn(len(e.Colors))]
+		}
+		s.particles = append(s.particles, p)
+	}
+}
+
+// Update advances the simulation by dt, and drains particles that
+// have reached the end of their lifetime.
+func (s *System) Update(dt time.Duration) {
+	sec := dt.Seconds()
+	kept := s.particles[:0]
+	for _, p := range s.particles {
+		p.Age += dt
+		if p.Age >= p.Life {
+			continue
+		}
+		p.VY += p.Gravity * sec
+		p.X += p.VX * sec
+		p.Y += p.VY * sec
+		kept = append(kept, p)
+	}
+	s.particles = kept
+}
+
+// Particles returns the live particles. The returned slice is only
+// valid until the next call to Emit or Update.
+func (s *System) Particles() []Particle {
+	return s.particles
+}
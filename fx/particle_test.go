@@ -0,0 +1,94 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package fx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParticleAlpha(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		p    Particle
+		want float64
+	}{
+		{"no fade", Particle{Age: 50, Life: 100}, 1},
+		{"no life", Particle{Fade: true}, 1},
+		{"new", Particle{Fade: true, Life: 100}, 1},
+		{"half", Particle{Fade: true, Age: 50, Life: 100}, .5},
+		{"past life", Particle{Fade: true, Age: 150, Life: 100}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if a := tc.p.Alpha(); a != tc.want {
+				t.Fatalf("got alpha %v, want %v", a, tc.want)
+			}
+		})
+	}
+}
+
+func TestSystemEmit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		e    Emitter
+	}{
+		{"sparkles", Sparkles},
+		{"splat", Splat},
+		{"shielded", Shielded},
+		{"crumbs", Crumbs.WithSprite("bacon")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var s System
+			s.Emit(tc.e, 10, 20)
+			ps := s.Particles()
+			if len(ps) != tc.e.Count {
+				t.Fatalf("got %d particles, want %d", len(ps), tc.e.Count)
+			}
+			for _, p := range ps {
+				if p.X != 10 || p.Y != 20 || p.Life != tc.e.Life || p.Sprite != tc.e.Sprite {
+					t.Fatalf("got particle %+v, want one at 10,20 of the emitter", p)
+				}
+				speed := math.Hypot(p.VX, p.VY)
+				if math.Abs(speed-tc.e.Speed) > tc.e.SpeedJitter/2+1e-9 {
+					t.Fatalf("got speed %v, want %v±%v", speed, tc.e.Speed, tc.e.SpeedJitter/2)
+				}
+				if !hasColor(tc.e.Colors, p.Color) {
+					t.Fatalf("got color %v, want one of %v", p.Color, tc.e.Colors)
+				}
+			}
+		})
+	}
+}
+
+func hasColor(colors []Color, c Color) bool {
+	for _, cc := range colors {
+		if cc == c {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSystemUpdate(t *testing.T) {
+	s := System{particles: []Particle{
+		{VX: 10, VY: -10, Gravity: 20, Life: time.Second},
+		{Life: 400 * time.Millisecond},
+	}}
+	s.Update(500 * time.Millisecond)
+	ps := s.Particles()
+	if len(ps) != 1 {
+		t.Fatalf("got %d particles, want 1 after the other's lifetime", len(ps))
+	}
+	p := ps[0]
+	if p.Age != 500*time.Millisecond || p.X != 5 || p.VY != 0 || p.Y != 0 {
+		t.Fatalf("got age %v at %v,%v with vy %v, want 500ms at 5,0 with vy 0", p.Age, p.X, p.Y, p.VY)
+	}
+	s.Update(500 * time.Millisecond)
+	if n := len(s.Particles()); n != 0 {
+		t.Fatalf("got %d particles, want none after their lifetime", n)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package fx
+
+import (
+	"strconv"
+	"time"
+)
+
+// Popup colors, by sign of the points.
+var (
+	PopupGood = Color{50, 220, 80, 255}
+	PopupBad  = Color{255, 60, 60, 255}
+)
+
+// Popup settings.
+const (
+	PopupLife  = 900 * time.Millisecond // lifetime of popups
+	PopupSpeed = 80                     // rise speed, in pixels per second
+)
+
+// Popup is a score popup text, e.g. "+15", that floats up and fades.
+type Popup struct {
+	Text  string        // text of the popup
+	X, Y  float64       // center of the text, in pixels
+	Color Color         // color of the text
+	Age   time.Duration // time since the popup was spawned
+}
+
+// Alpha returns the opacity of the popup, from 0 to 1.
+func (p *Popup) Alpha() float64 {
+	a := 1 - float64(p.Age)/float64(PopupLife)
+	if a < 0 {
+		return 0
+	}
+	return a
+}
+
+// Popups simulates score popups.
+type Popups struct {
+	popups []Popup
+}
+
+// Spawn spawns a popup for the given points at x, y.
+func (ps *Popups) Spawn(points int64, x, y float64) {
+	p := Popup{
+		Text:  strconv.FormatInt(points, 10),
+		X:     x,
+		Y:     y,
+		Color: PopupBad,
+	}
+	if points >= 0 {
+		p.Text = "+" + p.Text
+		p.Color = PopupGood
+	}
+	ps.popups = append(ps.popups, p)
+}
+
+// Update advances the popups by dt, and drains expired popups.
+func (ps *Popups) Update(dt time.Duration) {
+	kept := ps.popups[:0]
+	for _, p := range ps.popups {
+		p.Age += dt
+		if p.Age >= PopupLife {
+			continue
+		}
+		p.Y -= PopupSpeed * dt.Seconds()
+		kept = append(kept, p)
+	}
+	ps.popups = kept
+}
+
+// List returns the live popups. The returned slice is only valid
+// until the next call to Spawn or Update.
+func (ps *Popups) List() []Popup {
+	return ps.popups
+}
@@ -0,0 +1,56 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package fx
+
+import (
+	"testing"
+)
+
+func TestPopupsSpawn(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		points int64
+		text   string
+		color  Color
+	}{
+		{"good", 15, "+15", PopupGood},
+		{"zero", 0, "+0", PopupGood},
+		{"bad", -10, "-10", PopupBad},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var ps Popups
+			ps.Spawn(tc.points, 10, 20)
+			list := ps.List()
+			if len(list) != 1 {
+				t.Fatalf("got %d popups, want 1", len(list))
+			}
+			if p := list[0]; p.Text != tc.text || p.Color != tc.color || p.X != 10 || p.Y != 20 {
+				t.Fatalf("got %+v, want %q in %v at 10,20", p, tc.text, tc.color)
+			}
+		})
+	}
+}
+
+func TestPopupsUpdate(t *testing.T) {
+	var ps Popups
+	ps.Spawn(5, 0, 100)
+	ps.Update(PopupLife / 2)
+	list := ps.List()
+	if len(list) != 1 {
+		t.Fatalf("got %d popups, want 1 within its lifetime", len(list))
+	}
+	p := list[0]
+	if want := 100 - PopupSpeed*(PopupLife/2).Seconds(); p.Y != want {
+		t.Fatalf("got y %v, want %v risen", p.Y, want)
+	}
+	if a := p.Alpha(); a != .5 {
+		t.Fatalf("got alpha %v, want .5 halfway", a)
+	}
+	ps.Update(PopupLife / 2)
+	if n := len(ps.List()); n != 0 {
+		t.Fatalf("got %d popups, want none after their lifetime", n)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+)
+
+// Adaptive adjusts the rain to the player's performance, and
+// optionally displays and logs the adjustments.
+type Adaptive interface {
+	// Record records the outcome of a drop.
+	Record(now time.Time, o difficulty.Outcome)
+
+	// Apply updates the adjustment and returns the given rain
+	// parameters adjusted to the player.
+	Apply(now time.Time, p difficulty.Params) difficulty.Params
+
+	// Draw draws the status of the adjustment, if enabled.
+	Draw(now time.Time, viewport *sdl.Rect)
+
+	// Reset forgets the outcomes recorded and the adjustment.
+	Reset()
+}
+
+type adaptive struct {
+	*difficulty.Adaptive
+	r *sdl.Renderer
+	f *font // nil unless the status is shown
+}
+
+// NewAdaptive creates and initializes the adaptive difficulty. When
+// show is set the adjustments are logged and drawn on the screen.
+func NewAdaptive(r *sdl.Renderer, show bool) (Adaptive, error) {
+	a := &adaptive{Adaptive: difficulty.NewAdaptive(), r: r}
+	if !show {
+		return a, nil
+	}
+	f, err := openFont(20)
+	if err != nil {
+		return nil, err
+	}
+	a.f = f
+	return a, nil
+}
+
+// Apply implements the Adaptive interface.
+func (a *adaptive) Apply(now time.Time, p difficulty.Params) difficulty.Params {
+	if a.Update(now) && a.f != nil {
+		log.Println(a.Status(now))
+	}
+	return a.Adaptive.Apply(p)
+}
+
+// Draw implements the Adaptive interface.
+func (a *adaptive) Draw(now time.Time, viewport *sdl.Rect) {
+	if a.f == nil {
+		return
+	}
+	s, err := a.f.RenderUTF8Blended(a.Status(now), sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	if err != nil {
+		log.Println("failed to create font surface:", err)
+		return
+	}
+	defer s.Free()
+	t, err := a.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create font texture:", err)
+		return
+	}
+	defer t.Destroy()
+	x := int32(float32(viewport.W) * .05)
+	y := viewport.H - s.H - 8
+	a.r.Copy(t, nil, &sdl.Rect{X: x, Y: y, W: s.W, H: s.H})
+}
@@ -0,0 +1,123 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/sound"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// mixChannels is the number of mixer channels. Sound effects play on
+// any free channel not reserved for the music, so they can overlap.
+const mixChannels = 16
+
+// Audio controls the volume of the music and the sound effects, and
+// keeps the audio settings between runs.
+type Audio interface {
+	// Settings returns the audio settings.
+	Settings() settings.Audio
+
+	// Set applies and saves the audio settings.
+	Set(a settings.Audio)
+
+	// ToggleMute mutes or unmutes the audio.
+	ToggleMute()
+
+	// Step changes the master volume by the given number of steps.
+	Step(n int)
+}
+
+type audio struct {
+	music Music
+	st    store.Store
+	s     *settings.Settings
+	saved *settings.Settings
+}
+
+// NewAudio applies the audio settings of s to the music and the sound
+// effects. Setting the audio sets it in s and saved, and saves saved
+// to the store, see engine.saved.
+func NewAudio(m Music, st store.Store, s, saved *settings.Settings) Audio {
+	a := &audio{music: m, st: st, s: s, saved: saved}
+	a.apply()
+	return a
+}
+
+// Settings implements the Audio interface.
+func (a *audio) Settings() settings.Audio {
+	return a.s.Audio
+}
+
+// Set implements the Audio interface.
+func (a *audio) Set(s settings.Audio) {
+	a.s.Audio = s
+	a.saved.Audio = s
+	a.apply()
+	if err := a.saved.Save(a.st); err != nil {
+		log.Println("failed to save settings:", err)
+	}
+}
+
+// ToggleMute implements the Audio interface.
+func (a *audio) ToggleMute() {
+	s := a.s.Audio
+	s.Muted = !s.Muted
+	a.Set(s)
+}
+
+// Step implements the Audio interface.
+func (a *audio) Step(n int) {
+	s := a.s.Audio
+	s.Step(n)
+	a.Set(s)
+}
+
+// apply sets the volume of the music, and of the channels of the
+// sound effects.
+func (a *audio) apply() {
+	a.music.SetVolume(a.s.Audio.MusicVolume())
+	v := int(a.s.Audio.SFXVolume() * sdlmix.MAX_VOLUME)
+	for ch := len(musicChannels); ch < mixChannels; ch++ {
+		sdlmix.Volume(ch, v)
+	}
+}
+
+// playAt plays the sound effect on a free channel, panned to the
+// stereo position p from -1 (left) to 1 (right).
+func playAt(c *sdlmix.Chunk, p float64) {
+	ch, err := c.Play(-1, 0)
+	if err != nil {
+		return // no free channel
+	}
+	l, r := sound.Gains(p)
+	sdlmix.SetPanning(ch, uint8(l*255), uint8(r*255))
+}
+
+// loadSound loads the sound effect from file, or synthesizes the named
+// sound preset if the file doesn't exist.
+func loadSound(file, preset string) (*sdlmix.Chunk, error) {
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		return sdlmix.LoadWAV(file)
+	}
+	p, ok := sound.Presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("%s not found, and no sound preset %q", file, preset)
+	}
+	log.Printf("%s not found, using the synthesized %q sound", file, preset)
+	rw, err := sdl.RWFromMem(p.WAV())
+	if err != nil {
+		return nil, err
+	}
+	return sdlmix.LoadWAVRW(rw, true)
+}
@@ -0,0 +1,115 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/theme"
+)
+
+// Background draws the layers and the weather of a theme behind the
+// rest of the scene.
+type Background interface {
+	// Draw draws the background. The focus is the lateral position
+	// of the player in the viewport, for the parallax of the layers.
+	Draw(now time.Time, viewport *sdl.Rect, focus int32)
+}
+
+type background struct {
+	r       *sdl.Renderer
+	theme   *theme.Theme
+	layers  []Image
+	cloud   Image // nil for themes without clouds
+	weather *theme.Weather
+	start   time.Time
+	last    time.Time
+}
+
+// NewBackground creates and initializes the background of a theme.
+func NewBackground(r *sdl.Renderer, t *theme.Theme) (Background, error) {
+	b := &background{r: r, theme: t, weather: theme.NewWeather(t)}
+	for _, l := range t.Layers {
+		img, err := NewImageFromFile(r, l.Image)
+		if err != nil {
+			return nil, err
+		}
+		b.layers = append(b.layers, img)
+	}
+	if t.Cloud != "" {
+		img, err := NewImageFromFile(r, t.Cloud)
+		if err != nil {
+			return nil, err
+		}
+		b.cloud = img
+	}
+	return b, nil
+}
+
+// Draw implements the Background interface.
+func (b *background) Draw(now time.Time, viewport *sdl.Rect, focus int32) {
+	if b.start.IsZero() {
+		b.start = now
+	}
+	dt := frameTime(b.last, now)
+	b.last = now
+	t := now.Sub(b.start)
+	for i, l := range b.theme.Layers {
+		b.drawLayer(b.layers[i], l, t, float64(focus-viewport.W/2), viewport)
+	}
+	var cw, ch int32
+	if b.cloud != nil {
+		cw, ch = b.cloud.Size()
+	}
+	b.weather.Update(dt, float64(viewport.W), float64(viewport.H), float64(cw))
+	for _, c := range b.weather.Clouds() {
+		b.r.Copy(b.cloud.Texture(), nil, &sdl.Rect{
+			X: int32(c.X),
+			Y: int32(c.Y),
+			W: int32(float64(cw) * c.Scale),
+			H: int32(float64(ch) * c.Scale),
+		})
+	}
+	b.r.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	b.r.SetDrawColor(180, 200, 255, 160)
+	for _, s := range b.weather.Streaks() {
+		v := math.Hypot(s.VX, s.VY)
+		x2 := s.X + s.VX/v*s.Len
+		y2 := s.Y + s.VY/v*s.Len
+		b.r.DrawLine(int32(s.X), int32(s.Y), int32(x2), int32(y2))
+	}
+	if dim := b.weather.Dim(); dim > 0 {
+		b.r.SetDrawColor(0, 0, 0, uint8(255*dim))
+		b.r.FillRect(nil)
+	}
+	b.r.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+}
+
+// drawLayer draws the layer scrolled after time t, repeating its
+// image across the viewport.
+func (b *background) drawLayer(img Image, l theme.Layer, t time.Duration, focus float64, viewport *sdl.Rect) {
+	y, h := l.Rect(float64(viewport.H))
+	iw, ih := img.Size()
+	w := float64(iw) * h / float64(ih)
+	if l.Stretch {
+		w = float64(viewport.W)
+	}
+	off := math.Mod(l.Offset(t, focus), w)
+	if off < 0 {
+		off += w
+	}
+	for x := -off; x < float64(viewport.W); x += w {
+		b.r.Copy(img.Texture(), nil, &sdl.Rect{
+			X: int32(x),
+			Y: int32(y),
+			W: int32(math.Ceil(w)),
+			H: int32(math.Ceil(h)),
+		})
+	}
+}
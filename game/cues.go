@@ -0,0 +1,57 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/sound"
+)
+
+// cueVolume is the volume of the cues, relative to the other sound
+// effects, so they stay in the background.
+const cueVolume = sdlmix.MAX_VOLUME / 3
+
+// Cues play subtle sounds for the drops approaching the player,
+// panned to where they fall, so players can hear what's coming and
+// from where.
+type Cues interface {
+	// Play plays the cues of the given drops.
+	Play(drops []Drop, viewport *sdl.Rect)
+}
+
+type cues struct {
+	good *sdlmix.Chunk
+	bad  *sdlmix.Chunk
+}
+
+// NewCues creates and initializes the cues of approaching drops.
+func NewCues() (Cues, error) {
+	good, err := loadSound("assets/snd/cue_good.wav", "cue_good")
+	if err != nil {
+		return nil, err
+	}
+	bad, err := loadSound("assets/snd/cue_bad.wav", "cue_bad")
+	if err != nil {
+		return nil, err
+	}
+	good.Volume(cueVolume)
+	bad.Volume(cueVolume)
+	return &cues{good: good, bad: bad}, nil
+}
+
+// Play implements the Cues interface.
+func (c *cues) Play(drops []Drop, viewport *sdl.Rect) {
+	for _, d := range drops {
+		cue := c.good
+		if d.Points() < 0 {
+			cue = c.bad
+		}
+		pos := d.Pos()
+		playAt(cue, sound.Pan(float64(pos.X+pos.W/2), float64(viewport.W)))
+	}
+}
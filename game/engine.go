@@ -8,10 +8,22 @@ package game
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/screen"
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
 )
 
 // Version is the version of the game engine.
@@ -28,10 +40,51 @@ type engine struct {
 	w *sdl.Window
 	r *sdl.Renderer
 	s Scene
+	a Audio
+	m Menu
+
+	st     store.Store
+	picker *profile.Picker
+	prof   *profile.Profile // profile playing
+	stats  *stats.Stats
+	last   *stats.Session // last game of the profile, nil if none
+	defs   achievement.Defs
+	ach    *achievement.Achievements
+	toasts Toasts
+
+	set      *settings.Settings // saved, overridden by the config
+	saved    *settings.Settings // saved, with the changes of the player
+	adaptive Adaptive           // enabled in the settings
+	cues     Cues               // enabled in the settings
+
+	screens screen.Stack[Screen] // the one on top gets the input
+	running bool
 }
 
-// NewEngine creates and initializes a new game engine.
+// NewEngine creates and initializes a new game engine, with the
+// settings saved, overridden by the config.
 func NewEngine(c *Config) (Engine, error) {
+	// the fonts and text of the game are in the locale
+	setLocale(c.Lang)
+	st := openStore(c.ConfigDir)
+	saved, err := settings.Load(st)
+	if err != nil {
+		log.Printf("failed to load settings, using defaults: %v", err)
+	}
+	set := new(settings.Settings)
+	*set = *saved
+	c.override(set)
+	if err = set.Validate(); err != nil {
+		return nil, err
+	}
+	name := set.Gameplay.Difficulty
+	if name == "" {
+		name = difficulty.Default
+	}
+	d, err := difficulty.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
 	renderDriver := os.Getenv("SDL_RENDER_DRIVER")
 	userSelectedRenderer := renderDriver != ""
 	if renderDriver == "" {
@@ -48,8 +101,8 @@ func NewEngine(c *Config) (Engine, error) {
 	log.Printf("SDL video driver=%q render driver=%q", videoDriver, renderDriver)
 
 	w, r, err := sdl.CreateWindowAndRenderer(
-		int32(c.Width),
-		int32(c.Height),
+		int32(set.Video.Width),
+		int32(set.Video.Height),
 		sdl.WINDOW_SHOWN,
 	)
 	if err != nil && !userSelectedRenderer && runtime.GOOS == "linux" && renderDriver == "opengl" {
@@ -57,8 +110,8 @@ func NewEngine(c *Config) (Engine, error) {
 		renderDriver = "software"
 		sdl.SetHint(sdl.HINT_RENDER_DRIVER, renderDriver)
 		w, r, err = sdl.CreateWindowAndRenderer(
-			int32(c.Width),
-			int32(c.Height),
+			int32(set.Video.Width),
+			int32(set.Video.Height),
 			sdl.WINDOW_SHOWN,
 		)
 	}
@@ -66,59 +119,186 @@ func NewEngine(c *Config) (Engine, error) {
 		return nil, err
 	}
 	w.SetTitle("cat-o-licious " + Version)
-	s, err := NewScene(r)
+	a, err := NewAdaptive(r, c.ShowAdaptive)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := NewCues()
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewScene(r, d, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMenu(r, st)
+	if err != nil {
+		return nil, err
+	}
+	ps, err := profile.Load(st)
+	if err != nil {
+		log.Printf("failed to load profiles, starting over: %v", err)
+	}
+	ss, err := stats.Load(st)
+	if err != nil {
+		log.Printf("failed to load stats, starting over: %v", err)
+	}
+	defs, err := LoadAchievements(achievement.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	if err = defs.Check(s.Names()); err != nil {
+		return nil, err
+	}
+	ach, err := achievement.Load(st)
+	if err != nil {
+		log.Printf("failed to load achievements, starting over: %v", err)
+	}
+	toasts, err := NewToasts(r)
 	if err != nil {
 		return nil, err
 	}
-	return &engine{c, w, r, s}, nil
+	e := &engine{
+		c:        c,
+		w:        w,
+		r:        r,
+		s:        s,
+		a:        NewAudio(s.Music(), st, set, saved),
+		m:        m,
+		st:       st,
+		picker:   profile.NewPicker(ps),
+		stats:    ss,
+		defs:     defs,
+		ach:      ach,
+		toasts:   toasts,
+		set:      set,
+		saved:    saved,
+		adaptive: a,
+		cues:     cues,
+	}
+	if set.Video.Fullscreen {
+		e.setFullscreen(true)
+	}
+	s.SetReduceMotion(set.Accessibility.ReduceMotion)
+	if set.Accessibility.AudioCues {
+		s.SetCues(cues)
+	}
+	e.subscribe()
+	base := baseScreen{e: e}
+	e.screens.Push(&titleScreen{uiScreen: uiScreen{baseScreen: base}})
+	e.screens.Push(&profileScreen{uiScreen: uiScreen{baseScreen: base}, startup: true})
+	return e, nil
+}
+
+// subscribe subscribes the stats and achievements of the profile to
+// the events of the game.
+func (e *engine) subscribe() {
+	bus := e.s.Events()
+	event.Subscribe(bus, func(DropCaught) { e.achieve(time.Now()) })
+	event.Subscribe(bus, func(ScoreChanged) { e.achieve(time.Now()) })
+	event.Subscribe(bus, func(ev StateChanged) {
+		now := time.Now()
+		switch ev.State {
+		case GameStarted:
+			e.ach.Profile(e.prof.Name).Play(now)
+			e.achieve(now)
+			e.saveAchievements()
+		case GameOver:
+			e.achieve(now)
+			e.last = e.s.Stats()
+			e.stats.Profile(e.prof.Name).Add(e.last)
+			if err := e.stats.Save(e.st); err != nil {
+				log.Printf("failed to save stats: %v", err)
+			}
+		}
+	})
+}
+
+// setLocale sets the locale of the game to the given locale, or to the
+// locale of the environment if empty, e.g. LANG=pt_BR.UTF-8. Catalogs
+// that fail to load are logged, and their messages are in English.
+func setLocale(name string) {
+	if name == "" {
+		name = locale.FromEnv(os.Getenv)
+	}
+	l, err := locale.Load(name, func(name string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(locale.DefaultDir, name+".json"))
+	})
+	if err != nil {
+		log.Printf("failed to load locale %q: %v", name, err)
+	}
+	log.Printf("locale=%q", l.Name())
+	locale.Set(l)
+}
+
+// openStore returns the store of the game data in the given directory,
+// or in the user's config directory if empty. Without a config
+// directory the data is kept in memory, and lost on quit.
+func openStore(dir string) store.Store {
+	if dir != "" {
+		return store.Dir(dir)
+	}
+	st, err := store.UserDir()
+	if err != nil {
+		log.Printf("failed to find the config directory, settings and scores won't be saved: %v", err)
+		return store.Mem{}
+	}
+	return st
 }
 
 // Run implements the Engine interface.
 func (e *engine) Run() {
-	p := e.s.Player()
-	playerSpeed := int32(e.c.PlayerSpeed)
-	fullscreen := false
 	var viewport sdl.Rect
-	fps := uint32(e.c.FPS)
-	frameDelay := uint32(1000 / fps)
-
-	running := true
-	for {
-		if !running {
-			break
-		}
+	e.running = true
+	for e.running {
 		frameStart := sdl.GetTicks()
+		frameDelay := uint32(1000 / e.set.Video.FPS)
 
 		// 1. Handle Input (Main Thread)
 		for ev := sdl.PollEvent(); ev != nil; ev = sdl.PollEvent() {
 			switch t := ev.(type) {
 			case *sdl.QuitEvent:
-				running = false
+				e.running = false
+			case *sdl.WindowEvent:
+				// pause the music while in the background, and
+				// keep it paused with the game
+				switch t.Event {
+				case sdl.WINDOWEVENT_FOCUS_LOST, sdl.WINDOWEVENT_MINIMIZED:
+					e.s.Music().SetPaused(true)
+				case sdl.WINDOWEVENT_FOCUS_GAINED, sdl.WINDOWEVENT_RESTORED:
+					e.s.Music().SetPaused(e.s.Paused())
+				}
 			case *sdl.KeyboardEvent:
-				if t.State != sdl.PRESSED {
-					continue
+				if t.State == sdl.PRESSED {
+					e.key(t.Keysym.Sym)
 				}
-				switch t.Keysym.Sym {
-				case sdl.K_q:
-					running = false
-				case sdl.K_f:
-					if fullscreen {
-						e.w.SetFullscreen(0)
-					} else {
-						e.w.SetFullscreen(sdl.WINDOW_FULLSCREEN)
-					}
-					fullscreen = !fullscreen
-				case sdl.K_LEFT, sdl.K_a:
-					p.Move(Left, playerSpeed)
-				case sdl.K_RIGHT, sdl.K_d:
-					p.Move(Right, playerSpeed)
+			case *sdl.ControllerButtonEvent:
+				if t.State == sdl.PRESSED {
+					e.button(sdl.GameControllerButton(t.Button))
+				}
+			case *sdl.ControllerDeviceEvent:
+				if t.Type == sdl.CONTROLLERDEVICEADDED {
+					sdl.GameControllerOpen(int(t.Which))
+				}
+			case *sdl.TextInputEvent:
+				if top, ok := e.screens.Top(); ok {
+					top.Type(t.GetText())
+				}
+			case *sdl.MouseMotionEvent:
+				if top, ok := e.screens.Top(); ok {
+					top.Mouse(t.X, t.Y, false)
+				}
+			case *sdl.MouseButtonEvent:
+				top, ok := e.screens.Top()
+				if ok && t.State == sdl.PRESSED && t.Button == sdl.BUTTON_LEFT {
+					top.Mouse(t.X, t.Y, true)
 				}
 			}
 		}
 
 		// 2. Draw (Main Thread)
 		viewport = e.r.GetViewport()
-		e.s.Draw(time.Now(), &viewport)
+		e.draw(time.Now(), &viewport)
 		e.r.Present()
 
 		// 3. Frame Rate Control
@@ -128,3 +308,108 @@ func (e *engine) Run() {
 		}
 	}
 }
+
+// key handles a key pressed, by the screen on top, or else the keys
+// of all screens.
+func (e *engine) key(k sdl.Keycode) {
+	if top, ok := e.screens.Top(); ok && top.Key(k) {
+		return
+	}
+	switch k {
+	case sdl.K_q:
+		e.running = false
+	case sdl.K_f:
+		s := *e.set
+		s.Video.Fullscreen = !s.Video.Fullscreen
+		e.configure(s)
+	case sdl.K_m:
+		e.a.ToggleMute()
+	case sdl.K_MINUS, sdl.K_KP_MINUS:
+		e.a.Step(-1)
+	case sdl.K_EQUALS, sdl.K_PLUS, sdl.K_KP_PLUS:
+		e.a.Step(1)
+	}
+}
+
+// button handles a button of a game controller pressed, by the screen
+// on top if it handles buttons, or else as the key of the button.
+func (e *engine) button(b sdl.GameControllerButton) {
+	if top, ok := e.screens.Top(); ok {
+		if bs, ok := top.(buttonScreen); ok && bs.Button(b) {
+			return
+		}
+	}
+	if k, ok := controllerKeys[b]; ok {
+		e.key(k)
+	}
+}
+
+// draw draws the visible screens, and the toasts on top.
+func (e *engine) draw(now time.Time, viewport *sdl.Rect) {
+	e.r.SetDrawColor(0, 0, 0, 255)
+	e.r.Clear()
+	for _, s := range e.screens.Visible() {
+		s.Draw(now, viewport)
+	}
+	e.toasts.Draw(now, viewport)
+}
+
+// gameOver ends the game, and replaces it with the name entry if the
+// player made it to the high scores, or with the high scores.
+func (e *engine) gameOver() {
+	event.Publish(e.s.Events(), StateChanged{GameOver})
+	base := baseScreen{e: e}
+	if e.m.GameOver(e.s.Points(), e.s.Level()) {
+		e.screens.Replace(&nameScreen{baseScreen: base})
+		return
+	}
+	e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: base}})
+}
+
+// pick starts playing as the given profile, with its difficulty and
+// the color of its cat, unless the difficulty is set in the settings,
+// and with the adaptive difficulty if enabled in the settings, afresh.
+func (e *engine) pick(p *profile.Profile) {
+	name := e.set.Gameplay.Difficulty
+	if name == "" {
+		name = p.Difficulty
+	}
+	d, err := difficulty.Lookup(name)
+	if err != nil {
+		log.Printf("profile %q: %v", p.Name, err)
+		name = difficulty.Default
+		d, _ = difficulty.Lookup(name)
+	}
+	if e.prof != p {
+		e.last = nil
+	}
+	e.prof = p
+	e.s.SetDifficulty(d)
+	e.s.SetAdaptive(nil)
+	e.adaptive.Reset()
+	if e.set.Gameplay.Adaptive {
+		e.s.SetAdaptive(e.adaptive)
+	}
+	e.s.Player().SetColor(profile.LookupColor(p.Color))
+	e.m.SetProfile(p, highscore.Mode(name, e.set.Gameplay.Adaptive))
+}
+
+// achieve unlocks the achievements of the profile met in the game so
+// far, and shows a toast for each.
+func (e *engine) achieve(now time.Time) {
+	p := e.ach.Profile(e.prof.Name)
+	unlocked := p.Check(e.defs, now, e.s.Stats(), e.stats.Profile(e.prof.Name))
+	for _, d := range unlocked {
+		e.toasts.Show(locale.Sprintf("Achievement: %s", locale.T(d.Name)), locale.T(d.Description))
+	}
+	if len(unlocked) > 0 {
+		e.saveAchievements()
+	}
+}
+
+// saveAchievements saves the progress of the achievements.
+func (e *engine) saveAchievements() {
+	if err := e.ach.Save(e.st); err != nil {
+		log.Printf("failed to save achievements: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"time"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+)
+
+// Events of the game, published to the event bus of the scene. See
+// the event package.
+
+// DropSpawned is published when a drop starts falling.
+type DropSpawned struct {
+	Drop Drop
+}
+
+// DropCaught is published when the player catches a drop.
+type DropCaught struct {
+	Drop Drop
+	Now  time.Time
+}
+
+// DropMissed is published when a drop hits the floor, at the height
+// of Floor. Bad drops are meant to be missed.
+type DropMissed struct {
+	Drop  Drop
+	Now   time.Time
+	Floor int32
+}
+
+// ScoreChanged is published when a drop caught is scored, or blocked
+// by the shield.
+type ScoreChanged struct {
+	Now       time.Time
+	Points    int64 // points of the player
+	Delta     int64 // points of the drop, with the combo and power-ups
+	BestCombo int   // longest combo of the game
+	Blocked   bool  // the shield blocked a bad drop, with no delta
+	X, Y      int32 // where the drop was caught
+}
+
+// RateChanged is published when the parameters of the rain change,
+// e.g. the rate of new drops.
+type RateChanged struct {
+	Params difficulty.Params
+}
+
+// State is the state of the game.
+type State int
+
+// States of the game.
+const (
+	GameStarted State = iota
+	GameOver
+)
+
+// StateChanged is published when the game starts or ends.
+type StateChanged struct {
+	State State
+}
@@ -0,0 +1,269 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/veandco/go-sdl2/sdl"
+	sdlttf "github.com/veandco/go-sdl2/ttf"
+
+	"github.com/fiorix/cat-o-licious/locale"
+)
+
+// fontDir is the directory of the fonts of the game, and fontFile is
+// the font of the game.
+const (
+	fontDir  = "assets/fonts"
+	fontFile = fontDir + "/score.ttf"
+)
+
+// systemFonts are the fonts of the systems, by GOOS, used for the
+// characters missing from the font of the game, e.g. accents and
+// non-Latin scripts, unless the locale has a font.
+var systemFonts = map[string][]string{
+	"linux": {
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/TTF/DejaVuSans.ttf",
+		"/usr/share/fonts/truetype/noto/NotoSans-Regular.ttf",
+		"/usr/share/fonts/noto/NotoSans-Regular.ttf",
+	},
+	"darwin": {
+		"/System/Library/Fonts/Supplemental/Arial Unicode.ttf",
+		"/Library/Fonts/Arial Unicode.ttf",
+		"/System/Library/Fonts/Supplemental/Arial.ttf",
+	},
+	"windows": {
+		`C:\Windows\Fonts\arialuni.ttf`,
+		`C:\Windows\Fonts\arial.ttf`,
+	},
+}
+
+// font is the font of the game in a size, with a fallback font for the
+// text with characters it doesn't have. Text that neither font has is
+// drawn in ASCII, e.g. "e" for "é".
+type font struct {
+	*sdlttf.Font
+	fallback *sdlttf.Font // nil if none
+}
+
+// openFont opens the font of the game in the size, and the fallback
+// font of the locale of the game in the same size.
+func openFont(size int) (*font, error) {
+	f, err := sdlttf.OpenFont(fontFile, size)
+	if err != nil {
+		return nil, err
+	}
+	ft := &font{Font: f}
+	if fb := fallbackFont(); fb.name != "" {
+		ft.fallback, err = sdlttf.OpenFont(fb.name, size)
+		if err != nil {
+			log.Printf("failed to open fallback font %q: %v", fb.name, err)
+		}
+	}
+	return ft, nil
+}
+
+// text returns the font to draw the text with, and the text to draw.
+func (f *font) text(text string) (*sdlttf.Font, string) {
+	if fontChars().hasAll(text) {
+		return f.Font, text
+	}
+	if f.fallback != nil && fallbackFont().chars.hasAll(text) {
+		return f.fallback, text
+	}
+	return f.Font, locale.Fold(text)
+}
+
+// RenderUTF8Solid renders the text, like the sdlttf.Font method.
+func (f *font) RenderUTF8Solid(text string, c sdl.Color) (*sdl.Surface, error) {
+	ft, text := f.text(text)
+	return ft.RenderUTF8Solid(text, c)
+}
+
+// RenderUTF8Blended renders the text, like the sdlttf.Font method.
+func (f *font) RenderUTF8Blended(text string, c sdl.Color) (*sdl.Surface, error) {
+	ft, text := f.text(text)
+	return ft.RenderUTF8Blended(text, c)
+}
+
+// SizeUTF8 returns the size of the text, like the sdlttf.Font method.
+func (f *font) SizeUTF8(text string) (int, int, error) {
+	ft, text := f.text(text)
+	return ft.SizeUTF8(text)
+}
+
+// fontChars returns the characters of the font of the game.
+var fontChars = sync.OnceValue(func() charset {
+	cs, err := loadCharset(fontFile)
+	if err != nil {
+		log.Printf("failed to read the characters of %q: %v", fontFile, err)
+	}
+	return cs
+})
+
+// fallback is a fallback font, and its characters.
+type fallback struct {
+	name  string // file of the font, empty if none
+	chars charset
+}
+
+// fallbackFont returns the fallback font: the font of the locale of
+// the game, if any, or else the first font of the system found.
+var fallbackFont = sync.OnceValue(func() fallback {
+	var names []string
+	if name := locale.Current().Font(); name != "" {
+		names = append(names, filepath.Join(fontDir, name))
+	}
+	for _, name := range append(names, systemFonts[runtime.GOOS]...) {
+		cs, err := loadCharset(name)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			continue
+		case err != nil:
+			log.Printf("failed to read the characters of %q: %v", name, err)
+			continue
+		}
+		log.Printf("fallback font: %q", name)
+		return fallback{name: name, chars: cs}
+	}
+	return fallback{}
+})
+
+// charset is the characters of a font, in sorted ranges, e.g. {32, 126}
+// for ASCII.
+type charset [][2]rune
+
+// has returns true if the charset has the character.
+func (cs charset) has(r rune) bool {
+	i := sort.Search(len(cs), func(i int) bool { return cs[i][1] >= r })
+	return i < len(cs) && cs[i][0] <= r
+}
+
+// hasAll returns true if the charset has all the characters of the
+// text, except spaces.
+func (cs charset) hasAll(text string) bool {
+	for _, r := range text {
+		if r != ' ' && !cs.has(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadCharset loads the characters of the TrueType or OpenType font
+// file, from its character map (cmap table).
+func loadCharset(name string) (charset, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return parseCharset(b)
+}
+
+// errBadFont is the error of fonts that fail to parse.
+var errBadFont = errors.New("invalid or unsupported font")
+
+// fontReader reads the big-endian numbers of font files, and records
+// reads out of bounds.
+type fontReader struct {
+	b   []byte
+	bad bool
+}
+
+// u16 returns the uint16 at the offset.
+func (fr *fontReader) u16(off int) int {
+	if off < 0 || off+2 > len(fr.b) {
+		fr.bad = true
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(fr.b[off:]))
+}
+
+// u32 returns the uint32 at the offset.
+func (fr *fontReader) u32(off int) int {
+	if off < 0 || off+4 > len(fr.b) {
+		fr.bad = true
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(fr.b[off:]))
+}
+
+// parseCharset returns the characters of the font, from the Unicode
+// subtables of its cmap table, in formats 4 or 12.
+func parseCharset(b []byte) (charset, error) {
+	fr := &fontReader{b: b}
+	cmap := -1
+	for i := range fr.u16(4) {
+		if string(b[min(12+16*i, len(b)):min(16+16*i, len(b))]) == "cmap" {
+			cmap = fr.u32(12 + 16*i + 8)
+		}
+	}
+	if cmap < 0 || fr.bad {
+		return nil, errBadFont
+	}
+	var cs charset
+	for i := range fr.u16(cmap + 2) {
+		rec := cmap + 4 + 8*i
+		platform, encoding := fr.u16(rec), fr.u16(rec+2)
+		if platform != 0 && (platform != 3 || (encoding != 1 && encoding != 10)) {
+			continue // not Unicode
+		}
+		sub := cmap + fr.u32(rec+4)
+		switch fr.u16(sub) {
+		case 4:
+			segs := fr.u16(sub+6) / 2
+			ends, starts := sub+14, sub+16+2*segs
+			offsets := starts + 4*segs // after the deltas
+			for s := range segs {
+				start, end := fr.u16(starts+2*s), fr.u16(ends+2*s)
+				ro := fr.u16(offsets + 2*s)
+				for c := start; c <= end && c != 0xffff && !fr.bad; c++ {
+					// chars map to glyph 0 if missing
+					if ro == 0 || fr.u16(offsets+2*s+ro+2*(c-start)) != 0 {
+						cs = append(cs, [2]rune{rune(c), rune(c)})
+					}
+				}
+			}
+		case 12:
+			for g := range fr.u32(sub + 12) {
+				group := sub + 16 + 12*g
+				cs = append(cs, [2]rune{rune(fr.u32(group)), rune(fr.u32(group + 4))})
+				if fr.bad {
+					break
+				}
+			}
+		}
+		if fr.bad {
+			return nil, errBadFont
+		}
+	}
+	return cs.merge(), nil
+}
+
+// merge returns the charset sorted, with its overlapping and adjacent
+// ranges merged.
+func (cs charset) merge() charset {
+	slices.SortFunc(cs, func(a, b [2]rune) int { return int(a[0] - b[0]) })
+	var m charset
+	for _, r := range cs {
+		if n := len(m); n > 0 && r[0] <= m[n-1][1]+1 {
+			m[n-1][1] = max(m[n-1][1], r[1])
+			continue
+		}
+		m = append(m, r)
+	}
+	return m
+}
@@ -13,9 +13,11 @@ import (
 	sdlttf "github.com/veandco/go-sdl2/ttf"
 )
 
-// Config is the game configuration.
+// Config is the game configuration. The settings the player changes
+// in the game are saved, and the fields of the config named in Set
+// override them, e.g. the flags given in the command line.
 type Config struct {
-	// FPS is the rendering rate of the game. It cannot be changed.
+	// FPS is the rendering rate of the game.
 	FPS int
 
 	// Width is the initial width for the game window.
@@ -26,6 +28,37 @@ type Config struct {
 
 	// PlayerSpeed is the speed of the player's lateral movement.
 	PlayerSpeed int
+
+	// Difficulty is the name of the difficulty preset, one of
+	// toddler, easy, normal or hard. Empty uses the difficulty of
+	// the player's profile.
+	Difficulty string
+
+	// Adaptive enables the adaptive difficulty, that adjusts the
+	// rain to the player's performance.
+	Adaptive bool
+
+	// ShowAdaptive shows and logs the adjustments of the adaptive
+	// difficulty.
+	ShowAdaptive bool
+
+	// AudioCues plays sounds for the drops approaching the player,
+	// panned to where they fall.
+	AudioCues bool
+
+	// Lang is the locale of the text of the game, e.g. pt or es.
+	// Empty uses the locale of the environment, e.g. LANG.
+	Lang string
+
+	// ConfigDir is the directory where settings are kept between
+	// runs. Empty uses the user's config directory.
+	ConfigDir string
+
+	// Set is the names of the flags of the fields given, that
+	// override the settings saved: fps, width, height, speed,
+	// difficulty, adaptive and audio-cues. Other fields always
+	// apply.
+	Set map[string]bool
 }
 
 // DefaultConfig is the game's default configuration.
@@ -61,6 +94,7 @@ func Run(c *Config) error {
 	if err := sdlmix.OpenAudio(44100, sndfmt, 2, 1024); err != nil {
 		return err
 	}
+	sdlmix.AllocateChannels(mixChannels)
 	// init images
 	sdlimg.Init(sdlimg.INIT_PNG)
 	defer sdlimg.Quit()
@@ -6,10 +6,18 @@
 package game
 
 import (
+	"os"
 	"strconv"
 
 	"github.com/veandco/go-sdl2/sdl"
 	sdlimg "github.com/veandco/go-sdl2/img"
+
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/level"
+	"github.com/fiorix/cat-o-licious/music"
+	"github.com/fiorix/cat-o-licious/sprite"
+	"github.com/fiorix/cat-o-licious/theme"
 )
 
 // Image is a wrapper for SDL images.
@@ -73,3 +81,63 @@ func NewImageSetFromFiles(r *sdl.Renderer, prefix string) ([]Image, error) {
 	}
 	return imgs, nil
 }
+
+// LoadSprites loads and validates the sprite metadata from file.
+func LoadSprites(file string) (sprite.Catalog, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return sprite.Parse(f)
+}
+
+// LoadDrops loads and validates the drop catalog from file.
+func LoadDrops(file string) (catalog.Catalog, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return catalog.Parse(f)
+}
+
+// LoadAchievements loads and validates the achievements from file.
+func LoadAchievements(file string) (achievement.Defs, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return achievement.Parse(f)
+}
+
+// LoadLevels loads and validates the levels from file.
+func LoadLevels(file string) (level.Levels, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return level.Parse(f)
+}
+
+// LoadTheme loads and validates the background theme from file.
+func LoadTheme(file string) (*theme.Theme, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return theme.Parse(f)
+}
+
+// LoadPlaylist loads and validates the music playlist from file.
+func LoadPlaylist(file string) (*music.Playlist, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return music.Parse(f)
+}
@@ -0,0 +1,187 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/level"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/tween"
+)
+
+// levelCompleteTime is how long the level complete screen is shown
+// before the next level starts.
+const levelCompleteTime = 3 * time.Second
+
+// levelSlideTime is how long the level complete screen takes to
+// slide in and out.
+const levelSlideTime = 500 * time.Millisecond
+
+// Levels plays the levels of the game in order, on top of the rain.
+// After the last level the rain goes on endlessly.
+type Levels interface {
+	// Score records points made from a drop caught.
+	Score(points int64)
+
+	// Update starts the levels, spawns their waves into the rain,
+	// and moves on to the next level once the goals are met.
+	Update(now time.Time, rain Rain)
+
+	// Reset starts over from the first level on the next Update.
+	Reset()
+
+	// Current returns the index of the current level, or the number
+	// of levels after the last one.
+	Current() int
+
+	// Draw draws the progress of the current level, or the level
+	// complete screen.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+type levels struct {
+	r      *sdl.Renderer
+	f      *font // status font
+	fl     *font // level complete font
+	levels level.Levels
+	cur    int             // index of the current level
+	run    *level.Run      // nil before the first and after the last level
+	screen *tween.Sequence // level complete screen, nil unless shown
+	slide  float64         // position of the screen, 0 out to 1 in
+	last   time.Time       // time of the last update
+}
+
+// NewLevels creates and initializes the levels.
+func NewLevels(r *sdl.Renderer, ls level.Levels) (Levels, error) {
+	f, err := openFont(24)
+	if err != nil {
+		return nil, err
+	}
+	fl, err := openFont(60)
+	if err != nil {
+		return nil, err
+	}
+	return &levels{r: r, f: f, fl: fl, levels: ls}, nil
+}
+
+// Score implements the Levels interface.
+func (lv *levels) Score(points int64) {
+	if lv.run != nil && lv.screen == nil {
+		lv.run.Score(points)
+	}
+}
+
+// Update implements the Levels interface.
+func (lv *levels) Update(now time.Time, rain Rain) {
+	dt := frameTime(lv.last, now)
+	lv.last = now
+	if lv.cur >= len(lv.levels) {
+		return
+	}
+	if lv.run == nil {
+		lv.run = level.NewRun(lv.levels[lv.cur], now)
+	}
+	if lv.screen != nil {
+		if lv.screen.Update(dt) {
+			return
+		}
+		lv.cur++
+		lv.screen = nil
+		lv.run = nil
+		rain.SetPool(nil)
+		if lv.cur < len(lv.levels) {
+			lv.run = level.NewRun(lv.levels[lv.cur], now)
+		}
+		return
+	}
+	if lv.run.Done(now) {
+		// slide the screen in, and out before the next level
+		lv.screen = tween.Seq(
+			lv.slideTo(0, 1, levelSlideTime, tween.OutBack),
+			tween.Wait(levelCompleteTime-2*levelSlideTime),
+			lv.slideTo(1, 0, levelSlideTime, tween.InQuad),
+		)
+		rain.SetPool([]string{}) // no drops while the screen is up
+		return
+	}
+	for _, sp := range lv.run.Spawns(now) {
+		rain.Spawn(sp.Name, sp.X)
+	}
+	rain.SetPool(lv.run.Pool(now))
+}
+
+// Reset implements the Levels interface.
+func (lv *levels) Reset() {
+	lv.cur = 0
+	lv.run = nil
+	lv.screen = nil
+	lv.slide = 0
+	lv.last = time.Time{}
+}
+
+// Current implements the Levels interface.
+func (lv *levels) Current() int {
+	return lv.cur
+}
+
+// slideTo returns a tween of the level complete screen, between the
+// given positions. Tweens of a sequence are made up front, so the
+// start can't be read from the current position.
+func (lv *levels) slideTo(from, to float64, d time.Duration, ease tween.Ease) tween.Tweener {
+	t := tween.New(from, to, d, ease)
+	t.OnUpdate = func(v float64) { lv.slide = v }
+	return t
+}
+
+// Draw implements the Levels interface.
+func (lv *levels) Draw(now time.Time, viewport *sdl.Rect) {
+	if lv.run == nil {
+		return
+	}
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	if lv.screen == nil {
+		text := locale.Sprintf("Level %d: %s", lv.cur+1, locale.T(lv.run.Level.Name))
+		h := lv.drawText(lv.f, text, white, viewport.W/2, 8)
+		lv.drawText(lv.f, lv.run.Status(now), white, viewport.W/2, 8+h)
+		return
+	}
+	lv.r.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	lv.r.SetDrawColor(0, 0, 0, uint8(128*math.Min(math.Max(lv.slide, 0), 1)))
+	lv.r.FillRect(nil)
+	lv.r.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	gold := sdl.Color{R: 255, G: 215, B: 0, A: 255}
+	// slide down from above the viewport
+	y := int32(float64(viewport.H/3+viewport.H/2)*lv.slide) - viewport.H/2
+	y += lv.drawText(lv.fl, locale.Sprintf("Level %d complete!", lv.cur+1), gold, viewport.W/2, y)
+	next := locale.T("Endless rain, good luck!")
+	if lv.cur+1 < len(lv.levels) {
+		next = locale.Sprintf("Next: %s", locale.T(lv.levels[lv.cur+1].Name))
+	}
+	lv.drawText(lv.f, next, white, viewport.W/2, y+16)
+}
+
+// drawText draws text centered at x, and returns its height.
+func (lv *levels) drawText(f *font, text string, c sdl.Color, x, y int32) int32 {
+	s, err := f.RenderUTF8Blended(text, c)
+	if err != nil {
+		log.Println("failed to create font surface:", err)
+		return 0
+	}
+	defer s.Free()
+	t, err := lv.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create font texture:", err)
+		return 0
+	}
+	defer t.Destroy()
+	lv.r.Copy(t, nil, &sdl.Rect{X: x - s.W/2, Y: y, W: s.W, H: s.H})
+	return s.H
+}
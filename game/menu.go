@@ -0,0 +1,527 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
+	"github.com/fiorix/cat-o-licious/ui"
+)
+
+// Text alignments, relative to x.
+const (
+	alignLeft = iota
+	alignCenter
+	alignRight
+)
+
+// Action is what the player picked in the menu.
+type Action int
+
+// Actions of the menu.
+const (
+	NoAction Action = iota
+	PlayAction
+	HighScoresAction
+	StatsAction
+	AchievementsAction
+	ProfileAction
+	QuitAction
+	ResumeAction
+	EndAction // end the game
+	BackAction
+	SettingsAction
+	ApplyAction // apply the settings changed
+	KeysAction  // change the keys of the profile
+)
+
+// Menu draws the screens around the game: the profile picker, the
+// title, the pause overlay, the settings, the name entry of a new high
+// score, the high scores, the stats and the achievements. It keeps the high-score
+// table of the game mode.
+type Menu interface {
+	// GameOver ends the game with the given points and level, and
+	// returns true if the points make it to the high scores, for
+	// the name entry.
+	GameOver(points int64, level int) bool
+
+	// Type adds text to the name being entered.
+	Type(text string)
+
+	// Erase erases the last character of the name being entered.
+	Erase()
+
+	// Enter adds the high score of the last game with the name
+	// entered, and saves the high scores.
+	Enter()
+
+	// SetProfile sets the profile of the player, and the game mode
+	// of the high scores, e.g. "normal".
+	SetProfile(p *profile.Profile, mode string)
+
+	// DrawProfiles draws the profile picker with the widgets of u,
+	// and returns true if the profile selected is clicked.
+	DrawProfiles(viewport *sdl.Rect, u *ui.UI, p *profile.Picker) bool
+
+	// DrawTitle draws the title screen with the widgets of u, and
+	// returns the action picked, if any.
+	DrawTitle(viewport *sdl.Rect, u *ui.UI) Action
+
+	// DrawPause draws the pause overlay over the game with the
+	// widgets of u, and returns the action picked, if any.
+	DrawPause(viewport *sdl.Rect, u *ui.UI) Action
+
+	// DrawSettings draws the section of the settings of f with the
+	// widgets of u, which edit f, and returns the action picked, if
+	// any.
+	DrawSettings(viewport *sdl.Rect, u *ui.UI, f *settingsForm) Action
+
+	// DrawNameEntry draws the name entry screen.
+	DrawNameEntry(viewport *sdl.Rect)
+
+	// DrawHighScores draws the high scores of the game mode, and
+	// the result of the last game, if any, with the widgets of u,
+	// and returns the action picked, if any.
+	DrawHighScores(viewport *sdl.Rect, u *ui.UI) Action
+
+	// DrawStats draws the stats of the profile, and a message for
+	// the player, e.g. where the stats were exported.
+	DrawStats(viewport *sdl.Rect, e *stats.Export, msg string)
+
+	// DrawAchievements draws the gallery of achievements, and the
+	// description of the selected one.
+	DrawAchievements(viewport *sdl.Rect, st []achievement.Status, selected int)
+}
+
+type menu struct {
+	r      *sdl.Renderer
+	f      *font // text font
+	ft     *font // title font
+	ui     *uiRenderer
+	st     store.Store
+	scores *highscore.Table
+	mode   string
+	prof   *profile.Profile
+	name   []rune // name entered, kept for the next high score
+	points int64  // points of the last game, -1 if none
+	level  int    // level of the last game
+	rank   int    // rank of the last game in the high scores, or -1
+	sel    int    // high score selected
+}
+
+// NewMenu creates and initializes the menu, and loads the high scores
+// from the store. High scores that fail to load are logged and start
+// over.
+func NewMenu(r *sdl.Renderer, st store.Store) (Menu, error) {
+	f, err := openFont(28)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := openFont(60)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := openFont(20)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := highscore.Load(st)
+	if err != nil {
+		log.Println("failed to load high scores, starting over:", err)
+	}
+	ur := &uiRenderer{r: r, fonts: map[ui.Size]*font{
+		ui.Normal: f,
+		ui.Small:  fs,
+		ui.Large:  ft,
+	}}
+	return &menu{
+		r:      r,
+		f:      f,
+		ft:     ft,
+		ui:     ur,
+		st:     st,
+		scores: scores,
+		points: -1,
+		rank:   -1,
+	}, nil
+}
+
+// SetProfile implements the Menu interface.
+func (m *menu) SetProfile(p *profile.Profile, mode string) {
+	if m.prof != p {
+		m.name = []rune(p.Name)
+		m.points, m.rank, m.sel = -1, -1, 0
+	}
+	m.prof = p
+	m.mode = mode
+}
+
+// GameOver implements the Menu interface.
+func (m *menu) GameOver(points int64, level int) bool {
+	m.points, m.level, m.rank, m.sel = points, level, -1, 0
+	return m.scores.Qualifies(m.mode, points)
+}
+
+// Type implements the Menu interface.
+func (m *menu) Type(text string) {
+	for _, c := range text {
+		if len(m.name) < highscore.MaxName {
+			m.name = append(m.name, c)
+		}
+	}
+}
+
+// Erase implements the Menu interface.
+func (m *menu) Erase() {
+	if len(m.name) > 0 {
+		m.name = m.name[:len(m.name)-1]
+	}
+}
+
+// Enter implements the Menu interface.
+func (m *menu) Enter() {
+	e := highscore.Entry{
+		Name:    string(m.name),
+		Profile: m.prof.Name,
+		Points:  m.points,
+		Level:   m.level,
+		Date:    time.Now(),
+	}
+	m.rank = m.scores.Add(m.mode, e)
+	m.sel = max(m.rank, 0)
+	m.name = []rune(highscore.CleanName(string(m.name)))
+	if err := m.scores.Save(m.st); err != nil {
+		log.Println("failed to save high scores:", err)
+	}
+}
+
+// DrawTitle implements the Menu interface.
+func (m *menu) DrawTitle(viewport *sdl.Rect, u *ui.UI) Action {
+	const w = 400 // width of the column
+	u.Begin(m.ui)
+	defer u.End()
+	u.Layout(int(viewport.W-w)/2, int(viewport.H/6), w)
+	u.Label("cat-o-licious", ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	action := NoAction
+	for _, it := range []struct {
+		text   string
+		action Action
+	}{
+		{"Play", PlayAction},
+		{"High scores", HighScoresAction},
+		{"Stats", StatsAction},
+		{"Achievements", AchievementsAction},
+		{"Settings", SettingsAction},
+		{"Change player", ProfileAction},
+		{"Quit", QuitAction},
+	} {
+		if u.Button(locale.T(it.text)) {
+			action = it.action
+		}
+	}
+	u.Space(20)
+	u.Label(locale.Sprintf("Player: %s", m.prof.Name), ui.Normal, ui.White, ui.AlignCenter)
+	u.Label(locale.Sprintf("Difficulty: %s", modeText(m.mode)), ui.Normal, ui.White, ui.AlignCenter)
+	u.Space(20)
+	u.Label(locale.T("M to mute, - and + for the volume, F for full screen"), ui.Small, ui.Gray, ui.AlignCenter)
+	return action
+}
+
+// DrawProfiles implements the Menu interface.
+func (m *menu) DrawProfiles(viewport *sdl.Rect, u *ui.UI, p *profile.Picker) bool {
+	const w = 600 // width of the list
+	u.Begin(m.ui)
+	defer u.End()
+	u.Layout(int(viewport.W-w)/2, int(viewport.H/10), w)
+	u.Label(locale.T("Who's playing?"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Label(p.Help(), ui.Normal, ui.White, ui.AlignCenter)
+	u.Label(p.Message, ui.Normal, ui.Red, ui.AlignCenter)
+	picked := u.List(profileItems(p.Profiles), &p.Selected, max(len(p.Profiles.Profiles), 1))
+	if p.Mode == profile.Naming {
+		u.Space(20)
+		u.Label(string(p.Name)+cursor(), ui.Large, ui.Gold, ui.AlignCenter)
+	}
+	return picked && p.Mode == profile.Browse
+}
+
+// profileItems returns the items of the list of profiles: their name,
+// and their color, difficulty and keys.
+func profileItems(ps *profile.Profiles) []ui.Item {
+	items := make([]ui.Item, len(ps.Profiles))
+	for i, pr := range ps.Profiles {
+		keys := strings.Join(pr.Bindings.Left, "/") + " " + strings.Join(pr.Bindings.Right, "/")
+		items[i] = ui.Item{
+			Left:  pr.Name,
+			Right: locale.T(pr.Color) + ", " + locale.T(pr.Difficulty) + "  " + keys,
+		}
+	}
+	return items
+}
+
+// DrawPause implements the Menu interface.
+func (m *menu) DrawPause(viewport *sdl.Rect, u *ui.UI) Action {
+	const w = 400 // width of the column
+	m.ui.FillRect(ui.Rect{W: int(viewport.W), H: int(viewport.H)}, ui.Color{A: 160})
+	u.Begin(m.ui)
+	defer u.End()
+	u.Layout(int(viewport.W-w)/2, int(viewport.H/3), w)
+	u.Label(locale.T("Paused"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	action := NoAction
+	if u.Button(locale.T("Resume")) {
+		action = ResumeAction
+	}
+	if u.Button(locale.T("Settings")) {
+		action = SettingsAction
+	}
+	if u.Button(locale.T("End the game")) {
+		action = EndAction
+	}
+	if u.Button(locale.T("Quit")) {
+		action = QuitAction
+	}
+	return action
+}
+
+// DrawSettings implements the Menu interface.
+func (m *menu) DrawSettings(viewport *sdl.Rect, u *ui.UI, f *settingsForm) Action {
+	const w = 500 // width of the column
+	u.Begin(m.ui)
+	defer u.End()
+	u.Layout(int(viewport.W-w)/2, int(viewport.H/10), w)
+	u.Label(locale.T("Settings"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	u.Choice(locale.T("Show"), translate(settingsSections), &f.section)
+	u.Space(10)
+	action := NoAction
+	changed := func(ok bool) {
+		if ok {
+			action = ApplyAction
+		}
+	}
+	switch f.section {
+	case videoSection:
+		names := make([]string, len(f.resolutions))
+		for i, r := range f.resolutions {
+			names[i] = r.String()
+		}
+		fps := make([]string, len(f.fpsCaps))
+		for i, n := range f.fpsCaps {
+			fps[i] = fmt.Sprint(n)
+		}
+		changed(u.Choice(locale.T("Window"), names, &f.resolution))
+		changed(u.Toggle(locale.T("Full screen"), &f.Video.Fullscreen))
+		changed(u.Choice(locale.T("Frame rate"), fps, &f.fps))
+	case gameplaySection:
+		changed(u.Slider(locale.T("Player speed"), &f.speed, speedStep))
+		changed(u.Choice(locale.T("Difficulty"), translate(difficultyOptions()), &f.difficulty))
+		changed(u.Toggle(locale.T("Adaptive difficulty"), &f.Gameplay.Adaptive))
+		u.Label(locale.T("Difficulty applies from the next game"), ui.Small, ui.Gray, ui.AlignCenter)
+	case audioSection:
+		changed(u.Slider(locale.T("Volume"), &f.Audio.Master, settings.VolumeStep))
+		changed(u.Slider(locale.T("Music"), &f.Audio.Music, settings.VolumeStep))
+		changed(u.Slider(locale.T("Sound effects"), &f.Audio.SFX, settings.VolumeStep))
+		changed(u.Toggle(locale.T("Mute"), &f.Audio.Muted))
+	case controlsSection:
+		u.Row(locale.T("Keys"), f.keys, ui.White)
+		if u.Button(locale.T("Change keys")) {
+			action = KeysAction
+		}
+		u.Label(locale.T("Gamepads: D-pad, A to pick, B back, Start to pause"), ui.Small, ui.Gray, ui.AlignCenter)
+	case accessibilitySection:
+		changed(u.Toggle(locale.T("Audio cues"), &f.Accessibility.AudioCues))
+		changed(u.Toggle(locale.T("Reduce motion"), &f.Accessibility.ReduceMotion))
+	}
+	u.Space(20)
+	if u.Button(locale.T("Back")) {
+		action = BackAction
+	}
+	return action
+}
+
+// DrawNameEntry implements the Menu interface.
+func (m *menu) DrawNameEntry(viewport *sdl.Rect) {
+	gold := sdl.Color{R: 255, G: 215, A: 255}
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	x, y := viewport.W/2, viewport.H/4
+	y += m.drawText(m.ft, locale.T("New high score!"), gold, x, y, alignCenter)
+	y += m.drawText(m.f, locale.Pluralf(m.points, "%d points", m.points), white, x, y, alignCenter) * 2
+	y += m.drawText(m.f, locale.T("Type your name and press Enter"), white, x, y, alignCenter)
+	m.drawText(m.ft, string(m.name)+cursor(), gold, x, y, alignCenter)
+}
+
+// cursor returns the blinking cursor of text entry.
+func cursor() string {
+	if time.Now().UnixNano()/int64(500*time.Millisecond)%2 == 1 {
+		return " "
+	}
+	return "_"
+}
+
+// modeText returns the mode of the game in the locale of the game,
+// e.g. "normal/adaptive".
+func modeText(mode string) string {
+	parts := strings.Split(mode, "/")
+	for i, p := range parts {
+		parts[i] = locale.T(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// translate returns the translations of the messages, e.g. the options
+// of a choice.
+func translate(ids []string) []string {
+	t := make([]string, len(ids))
+	for i, id := range ids {
+		t[i] = locale.T(id)
+	}
+	return t
+}
+
+// DrawHighScores implements the Menu interface.
+func (m *menu) DrawHighScores(viewport *sdl.Rect, u *ui.UI) Action {
+	const w = 400 // width of the table
+	u.Begin(m.ui)
+	defer u.End()
+	u.Layout(int(viewport.W-w)/2, int(viewport.H/10), w)
+	u.Label(locale.T("High scores"), ui.Large, ui.Gold, ui.AlignCenter)
+	if m.points >= 0 {
+		text := locale.Pluralf(m.points, "Game over: %d points", m.points)
+		u.Label(text, ui.Normal, ui.White, ui.AlignCenter)
+	}
+	u.Label(locale.Sprintf("Difficulty: %s", modeText(m.mode)), ui.Normal, ui.White, ui.AlignCenter)
+	u.Space(10)
+	top := m.scores.Top(m.mode)
+	if len(top) == 0 {
+		u.Space(20)
+		u.Label(locale.T("No high scores yet"), ui.Normal, ui.White, ui.AlignCenter)
+	}
+	back := len(top) > 0 && u.List(scoreItems(top), &m.sel, len(top))
+	u.Space(20)
+	if u.Button(locale.T("Back")) || back {
+		return BackAction
+	}
+	return NoAction
+}
+
+// scoreItems returns the items of the list of high scores.
+func scoreItems(top []highscore.Entry) []ui.Item {
+	items := make([]ui.Item, len(top))
+	for i, e := range top {
+		items[i] = ui.Item{Left: fmt.Sprintf("%d. %s", i+1, e.Name), Right: fmt.Sprint(e.Points)}
+	}
+	return items
+}
+
+// drawText draws text aligned to x, and returns its height.
+func (m *menu) drawText(f *font, text string, c sdl.Color, x, y int32, align int) int32 {
+	if text == "" {
+		return int32(f.Height())
+	}
+	s, err := f.RenderUTF8Blended(text, c)
+	if err != nil {
+		log.Println("failed to create font surface:", err)
+		return 0
+	}
+	defer s.Free()
+	t, err := m.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create font texture:", err)
+		return 0
+	}
+	defer t.Destroy()
+	switch align {
+	case alignCenter:
+		x -= s.W / 2
+	case alignRight:
+		x -= s.W
+	}
+	m.r.Copy(t, nil, &sdl.Rect{X: x, Y: y, W: s.W, H: s.H})
+	return s.H
+}
+
+// DrawStats implements the Menu interface.
+func (m *menu) DrawStats(viewport *sdl.Rect, e *stats.Export, msg string) {
+	gold := sdl.Color{R: 255, G: 215, A: 255}
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	x, y := viewport.W/2, viewport.H/12
+	y += m.drawText(m.ft, locale.Sprintf("Stats of %s", e.Profile), gold, x, y, alignCenter)
+	m.drawRows(e.Summary(), x-370, y, gold, white)
+	m.drawRows(e.Drops(), x+20, y, gold, white)
+	if msg == "" {
+		msg = locale.T("E to export, Enter to go back")
+	}
+	m.drawText(m.f, msg, white, x, viewport.H-viewport.H/12, alignCenter)
+}
+
+// drawRows draws the rows of stats in columns from x, under a header
+// of the columns.
+func (m *menu) drawRows(rows []stats.Row, x, y int32, header, c sdl.Color) {
+	const label, game, all = 0, 250, 350 // columns, relative to x
+	m.drawText(m.f, locale.T("Game"), header, x+game, y, alignRight)
+	y += m.drawText(m.f, locale.T("All"), header, x+all, y, alignRight)
+	for _, r := range rows {
+		m.drawText(m.f, r.Label, c, x+label, y, alignLeft)
+		m.drawText(m.f, r.Game, c, x+game, y, alignRight)
+		y += m.drawText(m.f, r.All, c, x+all, y, alignRight)
+	}
+}
+
+// DrawAchievements implements the Menu interface.
+func (m *menu) DrawAchievements(viewport *sdl.Rect, st []achievement.Status, selected int) {
+	gold := sdl.Color{R: 255, G: 215, A: 255}
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	gray := sdl.Color{R: 128, G: 128, B: 128, A: 255}
+	x, y := viewport.W/2, viewport.H/20
+	n := 0
+	for _, s := range st {
+		if !s.Unlocked.IsZero() {
+			n++
+		}
+	}
+	title := locale.Sprintf("Achievements %d/%d", n, len(st))
+	y += m.drawText(m.ft, title, gold, x, y, alignCenter)
+	const w = 370 // half the width of the list
+	// scroll the list to keep the selected achievement in view
+	bottom := viewport.H - viewport.H/6
+	first := 0
+	if rows := int((bottom - y) / int32(m.f.Height())); selected >= rows {
+		first = selected - rows + 1
+	}
+	for i := first; i < len(st) && y+int32(m.f.Height()) <= bottom; i++ {
+		s := st[i]
+		c, name, status := gray, locale.T(s.Name), ""
+		switch {
+		case !s.Unlocked.IsZero():
+			c, status = gold, s.Unlocked.Format("2006-01-02")
+		case s.Cumulative():
+			status = fmt.Sprintf("%d/%d", s.Count, s.Goal)
+		}
+		if i == selected {
+			name = "> " + name
+		}
+		m.drawText(m.f, name, c, x-w, y, alignLeft)
+		y += m.drawText(m.f, status, c, x+w, y, alignRight)
+	}
+	y = bottom
+	if selected < len(st) {
+		m.drawText(m.f, locale.T(st[selected].Description), white, x, y, alignCenter)
+	}
+	m.drawText(m.f, locale.T("Up and down to browse, Enter to go back"), white, x, y+40, alignCenter)
+}
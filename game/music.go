@@ -0,0 +1,132 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"os"
+	"time"
+
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/music"
+)
+
+// musicChannels are the mixer channels reserved for the music, one
+// for the current track and one for the track fading out. Tracks are
+// played as chunks on these channels, because the mixer cannot play
+// two music streams at once for the crossfade.
+var musicChannels = [2]int{0, 1}
+
+// Music plays the playlist, switching tracks on score and level
+// milestones with a crossfade.
+type Music interface {
+	// Update starts the music, switches tracks on milestones of the
+	// given points and level, and fades between tracks.
+	Update(now time.Time, points int64, level int)
+
+	// Reset restarts the milestones for a new game.
+	Reset()
+
+	// SetPaused pauses or resumes the music.
+	SetPaused(paused bool)
+
+	// SetVolume sets the volume of the music, from 0 to 1.
+	SetVolume(v float64)
+
+	// Volume returns the volume of the music.
+	Volume() float64
+}
+
+type musicPlayer struct {
+	mx     *music.Mixer
+	tracks []*sdlmix.Chunk
+	slot   int // index of the channel of the current track
+	cur    int // track playing on the current channel, -1 if none
+	last   time.Time
+}
+
+// NewMusic creates and initializes the music of the playlist. Tracks
+// whose files are missing are skipped, and the game plays without
+// music if all of them are.
+func NewMusic(pl *music.Playlist) (Music, error) {
+	m := &musicPlayer{cur: -1}
+	found := *pl
+	found.Tracks = nil
+	for _, t := range pl.Tracks {
+		if _, err := os.Stat(t.File); os.IsNotExist(err) {
+			log.Printf("%s not found, skipping music track %q", t.File, t.Name)
+			continue
+		}
+		c, err := sdlmix.LoadWAV(t.File)
+		if err != nil {
+			return nil, err
+		}
+		m.tracks = append(m.tracks, c)
+		found.Tracks = append(found.Tracks, t)
+	}
+	m.mx = music.NewMixer(&found)
+	sdlmix.ReserveChannels(len(musicChannels))
+	return m, nil
+}
+
+// Update implements the Music interface.
+func (m *musicPlayer) Update(now time.Time, points int64, level int) {
+	dt := frameTime(m.last, now)
+	m.last = now
+	if m.mx.Paused() || len(m.tracks) == 0 {
+		return
+	}
+	m.mx.Score(points)
+	m.mx.Level(level)
+	m.mx.Update(dt)
+	if cur := m.mx.Current(); cur != m.cur {
+		// start the new track on the other channel, and let
+		// the current one fade out
+		if m.cur >= 0 {
+			m.slot ^= 1
+		}
+		m.cur = cur
+		ch := musicChannels[m.slot]
+		sdlmix.HaltChannel(ch)
+		m.tracks[cur].Play(ch, -1)
+	}
+	vcur, vprev := m.mx.Volumes()
+	sdlmix.Volume(musicChannels[m.slot], int(vcur*sdlmix.MAX_VOLUME))
+	other := musicChannels[m.slot^1]
+	if m.mx.Previous() < 0 {
+		sdlmix.HaltChannel(other)
+		return
+	}
+	sdlmix.Volume(other, int(vprev*sdlmix.MAX_VOLUME))
+}
+
+// Reset implements the Music interface.
+func (m *musicPlayer) Reset() {
+	m.mx.Reset()
+}
+
+// SetPaused implements the Music interface.
+func (m *musicPlayer) SetPaused(paused bool) {
+	m.mx.SetPaused(paused)
+	for _, ch := range musicChannels {
+		if paused {
+			sdlmix.Pause(ch)
+		} else {
+			sdlmix.Resume(ch)
+		}
+	}
+}
+
+// SetVolume implements the Music interface.
+func (m *musicPlayer) SetVolume(v float64) {
+	m.mx.SetVolume(v)
+}
+
+// Volume implements the Music interface.
+func (m *musicPlayer) Volume() float64 {
+	return m.mx.Volume()
+}
@@ -0,0 +1,89 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/fx"
+)
+
+// maxFrameTime caps the time step of animations, so effects don't
+// jump ahead after the game has been stalled (e.g. window dragging).
+const maxFrameTime = 100 * time.Millisecond
+
+// frameTime returns the time step between the last and current frame.
+func frameTime(last, now time.Time) time.Duration {
+	if last.IsZero() {
+		return 0
+	}
+	dt := now.Sub(last)
+	if dt > maxFrameTime {
+		return maxFrameTime
+	}
+	return dt
+}
+
+// Particles draws particle effects such as sparkles and crumbs.
+type Particles interface {
+	// Emit emits a burst of particles at x, y. The sprite of the
+	// emitter, if set, must be an Image.
+	Emit(e fx.Emitter, x, y int32)
+
+	// Draw updates and draws the particles.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+type particles struct {
+	r    *sdl.Renderer
+	sys  fx.System
+	last time.Time
+}
+
+// NewParticles creates and initializes a new particle renderer.
+func NewParticles(r *sdl.Renderer) Particles {
+	return &particles{r: r}
+}
+
+// Emit implements the Particles interface.
+func (ps *particles) Emit(e fx.Emitter, x, y int32) {
+	ps.sys.Emit(e, float64(x), float64(y))
+}
+
+// Draw implements the Particles interface.
+func (ps *particles) Draw(now time.Time, viewport *sdl.Rect) {
+	dt := frameTime(ps.last, now)
+	ps.last = now
+	ps.sys.Update(dt)
+	ps.r.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	for _, p := range ps.sys.Particles() {
+		alpha := p.Alpha()
+		w := int32(p.Size)
+		if w < 1 {
+			w = 1
+		}
+		h := w
+		img, ok := p.Sprite.(Image)
+		if ok {
+			iw, ih := img.Size()
+			h = w * ih / iw
+		}
+		r := &sdl.Rect{X: int32(p.X) - w/2, Y: int32(p.Y) - h/2, W: w, H: h}
+		if !ok {
+			c := p.Color
+			ps.r.SetDrawColor(c.R, c.G, c.B, uint8(float64(c.A)*alpha))
+			ps.r.FillRect(r)
+			continue
+		}
+		t := img.Texture()
+		t.SetAlphaMod(uint8(255 * alpha))
+		ps.r.Copy(t, nil, r)
+		t.SetAlphaMod(255)
+	}
+	ps.r.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+}
@@ -6,11 +6,18 @@
 package game
 
 import (
-	"errors"
+	"fmt"
+	"math"
 	"sync/atomic"
+	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/sound"
+	"github.com/fiorix/cat-o-licious/sprite"
+	"github.com/fiorix/cat-o-licious/tween"
 )
 
 // Direction is the player's lateral movement direction.
@@ -26,16 +33,6 @@ const (
 // DefaultPlayerSide configures the default facing side of the player.
 var DefaultPlayerSide = Right
 
-// DefaultPlayerHitSquare defines the hit square within the
-// player image, in percentages.
-var DefaultPlayerHitSquare = struct{ X, Y, W, H, FlipOffset float32 }{
-	X:          .4,  // left offset
-	Y:          .8,  // top offset
-	W:          .3,  // pct of the img width
-	H:          .15, // pct of the img height
-	FlipOffset: .1,  // X offset for the horizontal flip
-}
-
 // Player is a game player.
 type Player interface {
 	// Move moves the player.
@@ -45,59 +42,104 @@ type Player interface {
 	// with the player's hit area. This is when you make points.
 	Hit(d Drop) bool
 
+	// Catch plays the animation of catching the drop: eating good
+	// drops, and disgust for bad ones.
+	Catch(d Drop)
+
+	// HitArea returns the player's hit area in the viewport.
+	HitArea() sdl.Rect
+
+	// SetColor sets the color of the cat.
+	SetColor(c profile.Color)
+
 	// Draw draws the player.
-	Draw(viewport *sdl.Rect)
+	Draw(now time.Time, viewport *sdl.Rect)
 }
 
-// players need at least 3 images, loaded in the following sequence:
-const (
-	moving  = 0
-	winning = 1
-	losing  = 2
-)
+// walkTime is how long the player keeps walking after a move.
+const walkTime = 200 * time.Millisecond
+
+// playerMoveTime is how long the player takes to glide to where
+// it has been moved.
+const playerMoveTime = 120 * time.Millisecond
+
+// player clips, from the sprite catalog
+var playerClips = []string{"idle", "walk", "eat", "disgust"}
 
 type player struct {
-	r    *sdl.Renderer   // main renderer
-	imgs []Image         // available images
-	img  Image           // current player image
-	x    int32           // lateral movement
-	y    int32           // vertical position relative to viewport
-	d    Direction       // facing side
-	hitP sdl.Rect        // hit area of the player
-	hitC int             // hit counter to swap image for N frames
-	sfx  []*sdlmix.Chunk // available sfx
+	r     *sdl.Renderer            // main renderer
+	imgs  []Image                  // available images
+	hbs   []sprite.Hitbox          // hit squares of the available images
+	anim  sprite.Animation         // current animation
+	move  *tween.Tween             // lateral movement drawn
+	last  time.Time                // time of the last frame drawn
+	moved int64                    // time of the last move, in unix nanoseconds
+	x     int32                    // lateral movement target
+	y     int32                    // vertical position relative to viewport
+	d     Direction                // facing side
+	hitP  sdl.Rect                 // hit area of the player
+	hitX  int32                    // where the last drop was caught
+	sfx   map[string]*sdlmix.Chunk // sfx played on animation events
 }
 
-// NewPlayer creates and initializes a new player.
-func NewPlayer(r *sdl.Renderer) (Player, error) {
+// NewPlayer creates and initializes a new player. Hit squares and
+// animation clips of the player images are taken from the sprite
+// catalog.
+func NewPlayer(r *sdl.Renderer, sprites sprite.Catalog) (Player, error) {
 	imgs, err := NewImageSetFromFiles(r, "assets/img/player_frame_")
 	if err != nil {
 		return nil, err
 	}
-	if len(imgs) < 3 {
-		return nil, errors.New("need at least 3 player frames")
+	clips := sprites.Clips("player_frame")
+	if err := checkClips(clips, playerClips, len(imgs)); err != nil {
+		return nil, fmt.Errorf("player: %v", err)
 	}
-	sfxwin, err := sdlmix.LoadWAV("assets/snd/score_win_1.wav")
+	sfxwin, err := loadSound("assets/snd/score_win_1.wav", "win")
 	if err != nil {
 		return nil, err
 	}
-	sfxlose, err := sdlmix.LoadWAV("assets/snd/score_lose_1.wav")
+	sfxlose, err := loadSound("assets/snd/score_lose_1.wav", "lose")
 	if err != nil {
 		return nil, err
 	}
+	hbs := make([]sprite.Hitbox, len(imgs))
+	for i := range imgs {
+		hbs[i] = sprites.Hitbox("player_frame", i+1)
+	}
 	p := &player{
 		r:    r,
 		imgs: imgs,
-		img:  imgs[moving],
+		hbs:  hbs,
+		anim: sprite.NewAnimation(clips),
 		d:    Center,
-		sfx:  []*sdlmix.Chunk{nil, sfxwin, sfxlose},
+		sfx:  map[string]*sdlmix.Chunk{"win": sfxwin, "lose": sfxlose},
 	}
+	p.anim.Play("idle")
 	return p, nil
 }
 
+// checkClips returns an error if any of the named clips is missing,
+// or if any clip shows a frame past the given number of frames.
+func checkClips(clips map[string]*sprite.Clip, names []string, frames int) error {
+	for _, name := range names {
+		if _, ok := clips[name]; !ok {
+			return fmt.Errorf("missing animation clip %q", name)
+		}
+	}
+	for name, c := range clips {
+		for _, k := range c.Keys {
+			if k.Frame > frames {
+				return fmt.Errorf("clip %q: frame %d of %d", name, k.Frame, frames)
+			}
+		}
+	}
+	return nil
+}
+
 // Move implements the Player interface.
 func (p *player) Move(d Direction, steps int32) {
 	p.d = d
+	atomic.StoreInt64(&p.moved, time.Now().UnixNano())
 	switch d {
 	case Left:
 		atomic.AddInt32(&p.x, -steps)
@@ -106,19 +148,57 @@ func (p *player) Move(d Direction, steps int32) {
 	}
 }
 
+// SetColor implements the Player interface.
+func (p *player) SetColor(c profile.Color) {
+	for _, img := range p.imgs {
+		img.Texture().SetColorMod(c.R, c.G, c.B)
+	}
+}
+
+// animate switches between the idle and walk clips, advances the
+// animation, and plays the sfx of its events panned to where the
+// last drop was caught, within the viewport width w.
+func (p *player) animate(now time.Time, dt time.Duration, w int32) {
+	moving := time.Since(time.Unix(0, atomic.LoadInt64(&p.moved))) < walkTime
+	switch {
+	case moving && p.anim.Playing() == "idle":
+		p.anim.Play("walk")
+	case !moving && p.anim.Playing() == "walk":
+		p.anim.Play("idle")
+	}
+	for _, ev := range p.anim.Update(dt) {
+		if sfx, ok := p.sfx[ev]; ok {
+			playAt(sfx, sound.Pan(float64(p.hitX), float64(w)))
+		}
+	}
+}
+
+// glide moves the player smoothly toward x, and returns the lateral
+// position to draw the player at.
+func (p *player) glide(x int32, dt time.Duration) int32 {
+	switch {
+	case p.move == nil:
+		p.move = tween.New(float64(x), float64(x), 0, nil)
+	case p.move.To != float64(x):
+		p.move = tween.New(p.move.Value(), float64(x), playerMoveTime, tween.OutQuad)
+	}
+	p.move.Update(dt)
+	return int32(math.Round(p.move.Value()))
+}
+
 // Draw implements the Player interface.
-func (p *player) Draw(viewport *sdl.Rect) {
+func (p *player) Draw(now time.Time, viewport *sdl.Rect) {
+	dt := frameTime(p.last, now)
+	p.last = now
+	p.animate(now, dt, viewport.W)
 	x := atomic.LoadInt32(&p.x)
-	if p.hitC > 0 {
-		// reset player image from hit to moving after 10 frames
-		if p.hitC == 10 {
-			p.hitC = 0
-			p.img = p.imgs[moving]
-		} else {
-			p.hitC++
-		}
+	pose := p.anim.Pose()
+	frame := pose.Frame
+	if frame == 0 {
+		frame = 1
 	}
-	w, h := p.img.Size()
+	img := p.imgs[frame-1]
+	w, h := img.Size()
 	if p.d == Center {
 		x = (viewport.W / 2) - (w / 2)
 		p.d = DefaultPlayerSide
@@ -136,46 +216,50 @@ func (p *player) Draw(viewport *sdl.Rect) {
 		}
 	}
 	atomic.StoreInt32(&p.x, x)
+	x = p.glide(x, dt)
 	p.y = int32(float32(viewport.H)/1.5) - (h / 2)
-	r := &sdl.Rect{X: x, Y: p.y, W: w, H: h}
-	hs := DefaultPlayerHitSquare
+	flipped := p.d != DefaultPlayerSide
 	// save player's current hit square position
-	p.hitP = sdl.Rect{
-		X: x + int32(float32(w)*hs.X),
-		Y: p.y + int32(float32(h)*hs.Y),
-		W: int32(float32(w) * hs.W),
-		H: int32(float32(h) * hs.H),
+	hx, hy, hw, hh := p.hbs[frame-1].Rect(int(x), int(p.y), int(w), int(h), flipped)
+	p.hitP = sdl.Rect{X: int32(hx), Y: int32(hy), W: int32(hw), H: int32(hh)}
+	// render the pose of the animation, scaled from the center
+	// and anchored at the bottom
+	sw, sh := int32(float64(w)*pose.Scale), int32(float64(h)*pose.Scale)
+	r := &sdl.Rect{
+		X: x - (sw-w)/2,
+		Y: p.y + h - sh + int32(pose.DY*float64(h)),
+		W: sw,
+		H: sh,
 	}
 	// render player facing default side
-	if p.d == DefaultPlayerSide {
-		p.r.Copy(p.img.Texture(), nil, r)
+	if !flipped {
+		p.r.CopyEx(img.Texture(), nil, r, pose.Angle, nil, sdl.FLIP_NONE)
 		//p.r.DrawRect(&p.hitP) // debug
 		return
 	}
-	// render player facing the opposide side, then adjust
-	// the hit square X position
-	p.r.CopyEx(p.img.Texture(), nil, r, 0, nil, sdl.FLIP_HORIZONTAL)
-	p.hitP.X = p.hitP.X - int32(float32(w)*hs.FlipOffset)
+	// render player facing the opposide side
+	p.r.CopyEx(img.Texture(), nil, r, -pose.Angle, nil, sdl.FLIP_HORIZONTAL)
 	//p.r.DrawRect(&p.hitP) // debug
 }
 
 // Hit implements the Player interface.
 func (p *player) Hit(d Drop) bool {
-	hit := p.hitP
-	area := d.Pos()
-	if (area.Y+area.H) < hit.Y || area.Y > hit.Y {
-		return false
-	}
-	if (area.X+area.W) < hit.X || area.X > (hit.X+hit.W) {
-		return false
-	}
-	p.hitC = 1
-	if d.Points() > 0 {
-		p.img = p.imgs[winning]
-		p.sfx[winning].Play(1, 0)
+	area := d.HitArea()
+	return p.hitP.HasIntersection(&area)
+}
+
+// Catch implements the Player interface.
+func (p *player) Catch(d Drop) {
+	area := d.HitArea()
+	p.hitX = area.X + area.W/2
+	if d.Points() >= 0 {
+		p.anim.Play("eat")
 	} else {
-		p.img = p.imgs[losing]
-		p.sfx[losing].Play(1, 0)
+		p.anim.Play("disgust")
 	}
-	return true
+}
+
+// HitArea implements the Player interface.
+func (p *player) HitArea() sdl.Rect {
+	return p.hitP
 }
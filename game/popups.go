@@ -0,0 +1,74 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/fx"
+)
+
+// Popups draws score popups, e.g. "+15", where drops are caught.
+type Popups interface {
+	// Spawn spawns a popup for the given points at x, y.
+	Spawn(points int64, x, y int32)
+
+	// Draw updates and draws the popups.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+type popups struct {
+	r    *sdl.Renderer
+	f    *font
+	ps   fx.Popups
+	last time.Time
+}
+
+// NewPopups creates and initializes a new popup renderer.
+func NewPopups(r *sdl.Renderer) (Popups, error) {
+	f, err := openFont(32)
+	if err != nil {
+		return nil, err
+	}
+	return &popups{r: r, f: f}, nil
+}
+
+// Spawn implements the Popups interface.
+func (pp *popups) Spawn(points int64, x, y int32) {
+	pp.ps.Spawn(points, float64(x), float64(y))
+}
+
+// Draw implements the Popups interface.
+func (pp *popups) Draw(now time.Time, viewport *sdl.Rect) {
+	pp.ps.Update(frameTime(pp.last, now))
+	pp.last = now
+	for _, p := range pp.ps.List() {
+		c := sdl.Color{R: p.Color.R, G: p.Color.G, B: p.Color.B, A: p.Color.A}
+		s, err := pp.f.RenderUTF8Blended(p.Text, c)
+		if err != nil {
+			log.Println("failed to create popup surface:", err)
+			continue
+		}
+		t, err := pp.r.CreateTextureFromSurface(s)
+		s.Free()
+		if err != nil {
+			log.Println("failed to create popup texture:", err)
+			continue
+		}
+		_, _, w, h, _ := t.Query()
+		t.SetAlphaMod(uint8(255 * p.Alpha()))
+		pp.r.Copy(t, nil, &sdl.Rect{
+			X: int32(p.X) - w/2,
+			Y: int32(p.Y) - h/2,
+			W: w,
+			H: h,
+		})
+		t.Destroy()
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/powerup"
+)
+
+// PowerUps tracks the active power-ups and draws their timers.
+type PowerUps interface {
+	// Activate activates the power-up.
+	Activate(k powerup.Kind, now time.Time)
+
+	// Deactivate deactivates the power-up.
+	Deactivate(k powerup.Kind)
+
+	// Active returns true if the power-up is active.
+	Active(k powerup.Kind, now time.Time) bool
+
+	// Reset deactivates all power-ups.
+	Reset()
+
+	// TimeScale returns the speed of the rain.
+	TimeScale(now time.Time) float64
+
+	// Draw draws the icons and timers of the active power-ups.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+type powerups struct {
+	powerup.Effects
+	r    *sdl.Renderer
+	f    *font
+	imgs []Image
+	sfx  *sdlmix.Chunk
+}
+
+// NewPowerUps creates and initializes the power-ups.
+func NewPowerUps(r *sdl.Renderer) (PowerUps, error) {
+	imgs, err := NewImageSetFromFiles(r, "assets/img/powerup_")
+	if err != nil {
+		return nil, err
+	}
+	f, err := openFont(28)
+	if err != nil {
+		return nil, err
+	}
+	sfx, err := loadSound("assets/snd/powerup_1.wav", "powerup")
+	if err != nil {
+		return nil, err
+	}
+	return &powerups{r: r, f: f, imgs: imgs, sfx: sfx}, nil
+}
+
+// Activate implements the PowerUps interface.
+func (pu *powerups) Activate(k powerup.Kind, now time.Time) {
+	pu.Effects.Activate(k, now)
+	playAt(pu.sfx, 0)
+}
+
+// Reset implements the PowerUps interface.
+func (pu *powerups) Reset() {
+	pu.Effects = powerup.Effects{}
+}
+
+// Draw implements the PowerUps interface.
+func (pu *powerups) Draw(now time.Time, viewport *sdl.Rect) {
+	const size = 40
+	x := int32(float32(viewport.W) * .05)
+	y := int32(float32(viewport.H) * .1)
+	for i, k := range powerup.Kinds {
+		left := pu.Remaining(k, now)
+		if left == 0 || i >= len(pu.imgs) {
+			continue
+		}
+		// blink for the last 2 seconds
+		if left < 2*time.Second && left%(250*time.Millisecond) < 125*time.Millisecond {
+			y += size + 8
+			continue
+		}
+		pu.r.Copy(pu.imgs[i].Texture(), nil, &sdl.Rect{X: x, Y: y, W: size, H: size})
+		text := fmt.Sprintf("%d", int(left.Seconds())+1)
+		s, err := pu.f.RenderUTF8Blended(text, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+		if err != nil {
+			log.Println("failed to create font surface:", err)
+			return
+		}
+		t, err := pu.r.CreateTextureFromSurface(s)
+		if err != nil {
+			s.Free()
+			log.Println("failed to create font texture:", err)
+			return
+		}
+		pu.r.Copy(t, nil, &sdl.Rect{X: x + size + 8, Y: y + (size-s.H)/2, W: s.W, H: s.H})
+		t.Destroy()
+		s.Free()
+		y += size + 8
+	}
+}
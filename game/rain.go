@@ -6,21 +6,68 @@
 package game
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/motion"
+	"github.com/fiorix/cat-o-licious/powerup"
+	"github.com/fiorix/cat-o-licious/sprite"
 )
 
 // Rain makes objects (raindrops) fall from the top of the viewport
 // all the way to the bottom.
 type Rain interface {
-	// SetRate sets the rate of drops per second.
-	SetRate(n int)
+	// SetParams sets the rain parameters, such as the interval
+	// between new drops, for the current difficulty.
+	SetParams(p difficulty.Params)
+
+	// SetTimeScale sets the speed of the rain, where 1 is the
+	// normal speed. It scales both the fall speed of the drops
+	// and the rate of new drops.
+	SetTimeScale(f float64)
+
+	// Attract moves good drops laterally toward x, at the given
+	// speed in pixels per frame.
+	Attract(x, speed int32)
+
+	// Names returns the names of the drops of the rain, from the
+	// drop catalog.
+	Names() []string
+
+	// SetPool restricts new random drops to the named drops. The
+	// nil pool allows all drops, and an empty pool none.
+	SetPool(names []string)
+
+	// Spawn adds the named drop to the rain on the next call to
+	// Draw, at the horizontal position x from 0 (left) to 1 (right).
+	Spawn(name string, x float64)
 
 	// Drops returns all raindrops from the rain.
 	Drops() []Drop
 
+	// Landed returns the drops that hit the floor, the bottom of
+	// the viewport, during the last call to Draw.
+	Landed() []Drop
+
+	// Approaching returns the drops that fell past the middle of
+	// the viewport, toward the player, during the last call to Draw.
+	Approaching() []Drop
+
+	// Reset removes all drops for a new game.
+	Reset()
+
+	// SetPaused freezes the drops where they are, or lets them
+	// fall again. Paused rain draws no new drops, and no drops
+	// land or approach the player.
+	SetPaused(paused bool)
+
 	// Draw draws the rain.
 	Draw(now time.Time, viewport *sdl.Rect)
 }
@@ -30,10 +77,26 @@ type Drop interface {
 	// Pos returns the position of the drop in the viewport.
 	Pos() sdl.Rect
 
+	// HitArea returns the area of the drop that collides
+	// with the player, within the drop's position.
+	HitArea() sdl.Rect
+
+	// Image returns the image of the drop.
+	Image() Image
+
+	// Name returns the name of the drop in the drop catalog,
+	// e.g. bacon.
+	Name() string
+
 	// Points returns delta points for the drop. Good drops
 	// return positive numbers while bad drops return negative.
+	// Halves of a split drop return half the points each.
 	Points() int64
 
+	// PowerUp returns the power-up of the drop, or powerup.None
+	// for regular drops. Power-ups have no points.
+	PowerUp() powerup.Kind
+
 	// Consume marks the drop as consumed by the player.
 	Consume()
 
@@ -43,32 +106,60 @@ type Drop interface {
 
 // rain implements the Rain interface.
 type rain struct {
-	r         *sdl.Renderer
-	lastdrop  time.Time
-	available []*raindrop
-	drops     []*drop
-	delay     time.Duration
+	r        *sdl.Renderer
+	bus      *event.Bus
+	lastdrop time.Time
+	good     []*raindrop
+	bad      []*raindrop
+	powerups []*raindrop
+	byName   map[string]*raindrop
+	pool     []*raindrop
+	pending  []spawn
+	drops    []*drop
+	landed   []Drop
+	near     []Drop // drops approaching the player
+	params   difficulty.Params
+	scale    float64
+	last     time.Time // time of the last frame drawn
+	paused   bool
 }
 
 // raindrop is a storage for drop images and the points associated
 // to them: good drops have positive points, bad drops have negative.
+// Power-up drops have no points.
 type raindrop struct {
-	r      *sdl.Renderer
-	img    Image
-	points int64
+	r       *sdl.Renderer
+	img     Image
+	hb      sprite.Hitbox
+	clips   map[string]*sprite.Clip
+	def     *catalog.Def
+	points  int64
+	powerup powerup.Kind
+}
+
+// spawn is a drop to be spawned at x, from 0 to 1.
+type spawn struct {
+	rd *raindrop
+	x  float64
 }
 
-// NewRain creates and initializes a Rain object.
-func NewRain(r *sdl.Renderer) (Rain, error) {
-	var rd []*raindrop
+// NewRain creates and initializes a Rain object. Hit boxes of the
+// drop images are taken from the sprite catalog, and their movement
+// behaviors from the drop catalog. New drops are published to the
+// bus.
+func NewRain(r *sdl.Renderer, sprites sprite.Catalog, drops catalog.Catalog, bus *event.Bus) (Rain, error) {
+	var good, bad []*raindrop
 	imgs, err := NewImageSetFromFiles(r, "assets/img/drop_good_")
 	if err != nil {
 		return nil, err
 	}
 	for i, img := range imgs {
-		rd = append(rd, &raindrop{
+		good = append(good, &raindrop{
 			// you get 5*frameidx(e.g. bacon) points per hit
 			r: r, img: img, points: int64(i+1) * 5,
+			hb:    sprites.Hitbox("drop_good", i+1),
+			clips: sprites.Clips("drop_good"),
+			def:   drops.Lookup(fmt.Sprintf("drop_good_%d", i+1)),
 		})
 	}
 	imgs, err = NewImageSetFromFiles(r, "assets/img/drop_bad_")
@@ -76,78 +167,233 @@ func NewRain(r *sdl.Renderer) (Rain, error) {
 		return nil, err
 	}
 	for i, img := range imgs {
-		rd = append(rd, &raindrop{
+		bad = append(bad, &raindrop{
 			// lose 20*frameidx(e.g. pineapple) points per hit
 			r: r, img: img, points: -(int64(i+1) * 20),
+			hb:    sprites.Hitbox("drop_bad", i+1),
+			clips: sprites.Clips("drop_bad"),
+			def:   drops.Lookup(fmt.Sprintf("drop_bad_%d", i+1)),
+		})
+	}
+	var pu []*raindrop
+	imgs, err = NewImageSetFromFiles(r, "assets/img/powerup_")
+	if err != nil {
+		return nil, err
+	}
+	for i, img := range imgs {
+		if i >= len(powerup.Kinds) {
+			break
+		}
+		pu = append(pu, &raindrop{
+			r: r, img: img, powerup: powerup.Kinds[i],
+			hb:    sprites.Hitbox("powerup", i+1),
+			clips: sprites.Clips("powerup"),
+			def:   drops.Lookup(fmt.Sprintf("powerup_%d", i+1)),
 		})
 	}
 	ra := &rain{
-		r:         r,
-		lastdrop:  time.Now(), // also initial delay
-		available: rd,
-		delay:     time.Second, // start at 1 new drop per second
+		r:        r,
+		bus:      bus,
+		lastdrop: time.Now(), // also initial delay
+		good:     good,
+		bad:      bad,
+		powerups: pu,
+		byName:   make(map[string]*raindrop),
+		scale:    1,
+	}
+	for _, set := range [][]*raindrop{good, bad, pu} {
+		for _, rd := range set {
+			ra.byName[rd.def.Name] = rd
+		}
 	}
 	return ra, nil
 }
 
-// SetRate implements the Rain interface.
-func (r *rain) SetRate(n int) {
-	const min = 100 * time.Millisecond
-	r.delay = time.Duration(1000-(n-1)*100) * time.Millisecond
-	if r.delay < min {
-		r.delay = min
+// SetParams implements the Rain interface.
+func (r *rain) SetParams(p difficulty.Params) {
+	r.params = p
+}
+
+// SetTimeScale implements the Rain interface.
+func (r *rain) SetTimeScale(f float64) {
+	r.scale = f
+}
+
+// Attract implements the Rain interface.
+func (r *rain) Attract(x, speed int32) {
+	for _, d := range r.drops {
+		if d.src.points <= 0 {
+			continue
+		}
+		dx := float64(x) - (d.st.X + d.st.W/2)
+		if dx > float64(speed) {
+			dx = float64(speed)
+		} else if dx < -float64(speed) {
+			dx = -float64(speed)
+		}
+		d.st.X += dx
+	}
+}
+
+// Names implements the Rain interface.
+func (r *rain) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	return names
+}
+
+// SetPool implements the Rain interface.
+func (r *rain) SetPool(names []string) {
+	if names == nil {
+		r.pool = nil
+		return
+	}
+	r.pool = make([]*raindrop, 0, len(names))
+	for _, name := range names {
+		if rd, ok := r.byName[name]; ok {
+			r.pool = append(r.pool, rd)
+		}
 	}
 }
 
+// Spawn implements the Rain interface.
+func (r *rain) Spawn(name string, x float64) {
+	if rd, ok := r.byName[name]; ok {
+		r.pending = append(r.pending, spawn{rd, x})
+	}
+}
+
+// Reset implements the Rain interface.
+func (r *rain) Reset() {
+	r.drops = nil
+	r.pending = nil
+	r.pool = nil
+	r.landed = r.landed[:0]
+	r.near = r.near[:0]
+}
+
+// SetPaused implements the Rain interface.
+func (r *rain) SetPaused(paused bool) {
+	r.paused = paused
+}
+
 // Draw implements the Rain interface.
 func (r *rain) Draw(now time.Time, viewport *sdl.Rect) {
-	if now.Sub(r.lastdrop) >= r.delay {
+	if r.paused {
+		r.last = now
+		r.landed = r.landed[:0]
+		r.near = r.near[:0]
+		for _, d := range r.drops {
+			if !d.consumed {
+				d.draw()
+			}
+		}
+		return
+	}
+	for _, sp := range r.pending {
+		r.add(sp.rd, sp.x, viewport)
+	}
+	r.pending = r.pending[:0]
+	delay := time.Duration(float64(r.params.Interval) / r.scale)
+	if now.Sub(r.lastdrop) >= delay {
 		r.newDrop(viewport)
 		r.lastdrop = now
 	}
-	r.drawAndDrain(viewport)
+	dt := time.Duration(float64(frameTime(r.last, now)) * r.scale)
+	r.last = now
+	r.drawAndDrain(viewport, dt)
 }
 
-// newDrop adds a new drop to the rain.
+// newDrop adds a new random drop to the rain.
 func (r *rain) newDrop(viewport *sdl.Rect) {
+	// roll the dice to pick a raindrop from the pool, or a
+	// preloaded good or bad raindrop, or once in a while
+	// a power-up
+	var rd *raindrop
+	switch {
+	case r.pool != nil && len(r.pool) == 0:
+		return
+	case r.pool != nil:
+		rd = r.pool[rand.Intn(len(r.pool))]
+	default:
+		set := r.good
+		if rand.Float64() < r.params.BadRatio {
+			set = r.bad
+		}
+		rd = set[rand.Intn(len(set))]
+		if len(r.powerups) > 0 && rand.Float64() < powerup.Chance {
+			rd = r.powerups[rand.Intn(len(r.powerups))]
+		}
+	}
+	// roll the dice to place the raindrop horizontally
+	r.add(rd, rand.Float64(), viewport)
+}
+
+// add adds the raindrop to the rain at x, from 0 to 1.
+func (r *rain) add(rd *raindrop, x float64, viewport *sdl.Rect) {
 	// border is the pct of the viewport that should not
 	// have rain drops
 	border := int32(float32(viewport.W) * .05)
-	// roll the dice to pick a preloaded raindrop
-	idx := rand.Intn(len(r.available))
-	rd := r.available[idx]
-	// roll the dice to place the raindrop horizontally
 	w, h := rd.img.Size()
 	lim := viewport.W - w - (border * 2)
 	pos := &sdl.Rect{
-		X: border + rand.Int31n(lim),
+		X: border + int32(x*float64(lim)),
 		Y: -h,
 		W: w,
 		H: h,
 	}
 	// roll the dice for the drop speed
 	d := &drop{
-		src:   rd,
-		pos:   pos,
-		speed: 5 + rand.Int31n(10),
+		src:  rd,
+		pos:  pos,
+		anim: sprite.NewAnimation(rd.clips),
+		st: motion.State{
+			X:     float64(pos.X),
+			Y:     float64(pos.Y),
+			W:     float64(w),
+			H:     float64(h),
+			VY:    float64(r.params.Speed(rand.Float64())),
+			Width: float64(viewport.W),
+			Floor: float64(viewport.H),
+		},
 	}
+	motion.Start(&d.st, rd.def.Motions())
+	d.anim.Play("fall")
 	r.drops = append(r.drops, d)
+	event.Publish(r.bus, DropSpawned{Drop: d})
 }
 
 // drawAndDrain draws drops that are within the viewport and drains
-// drops that placed past the height of the viewport.
-func (r *rain) drawAndDrain(viewport *sdl.Rect) {
+// drops that placed past the height of the viewport. Animations of
+// the drops are advanced by dt.
+func (r *rain) drawAndDrain(viewport *sdl.Rect, dt time.Duration) {
 	// Use a single-pass, in-place filter to draw and drain drops.
 	// This avoids allocating a map and a new slice on every frame.
 	// Important: clear the tail so drained drops aren't retained by the backing array.
 	orig := r.drops
 	kept := orig[:0]
+	r.landed = r.landed[:0]
+	r.near = r.near[:0]
+	var halves []*drop
 
 	for _, d := range orig {
 		if d.pos.Y > viewport.H || d.consumed {
 			continue // Drop is off-screen or consumed, drain it.
 		}
-		d.Draw(viewport)
+		d.Draw(viewport, r.scale, dt)
+		if !d.near && d.pos.Y+d.pos.H/2 > viewport.H/2 {
+			d.near = true
+			r.near = append(r.near, d)
+		}
+		if !d.landed && d.st.Landed() {
+			d.landed = true
+			r.landed = append(r.landed, d)
+		}
+		if h := d.split(); h != nil {
+			halves = append(halves, h)
+		}
 		kept = append(kept, d)
 	}
 
@@ -156,7 +402,7 @@ func (r *rain) drawAndDrain(viewport *sdl.Rect) {
 		orig[i] = nil
 	}
 
-	r.drops = kept
+	r.drops = append(kept, halves...)
 }
 
 // Drops implement the Rain interface.
@@ -168,18 +414,76 @@ func (r *rain) Drops() []Drop {
 	return drops
 }
 
-// drop is a single drop of rain, that falls from top to bottom.
+// Landed implements the Rain interface.
+func (r *rain) Landed() []Drop {
+	return r.landed
+}
+
+// Approaching implements the Rain interface.
+func (r *rain) Approaching() []Drop {
+	return r.near
+}
+
+// drop is a single drop of rain, that falls from top to bottom
+// according to the movement behaviors of its definition.
 type drop struct {
 	src      *raindrop
 	pos      *sdl.Rect
-	speed    int32
+	st       motion.State
 	consumed bool
+	landed   bool
+	near     bool             // past the middle of the viewport
+	halved   bool             // split in two already
+	anim     sprite.Animation // e.g. spinning or pulsing
+}
+
+// Draw draws the drop moving it one frame forward, scaled by the
+// rain's time scale, and advances its animation by dt.
+func (d *drop) Draw(viewport *sdl.Rect, scale float64, dt time.Duration) {
+	d.st.Width = float64(viewport.W)
+	d.st.Floor = float64(viewport.H)
+	motion.Step(&d.st, d.src.def.Motions(), scale)
+	d.pos.X = int32(math.Round(d.st.X))
+	d.pos.Y = int32(math.Round(d.st.Y))
+	d.anim.Update(dt)
+	d.draw()
+}
+
+// draw draws the drop where it is, in the pose of its animation.
+func (d *drop) draw() {
+	pose := d.anim.Pose()
+	w := int32(float64(d.pos.W) * pose.Scale)
+	h := int32(float64(d.pos.H) * pose.Scale)
+	d.src.r.CopyEx(d.src.img.Texture(), nil, &sdl.Rect{
+		X: d.pos.X - (w-d.pos.W)/2,
+		Y: d.pos.Y - (h-d.pos.H)/2 + int32(pose.DY*float64(d.pos.H)),
+		W: w,
+		H: h,
+	}, pose.Angle, nil, sdl.FLIP_NONE)
 }
 
-// Draw draws the drop incrementing its Y position at a given speed.
-func (d *drop) Draw(viewport *sdl.Rect) {
-	d.pos.Y = d.pos.Y + d.speed
-	d.src.r.Copy(d.src.img.Texture(), nil, d.pos)
+// split returns the right half of the drop when its motion splits
+// it in two, and turns the drop into the left half.
+func (d *drop) split() *drop {
+	if !d.st.Split || d.halved {
+		return nil
+	}
+	d.halved = true
+	for _, m := range d.src.def.Motions() {
+		sp, ok := m.(motion.Split)
+		if !ok {
+			continue
+		}
+		left, right := sp.Halves(d.st)
+		d.st = left
+		d.pos.W, d.pos.H = int32(left.W), int32(left.H)
+		h := *d
+		h.pos = &sdl.Rect{}
+		*h.pos = *d.pos
+		h.st = right
+		return &h
+	}
+	return nil
 }
 
 // Pos implements the Drop interface.
@@ -187,11 +491,36 @@ func (d *drop) Pos() sdl.Rect {
 	return *d.pos
 }
 
-// Points implements the Drop interface.
+// HitArea implements the Drop interface.
+func (d *drop) HitArea() sdl.Rect {
+	x, y, w, h := d.src.hb.Rect(int(d.pos.X), int(d.pos.Y), int(d.pos.W), int(d.pos.H), false)
+	return sdl.Rect{X: int32(x), Y: int32(y), W: int32(w), H: int32(h)}
+}
+
+// Image implements the Drop interface.
+func (d *drop) Image() Image {
+	return d.src.img
+}
+
+// Name implements the Drop interface.
+func (d *drop) Name() string {
+	return d.src.def.Name
+}
+
+// Points implements the Drop interface. Halves of a split drop
+// score half the points of the drop each.
 func (d *drop) Points() int64 {
+	if d.halved {
+		return d.src.points / 2
+	}
 	return d.src.points
 }
 
+// PowerUp implements the Drop interface.
+func (d *drop) PowerUp() powerup.Kind {
+	return d.src.powerup
+}
+
 // Consume implements the Drop interface.
 func (d *drop) Consume() {
 	d.consumed = true
@@ -9,16 +9,76 @@ import (
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
-	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/fx"
+	"github.com/fiorix/cat-o-licious/level"
+	"github.com/fiorix/cat-o-licious/music"
+	"github.com/fiorix/cat-o-licious/powerup"
+	"github.com/fiorix/cat-o-licious/sprite"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/theme"
+	"github.com/fiorix/cat-o-licious/tween"
+)
+
+// Camera shake on bad catches.
+const (
+	cameraShake     = 12 // pixels
+	cameraShakeTime = 300 * time.Millisecond
 )
 
 // Scene is the game scene.
-// It contains the background image, scoreboard, player, rain, and sound.
+// It contains the background, scoreboard, player, rain, and sound.
 type Scene interface {
 	// Player returns the game player so callers can control
 	// its lateral movement.
 	Player() Player
 
+	// Music returns the game music so callers can pause it and
+	// control its volume.
+	Music() Music
+
+	// Points returns the player's points.
+	Points() int64
+
+	// Level returns the level the player is at, from 1.
+	Level() int
+
+	// Events returns the event bus of the game.
+	Events() *event.Bus
+
+	// Names returns the names of the drops, from the drop catalog.
+	Names() []string
+
+	// Stats returns the statistics of the game.
+	Stats() *stats.Session
+
+	// SetDifficulty sets the difficulty of the next game.
+	SetDifficulty(d difficulty.Preset)
+
+	// SetAdaptive sets the adaptive difficulty, nil to disable it.
+	SetAdaptive(a Adaptive)
+
+	// SetCues sets the audio cues, nil to disable them.
+	SetCues(c Cues)
+
+	// SetReduceMotion turns the camera shake off, or back on.
+	SetReduceMotion(on bool)
+
+	// Reset starts a new game.
+	Reset()
+
+	// SetPaused freezes the game, or unfreezes it, and pauses or
+	// resumes its music. A paused scene is drawn as it is: the
+	// drops don't move, the player catches none, and no events
+	// are published.
+	SetPaused(paused bool)
+
+	// Paused returns true if the game is paused.
+	Paused() bool
+
 	// Draw draws the scene.
 	Draw(now time.Time, viewport *sdl.Rect)
 }
@@ -26,17 +86,46 @@ type Scene interface {
 type scene struct {
 	r          *sdl.Renderer
 	lastupdate time.Time
+	last       time.Time // time of the last frame drawn
+	shake      tween.Shake
+	start      time.Time
+	difficulty difficulty.Preset
+	adaptive   Adaptive // nil unless enabled
+	cues       Cues     // nil unless enabled
+	calm       bool     // no camera shake
+	paused     bool     // frozen, see SetPaused
 
-	bg     Image
+	bg     Background
 	score  Scoreboard
 	rain   Rain
 	player Player
-	mus    *sdlmix.Music
+	fx     Particles
+	popups Popups
+	pu     PowerUps
+	levels Levels
+	music  Music
+	stats  *stats.Session
+	bus    *event.Bus
 }
 
-// NewScene creates and initializes the game scene.
-func NewScene(r *sdl.Renderer) (Scene, error) {
-	bg, err := NewImageFromFile(r, "assets/img/background.png")
+// NewScene creates and initializes the game scene with the given
+// difficulty. The adaptive difficulty is optional, and adjusts the
+// difficulty to the player's performance. The cues are optional, and
+// play sounds for the drops approaching the player.
+func NewScene(r *sdl.Renderer, d difficulty.Preset, a Adaptive, cues Cues) (Scene, error) {
+	th, err := LoadTheme(theme.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	bg, err := NewBackground(r, th)
+	if err != nil {
+		return nil, err
+	}
+	sprites, err := LoadSprites(sprite.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	drops, err := LoadDrops(catalog.DefaultFile)
 	if err != nil {
 		return nil, err
 	}
@@ -44,62 +133,349 @@ func NewScene(r *sdl.Renderer) (Scene, error) {
 	if err != nil {
 		return nil, err
 	}
-	rain, err := NewRain(r)
+	bus := event.NewBus()
+	rain, err := NewRain(r, sprites, drops, bus)
 	if err != nil {
 		return nil, err
 	}
-	player, err := NewPlayer(r)
+	ls, err := LoadLevels(level.DefaultFile)
 	if err != nil {
 		return nil, err
 	}
-	mus, err := sdlmix.LoadMUS("assets/snd/music_1.wav")
+	if err = ls.Check(rain.Names()); err != nil {
+		return nil, err
+	}
+	levels, err := NewLevels(r, ls)
+	if err != nil {
+		return nil, err
+	}
+	player, err := NewPlayer(r, sprites)
+	if err != nil {
+		return nil, err
+	}
+	popups, err := NewPopups(r)
+	if err != nil {
+		return nil, err
+	}
+	pu, err := NewPowerUps(r)
+	if err != nil {
+		return nil, err
+	}
+	pl, err := LoadPlaylist(music.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	mus, err := NewMusic(pl)
 	if err != nil {
 		return nil, err
 	}
 	s := &scene{
-		r:      r,
-		bg:     bg,
-		score:  score,
-		rain:   rain,
-		player: player,
-		mus:    mus,
+		r:          r,
+		difficulty: d,
+		adaptive:   a,
+		cues:       cues,
+		bg:         bg,
+		score:      score,
+		rain:       rain,
+		player:     player,
+		fx:         NewParticles(r),
+		popups:     popups,
+		pu:         pu,
+		levels:     levels,
+		music:      mus,
+		stats:      stats.NewSession(),
+		bus:        bus,
 	}
+	s.subscribe()
+	event.Publish(bus, RateChanged{d.At(difficulty.Progress{})})
 	return s, nil
 }
 
 // Draw implements the Scene interface.
 func (s *scene) Draw(now time.Time, viewport *sdl.Rect) {
-	if !sdlmix.PlayingMusic() {
-		s.mus.Play(0)
+	// shake the camera by moving the viewport
+	dt := frameTime(s.last, now)
+	s.last = now
+	if s.paused {
+		dt = 0
+	}
+	s.stats.Play(dt)
+	dx, dy := s.shake.Update(dt)
+	if dx != 0 || dy != 0 {
+		s.r.SetDrawColor(0, 0, 0, 255)
+		s.r.Clear()
+		s.r.SetViewport(&sdl.Rect{
+			X: viewport.X + int32(dx),
+			Y: viewport.Y + int32(dy),
+			W: viewport.W,
+			H: viewport.H,
+		})
+		defer s.r.SetViewport(viewport)
 	}
-	s.r.Copy(s.bg.Texture(), nil, nil)
-	s.score.Draw(viewport)
-	s.player.Draw(viewport)
+	s.music.Update(now, s.score.Points(), s.levels.Current())
+	area := s.player.HitArea()
+	s.bg.Draw(now, viewport, area.X+area.W/2)
+	s.score.Draw(now, viewport)
+	s.pu.Draw(now, viewport)
+	s.player.Draw(now, viewport)
+	if s.paused {
+		s.rain.Draw(now, viewport)
+	} else {
+		s.play(now, viewport)
+	}
+	s.fx.Draw(now, viewport)
+	s.popups.Draw(now, viewport)
+	s.levels.Draw(now, viewport)
+	if s.adaptive != nil {
+		s.adaptive.Draw(now, viewport)
+	}
+	if s.paused {
+		return
+	}
+	// update rain parameters at most 1/s.
+	if s.start.IsZero() {
+		s.start = now
+	}
+	if now.Sub(s.lastupdate) < time.Second {
+		return
+	}
+	s.lastupdate = now
+	p := s.difficulty.At(difficulty.Progress{
+		Points:  s.score.Points(),
+		Elapsed: now.Sub(s.start),
+	})
+	if s.adaptive != nil {
+		p = s.adaptive.Apply(now, p)
+	}
+	event.Publish(s.bus, RateChanged{p})
+}
+
+// play plays a frame of the game: the rain falls, and the player
+// catches the drops or lets them hit the floor.
+func (s *scene) play(now time.Time, viewport *sdl.Rect) {
+	s.rain.SetTimeScale(s.pu.TimeScale(now))
+	if s.pu.Active(powerup.Magnet, now) {
+		area := s.player.HitArea()
+		s.rain.Attract(area.X+area.W/2, powerup.MagnetSpeed)
+	}
+	s.levels.Update(now, s.rain)
 	s.rain.Draw(now, viewport)
-	hit := false
+	if s.cues != nil {
+		s.cues.Play(s.rain.Approaching(), viewport)
+	}
 	for _, drop := range s.rain.Drops() {
 		if drop.Consumed() {
 			continue
 		}
 		if s.player.Hit(drop) {
 			drop.Consume()
-			s.score.Add(drop.Points())
-			hit = true
+			event.Publish(s.bus, DropCaught{Drop: drop, Now: now})
 		}
 	}
-	// update rain delay at most 1/s, only on player hits.
-	if !hit || now.Sub(s.lastupdate) < time.Second {
+	for _, drop := range s.rain.Landed() {
+		event.Publish(s.bus, DropMissed{Drop: drop, Now: now, Floor: viewport.H})
+	}
+}
+
+// subscribe subscribes the subsystems of the scene to its events: the
+// rules of the game, the player, the effects, the adaptive difficulty
+// and the stats.
+func (s *scene) subscribe() {
+	event.Subscribe(s.bus, s.catch)
+	event.Subscribe(s.bus, s.miss)
+	event.Subscribe(s.bus, func(e RateChanged) { s.rain.SetParams(e.Params) })
+	event.Subscribe(s.bus, func(e ScoreChanged) { s.levels.Score(e.Delta) })
+	event.Subscribe(s.bus, func(e DropCaught) { s.player.Catch(e.Drop) })
+	event.Subscribe(s.bus, s.caughtFX)
+	event.Subscribe(s.bus, s.scoredFX)
+	event.Subscribe(s.bus, s.missedFX)
+	event.Subscribe(s.bus, s.recordScored)
+	event.Subscribe(s.bus, s.recordMissed)
+	event.Subscribe(s.bus, func(e DropCaught) {
+		if e.Drop.PowerUp() != powerup.None {
+			s.stats.CatchPowerUp(e.Drop.Name())
+			return
+		}
+		s.stats.Catch(e.Drop.Name(), e.Drop.Points() < 0)
+	})
+	event.Subscribe(s.bus, func(e ScoreChanged) { s.stats.SetCombo(e.BestCombo) })
+	event.Subscribe(s.bus, func(e RateChanged) { s.stats.SetInterval(e.Params.Interval) })
+	event.Subscribe(s.bus, func(e DropMissed) {
+		if e.Drop.Points() > 0 {
+			s.stats.Miss(e.Drop.Name())
+		}
+	})
+}
+
+// catch activates the power-up of the drop caught by the player, or
+// scores it.
+func (s *scene) catch(e DropCaught) {
+	if k := e.Drop.PowerUp(); k != powerup.None {
+		s.pu.Activate(k, e.Now)
 		return
 	}
-	s.lastupdate = now
-	// TODO: do this less frequent, not on all hits
-	// TODO: switch music every 1000 points?
-	p := s.score.Points()
-	rate := (int(p) / 1000) + 1
-	s.rain.SetRate(rate)
+	area := e.Drop.HitArea()
+	sc := ScoreChanged{Now: e.Now, X: area.X + area.W/2, Y: area.Y + area.H/2}
+	points := e.Drop.Points()
+	switch {
+	case points < 0 && s.pu.Active(powerup.Shield, e.Now):
+		s.pu.Deactivate(powerup.Shield)
+		sc.Blocked = true
+	case points > 0 && s.pu.Active(powerup.DoublePoints, e.Now):
+		points *= 2
+	}
+	if !sc.Blocked {
+		sc.Delta = s.score.Add(points)
+	}
+	sc.Points = s.score.Points()
+	sc.BestCombo = s.score.BestCombo()
+	event.Publish(s.bus, sc)
+}
+
+// miss breaks the combo when good drops hit the floor.
+func (s *scene) miss(e DropMissed) {
+	if e.Drop.Points() > 0 {
+		s.score.Miss()
+	}
+}
+
+// caughtFX emits sparkles where power-ups are caught.
+func (s *scene) caughtFX(e DropCaught) {
+	if e.Drop.PowerUp() != powerup.None {
+		area := e.Drop.HitArea()
+		s.fx.Emit(fx.Sparkles, area.X+area.W/2, area.Y+area.H/2)
+	}
+}
+
+// scoredFX emits the effects of the drops scored, and pops up their
+// points.
+func (s *scene) scoredFX(e ScoreChanged) {
+	switch {
+	case e.Blocked:
+		s.fx.Emit(fx.Shielded, e.X, e.Y)
+		return
+	case e.Delta > 0:
+		s.fx.Emit(fx.Sparkles, e.X, e.Y)
+	default:
+		s.fx.Emit(fx.Splat, e.X, e.Y)
+		if !s.calm {
+			s.shake.Start(cameraShake, cameraShakeTime)
+		}
+	}
+	s.popups.Spawn(e.Delta, e.X, e.Y)
+}
+
+// missedFX crumbles the drops that hit the floor.
+func (s *scene) missedFX(e DropMissed) {
+	pos := e.Drop.Pos()
+	s.fx.Emit(fx.Crumbs.WithSprite(e.Drop.Image()), pos.X+pos.W/2, e.Floor)
+}
+
+// recordScored records the outcome of the drops scored for the
+// adaptive difficulty, if enabled.
+func (s *scene) recordScored(e ScoreChanged) {
+	switch {
+	case s.adaptive == nil, e.Blocked:
+	case e.Delta > 0:
+		s.adaptive.Record(e.Now, difficulty.Caught)
+	default:
+		s.adaptive.Record(e.Now, difficulty.BadHit)
+	}
+}
+
+// recordMissed records the good drops missed for the adaptive
+// difficulty, if enabled.
+func (s *scene) recordMissed(e DropMissed) {
+	if s.adaptive != nil && e.Drop.Points() > 0 {
+		s.adaptive.Record(e.Now, difficulty.Missed)
+	}
 }
 
 // Player implements the Scene interface.
 func (s *scene) Player() Player {
 	return s.player
 }
+
+// Points implements the Scene interface.
+func (s *scene) Points() int64 {
+	return s.score.Points()
+}
+
+// Level implements the Scene interface.
+func (s *scene) Level() int {
+	return s.levels.Current() + 1
+}
+
+// Events implements the Scene interface.
+func (s *scene) Events() *event.Bus {
+	return s.bus
+}
+
+// Names implements the Scene interface.
+func (s *scene) Names() []string {
+	return s.rain.Names()
+}
+
+// Stats implements the Scene interface.
+func (s *scene) Stats() *stats.Session {
+	s.stats.Points = s.score.Points()
+	return s.stats
+}
+
+// SetDifficulty implements the Scene interface.
+func (s *scene) SetDifficulty(d difficulty.Preset) {
+	s.difficulty = d
+}
+
+// SetAdaptive implements the Scene interface.
+func (s *scene) SetAdaptive(a Adaptive) {
+	s.adaptive = a
+}
+
+// SetCues implements the Scene interface.
+func (s *scene) SetCues(c Cues) {
+	s.cues = c
+}
+
+// SetReduceMotion implements the Scene interface.
+func (s *scene) SetReduceMotion(on bool) {
+	s.calm = on
+	if on {
+		s.shake = tween.Shake{}
+	}
+}
+
+// Reset implements the Scene interface.
+func (s *scene) Reset() {
+	s.score.Reset()
+	s.rain.Reset()
+	s.pu.Reset()
+	s.levels.Reset()
+	s.music.Reset()
+	if s.adaptive != nil {
+		s.adaptive.Reset()
+	}
+	s.shake = tween.Shake{}
+	s.start = time.Time{}
+	s.lastupdate = time.Time{}
+	s.last = time.Time{}
+	s.stats = stats.NewSession()
+	event.Publish(s.bus, RateChanged{s.difficulty.At(difficulty.Progress{})})
+}
+
+// SetPaused implements the Scene interface.
+func (s *scene) SetPaused(paused bool) {
+	s.paused = paused
+	s.rain.SetPaused(paused)
+	s.music.SetPaused(paused)
+}
+
+// Paused implements the Scene interface.
+func (s *scene) Paused() bool {
+	return s.paused
+}
+
+// Music implements the Scene interface.
+func (s *scene) Music() Music {
+	return s.music
+}
@@ -0,0 +1,107 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	sdlimg "github.com/veandco/go-sdl2/img"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	sdlttf "github.com/veandco/go-sdl2/ttf"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+)
+
+// renderer is the renderer of the tests, off screen; nil if SDL is not
+// available.
+var renderer *sdl.Renderer
+
+func TestMain(m *testing.M) {
+	// the assets are relative to the game
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Setenv("SDL_VIDEODRIVER", "dummy")
+	os.Setenv("SDL_AUDIODRIVER", "dummy")
+	sdl.SetHint(sdl.HINT_RENDER_DRIVER, "software")
+	if sdl.Init(sdl.INIT_VIDEO|sdl.INIT_AUDIO) == nil &&
+		sdlttf.Init() == nil &&
+		sdlmix.OpenAudio(44100, uint16(sdlmix.DEFAULT_FORMAT), 2, 1024) == nil {
+		sdlmix.AllocateChannels(mixChannels)
+		sdlimg.Init(sdlimg.INIT_PNG)
+		_, renderer, _ = sdl.CreateWindowAndRenderer(800, 600, sdl.WINDOW_HIDDEN)
+	}
+	os.Exit(m.Run())
+}
+
+func TestScenePaused(t *testing.T) {
+	if renderer == nil {
+		t.Skip("SDL is not available")
+	}
+	d, err := difficulty.Lookup(difficulty.Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewScene(renderer, d, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewport := &sdl.Rect{W: 800, H: 600}
+	now := time.Now()
+	frame := func() {
+		now = now.Add(100 * time.Millisecond)
+		s.Draw(now, viewport)
+	}
+	s.Reset()
+	for i := 0; i < 100 && len(s.(*scene).rain.Drops()) == 0; i++ {
+		frame()
+	}
+	drops := s.(*scene).rain.Drops()
+	if len(drops) == 0 {
+		t.Fatal("no drops")
+	}
+	pos := make([]sdl.Rect, len(drops))
+	for i, drop := range drops {
+		pos[i] = drop.Pos()
+	}
+
+	var events []string
+	bus := s.Events()
+	event.Subscribe(bus, func(DropSpawned) { events = append(events, "spawned") })
+	event.Subscribe(bus, func(DropCaught) { events = append(events, "caught") })
+	event.Subscribe(bus, func(DropMissed) { events = append(events, "missed") })
+	event.Subscribe(bus, func(ScoreChanged) { events = append(events, "scored") })
+	event.Subscribe(bus, func(RateChanged) { events = append(events, "rate") })
+	played := s.Stats().PlayTime
+	s.SetPaused(true)
+	for range 100 {
+		frame()
+	}
+	if n := len(s.(*scene).rain.Drops()); n != len(drops) {
+		t.Fatalf("got %d drops while paused, want %d", n, len(drops))
+	}
+	for i, drop := range drops {
+		if drop.Pos() != pos[i] {
+			t.Fatalf("drop %q moved while paused from %v to %v", drop.Name(), pos[i], drop.Pos())
+		}
+	}
+	if len(events) > 0 {
+		t.Fatalf("got events %v while paused, want none", events)
+	}
+	if p := s.Stats().PlayTime; p != played {
+		t.Fatalf("got play time %v while paused, want %v", p, played)
+	}
+
+	s.SetPaused(false)
+	frame()
+	if drops[0].Pos() == pos[0] {
+		t.Fatalf("drop %q did not move after resuming", drops[0].Name())
+	}
+}
@@ -8,42 +8,129 @@ package game
 import (
 	"fmt"
 	"log"
+	"math"
 	"sync/atomic"
+	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
-	sdlttf "github.com/veandco/go-sdl2/ttf"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/score"
+	"github.com/fiorix/cat-o-licious/tween"
 )
 
-// Scoreboard tracks the player's score and draws the scoreboard.
+// comboBreakTime is the duration of the combo break animation.
+const comboBreakTime = time.Second
+
+// scoreCountTime is the duration of the score counting up, or down,
+// to the current points.
+const scoreCountTime = 500 * time.Millisecond
+
+// Scoreboard tracks the player's score and combo, and draws the
+// scoreboard.
 type Scoreboard interface {
-	// Add adds the given delta to the player's score.
-	Add(delta int64)
+	// Add adds the given delta to the player's score. Positive
+	// deltas are good catches that extend the combo and are
+	// multiplied by the combo multiplier, negative deltas break
+	// the combo. Returns the delta added to the score.
+	Add(delta int64) int64
+
+	// Miss records a missed good drop, which breaks the combo.
+	Miss()
 
 	// Points returns the current player's points.
 	Points() int64
 
+	// BestCombo returns the longest combo of the game.
+	BestCombo() int
+
+	// Reset resets the score and combo for a new game.
+	Reset()
+
 	// Draw draws the scoreboard.
-	Draw(viewport *sdl.Rect)
+	Draw(now time.Time, viewport *sdl.Rect)
 }
 
 type scoreboard struct {
 	r      *sdl.Renderer
-	f      *sdlttf.Font
+	f      *font
+	fc     *font         // combo font
+	sfx    *sdlmix.Chunk // combo break sfx
+	sfxc   *sdlmix.Chunk // combo multiplier sfx
 	points int64
+	combo  score.Combo
+	lost   int          // length of the last broken combo
+	lostT  time.Time    // time of the last broken combo, set on Draw
+	count  *tween.Tween // points shown, counting up to points
+	last   time.Time    // time of the last frame drawn
 }
 
 // NewScoreboard creates and initializes a new scoreboard.
 func NewScoreboard(r *sdl.Renderer) (Scoreboard, error) {
-	f, err := sdlttf.OpenFont("assets/fonts/score.ttf", 50)
+	f, err := openFont(50)
 	if err != nil {
 		return nil, err
 	}
-	return &scoreboard{r: r, f: f}, nil
+	fc, err := openFont(28)
+	if err != nil {
+		return nil, err
+	}
+	sfx, err := loadSound("assets/snd/combo_break_1.wav", "combo_break")
+	if err != nil {
+		return nil, err
+	}
+	sfxc, err := loadSound("assets/snd/combo_1.wav", "combo")
+	if err != nil {
+		return nil, err
+	}
+	return &scoreboard{r: r, f: f, fc: fc, sfx: sfx, sfxc: sfxc}, nil
 }
 
 // Add implements the Scoreboard interface.
-func (sb *scoreboard) Add(delta int64) {
-	atomic.AddInt64(&sb.points, delta)
+func (sb *scoreboard) Add(delta int64) int64 {
+	if delta > 0 {
+		m := sb.combo.Multiplier()
+		sb.combo.Catch()
+		if sb.combo.Multiplier() > m {
+			playAt(sb.sfxc, 0)
+		}
+		delta *= sb.combo.Multiplier()
+	} else {
+		sb.breakCombo()
+	}
+	p := atomic.AddInt64(&sb.points, delta)
+	from := 0.0
+	if sb.count != nil {
+		from = sb.count.Value()
+	}
+	sb.count = tween.New(from, float64(p), scoreCountTime, tween.OutCubic)
+	return delta
+}
+
+// Miss implements the Scoreboard interface.
+func (sb *scoreboard) Miss() {
+	sb.breakCombo()
+}
+
+// breakCombo resets the combo, and starts the break animation and
+// sound if the combo was long enough.
+func (sb *scoreboard) breakCombo() {
+	lost := sb.combo.Break()
+	if lost == 0 {
+		return
+	}
+	sb.lost = lost
+	sb.lostT = time.Time{}
+	playAt(sb.sfx, 0)
+}
+
+// Reset implements the Scoreboard interface.
+func (sb *scoreboard) Reset() {
+	atomic.StoreInt64(&sb.points, 0)
+	sb.combo = score.Combo{}
+	sb.lost = 0
+	sb.count = nil
 }
 
 // Points implements the Scoreboard interface.
@@ -51,9 +138,19 @@ func (sb *scoreboard) Points() int64 {
 	return atomic.LoadInt64(&sb.points)
 }
 
+// BestCombo implements the Scoreboard interface.
+func (sb *scoreboard) BestCombo() int {
+	return sb.combo.Best()
+}
+
 // Draw implements the Scoreboard interface.
-func (sb *scoreboard) Draw(viewport *sdl.Rect) {
+func (sb *scoreboard) Draw(now time.Time, viewport *sdl.Rect) {
 	p := atomic.LoadInt64(&sb.points)
+	if sb.count != nil {
+		sb.count.Update(frameTime(sb.last, now))
+		p = int64(math.Round(sb.count.Value()))
+	}
+	sb.last = now
 	text := fmt.Sprintf("%d", p)
 	s, err := sb.f.RenderUTF8Solid(text, sdl.Color{R: 255})
 	if err != nil {
@@ -68,6 +165,7 @@ func (sb *scoreboard) Draw(viewport *sdl.Rect) {
 		log.Println("failed to create font texture:", err)
 		return
 	}
+	defer t.Destroy()
 	woff := float32(viewport.W) * .1
 	hoff := float32(viewport.H) * .1
 	r := &sdl.Rect{
@@ -77,4 +175,51 @@ func (sb *scoreboard) Draw(viewport *sdl.Rect) {
 		H: clip.H,
 	}
 	sb.r.Copy(t, nil, r)
+	// draw the combo right below the score
+	sb.drawCombo(now, r.X+r.W, r.Y+r.H)
+}
+
+// drawCombo draws the combo or the combo break animation, aligned
+// to the right of x.
+func (sb *scoreboard) drawCombo(now time.Time, x, y int32) {
+	if n := sb.combo.Count(); n >= score.MinCombo {
+		text := locale.Sprintf("combo %d x%d", n, sb.combo.Multiplier())
+		sb.drawText(text, sdl.Color{R: 255, G: 215}, 255, x, y)
+		return
+	}
+	if sb.lost == 0 {
+		return
+	}
+	if sb.lostT.IsZero() {
+		sb.lostT = now
+	}
+	since := now.Sub(sb.lostT)
+	if since >= comboBreakTime {
+		sb.lost = 0
+		return
+	}
+	// shake and fade out
+	prog := float64(since) / float64(comboBreakTime)
+	shake := int32(math.Sin(prog*40) * 10 * (1 - prog))
+	text := locale.Sprintf("combo %d lost", sb.lost)
+	alpha := uint8(255 * (1 - prog))
+	sb.drawText(text, sdl.Color{R: 255, G: 60, B: 60}, alpha, x+shake, y)
+}
+
+// drawText draws text with the combo font, aligned to the right of x.
+func (sb *scoreboard) drawText(text string, c sdl.Color, alpha uint8, x, y int32) {
+	s, err := sb.fc.RenderUTF8Blended(text, c)
+	if err != nil {
+		log.Println("failed to create font surface:", err)
+		return
+	}
+	defer s.Free()
+	t, err := sb.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create font texture:", err)
+		return
+	}
+	defer t.Destroy()
+	t.SetAlphaMod(alpha)
+	sb.r.Copy(t, nil, &sdl.Rect{X: x - s.W, Y: y, W: s.W, H: s.H})
 }
@@ -0,0 +1,553 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/screen"
+	"github.com/fiorix/cat-o-licious/store"
+	"github.com/fiorix/cat-o-licious/ui"
+)
+
+// Screen is a screen of the game engine, in its stack of screens: the
+// profile picker, the title, the game and its pause overlay, the
+// settings and the keys of the profile, the name entry of a new high
+// score, the high scores, the stats and the achievements. See the
+// screen package.
+type Screen interface {
+	screen.Screen
+
+	// Key handles a key pressed while the screen is on top, and
+	// returns false to leave it to the engine, e.g. M to mute.
+	Key(k sdl.Keycode) bool
+
+	// Type handles text typed while the screen is on top.
+	Type(text string)
+
+	// Mouse handles the mouse moved to x, y while the screen is on
+	// top, and clicked there if click.
+	Mouse(x, y int32, click bool)
+
+	// Draw draws the screen.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+// baseScreen is the base of the screens of the engine, which ignore
+// text typed and the mouse.
+type baseScreen struct {
+	screen.Base
+	e *engine
+}
+
+// Type implements the Screen interface.
+func (baseScreen) Type(text string) {}
+
+// Mouse implements the Screen interface.
+func (baseScreen) Mouse(x, y int32, click bool) {}
+
+// uiScreen is the base of the screens drawn with the ui package, which
+// navigate their widgets with the keyboard and the mouse.
+type uiScreen struct {
+	baseScreen
+	u ui.UI
+}
+
+// nav navigates the widgets with the key, and returns true if it's a
+// key of navigation.
+func (us *uiScreen) nav(k sdl.Keycode) bool {
+	n := uiNav(k)
+	if n == ui.NavNone {
+		return false
+	}
+	us.u.Nav(n)
+	return true
+}
+
+// Mouse implements the Screen interface.
+func (us *uiScreen) Mouse(x, y int32, click bool) {
+	us.u.Mouse(int(x), int(y), click)
+}
+
+// back returns true for the keys that go back from a screen.
+func back(k sdl.Keycode) bool {
+	switch k {
+	case sdl.K_RETURN, sdl.K_KP_ENTER, sdl.K_SPACE, sdl.K_ESCAPE:
+		return true
+	}
+	return false
+}
+
+// profileScreen is the profile picker, on top of the title until a
+// profile is picked.
+type profileScreen struct {
+	uiScreen
+	startup bool // shown at startup, rather than from the title
+	skip    bool // skip the text of the key that started naming
+}
+
+// Enter implements the Screen interface.
+func (ps *profileScreen) Enter() {
+	sdl.StartTextInput()
+}
+
+// Exit implements the Screen interface.
+func (ps *profileScreen) Exit() {
+	sdl.StopTextInput()
+}
+
+// Key implements the Screen interface. Q and Escape go back to the
+// title while browsing the profiles, or quit at startup.
+func (ps *profileScreen) Key(k sdl.Keycode) bool {
+	e := ps.e
+	if e.picker.Mode == profile.Browse && (k == sdl.K_q || k == sdl.K_ESCAPE) {
+		if ps.startup {
+			e.running = false
+		} else {
+			e.screens.Pop()
+		}
+		return true
+	}
+	// SDL sends the text of keys after them, e.g. "n" after N
+	naming := e.picker.Mode == profile.Naming
+	picked := e.picker.Key(sdl.GetKeyName(k))
+	ps.skip = !naming && e.picker.Mode == profile.Naming
+	if e.picker.Changed {
+		if err := e.picker.Profiles.Save(e.st); err != nil {
+			log.Printf("failed to save profiles: %v", err)
+		}
+		e.picker.Changed = false
+	}
+	if picked {
+		e.pick(e.picker.Current())
+		e.screens.Pop()
+	}
+	return true
+}
+
+// Type implements the Screen interface.
+func (ps *profileScreen) Type(text string) {
+	if ps.skip {
+		ps.skip = false
+		return
+	}
+	ps.e.picker.Type(text)
+}
+
+// Draw implements the Screen interface.
+func (ps *profileScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	if ps.e.m.DrawProfiles(viewport, &ps.u, ps.e.picker) {
+		ps.Key(sdl.K_RETURN)
+	}
+}
+
+// titleScreen is the title, at the bottom of the stack.
+type titleScreen struct {
+	uiScreen
+}
+
+// Key implements the Screen interface. Besides the menu, H, S, G and P
+// go to the high scores, the stats, the achievements and the profile
+// picker.
+func (ts *titleScreen) Key(k sdl.Keycode) bool {
+	switch k {
+	case sdl.K_ESCAPE:
+		ts.do(QuitAction)
+	case sdl.K_h:
+		ts.do(HighScoresAction)
+	case sdl.K_p:
+		ts.do(ProfileAction)
+	case sdl.K_s:
+		ts.do(StatsAction)
+	case sdl.K_g:
+		ts.do(AchievementsAction)
+	default:
+		return ts.nav(k)
+	}
+	return true
+}
+
+// do does the action of the menu.
+func (ts *titleScreen) do(a Action) {
+	e, base := ts.e, ts.baseScreen
+	switch a {
+	case PlayAction:
+		e.screens.Push(&playScreen{baseScreen: base})
+	case HighScoresAction:
+		e.screens.Push(&highScoresScreen{uiScreen: uiScreen{baseScreen: base}})
+	case StatsAction:
+		e.screens.Push(&statsScreen{baseScreen: base})
+	case AchievementsAction:
+		e.screens.Push(&achievementsScreen{baseScreen: base})
+	case SettingsAction:
+		e.screens.Push(&settingsScreen{uiScreen: uiScreen{baseScreen: base}})
+	case ProfileAction:
+		e.screens.Push(&profileScreen{uiScreen: uiScreen{baseScreen: base}})
+	case QuitAction:
+		e.running = false
+	}
+}
+
+// Draw implements the Screen interface.
+func (ts *titleScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	ts.do(ts.e.m.DrawTitle(viewport, &ts.u))
+}
+
+// buttonScreen is a screen that handles the buttons of game
+// controllers itself, rather than as keys.
+type buttonScreen interface {
+	// Button handles a button pressed while the screen is on top,
+	// and returns false to leave it to the engine as a key.
+	Button(b sdl.GameControllerButton) bool
+}
+
+// playScreen is the game, played on its own clock, which stops while
+// the game is paused.
+type playScreen struct {
+	baseScreen
+	paused time.Time     // when the game was paused, zero if playing
+	offset time.Duration // time paused so far
+}
+
+// Enter implements the Screen interface. The game starts with the
+// gameplay settings.
+func (ps *playScreen) Enter() {
+	ps.e.pick(ps.e.prof)
+	ps.e.s.Reset()
+	event.Publish(ps.e.s.Events(), StateChanged{GameStarted})
+}
+
+// Pause implements the Screen interface. The game freezes under the
+// screens on top, and its music pauses.
+func (ps *playScreen) Pause() {
+	ps.paused = time.Now()
+	ps.e.s.SetPaused(true)
+}
+
+// Resume implements the Screen interface.
+func (ps *playScreen) Resume() {
+	ps.offset += time.Since(ps.paused)
+	ps.paused = time.Time{}
+	ps.e.s.SetPaused(false)
+}
+
+// now returns the time of the game's clock at the given time.
+func (ps *playScreen) now(t time.Time) time.Time {
+	if !ps.paused.IsZero() {
+		t = ps.paused
+	}
+	return t.Add(-ps.offset)
+}
+
+// Key implements the Screen interface. Escape ends the game, P pauses
+// it, and the key bindings of the profile move the player.
+func (ps *playScreen) Key(k sdl.Keycode) bool {
+	e := ps.e
+	switch k {
+	case sdl.K_ESCAPE:
+		e.gameOver()
+		return true
+	case sdl.K_p:
+		e.screens.Push(&pauseScreen{uiScreen: uiScreen{baseScreen: ps.baseScreen}})
+		return true
+	}
+	speed := int32(e.set.Gameplay.PlayerSpeed)
+	switch e.prof.Bindings.Direction(sdl.GetKeyName(k)) {
+	case -1:
+		e.s.Player().Move(Left, speed)
+	case 1:
+		e.s.Player().Move(Right, speed)
+	default:
+		return false
+	}
+	return true
+}
+
+// Button implements the buttonScreen interface. The D-pad moves the
+// player, whatever the keys of the profile.
+func (ps *playScreen) Button(b sdl.GameControllerButton) bool {
+	e := ps.e
+	speed := int32(e.set.Gameplay.PlayerSpeed)
+	switch b {
+	case sdl.CONTROLLER_BUTTON_DPAD_LEFT:
+		e.s.Player().Move(Left, speed)
+	case sdl.CONTROLLER_BUTTON_DPAD_RIGHT:
+		e.s.Player().Move(Right, speed)
+	default:
+		return false
+	}
+	return true
+}
+
+// Draw implements the Screen interface.
+func (ps *playScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	ps.e.s.Draw(ps.now(now), viewport)
+}
+
+// pauseScreen is the pause overlay, on top of the game.
+type pauseScreen struct {
+	uiScreen
+}
+
+// Overlay implements the Screen interface.
+func (ps *pauseScreen) Overlay() bool {
+	return true
+}
+
+// Key implements the Screen interface. Besides the menu, P resumes the
+// game, and Escape ends it.
+func (ps *pauseScreen) Key(k sdl.Keycode) bool {
+	switch k {
+	case sdl.K_p:
+		ps.do(ResumeAction)
+	case sdl.K_ESCAPE:
+		ps.do(EndAction)
+	default:
+		return ps.nav(k)
+	}
+	return true
+}
+
+// do does the action of the menu.
+func (ps *pauseScreen) do(a Action) {
+	e := ps.e
+	switch a {
+	case ResumeAction:
+		e.screens.Pop()
+	case SettingsAction:
+		e.screens.Push(&settingsScreen{uiScreen: uiScreen{baseScreen: ps.baseScreen}, playing: true})
+	case EndAction:
+		e.screens.Pop()
+		e.gameOver()
+	case QuitAction:
+		e.running = false
+	}
+}
+
+// Draw implements the Screen interface.
+func (ps *pauseScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	ps.do(ps.e.m.DrawPause(viewport, &ps.u))
+}
+
+// settingsScreen is the settings, from the title or the pause overlay.
+// Settings apply as they change, and are saved.
+type settingsScreen struct {
+	uiScreen
+	section int  // section shown
+	playing bool // from the pause overlay, in the middle of a game
+}
+
+// Exit implements the Screen interface. The gameplay settings changed
+// from the title apply to the title, e.g. the difficulty shown.
+func (ss *settingsScreen) Exit() {
+	if !ss.playing {
+		ss.e.pick(ss.e.prof)
+	}
+}
+
+// Key implements the Screen interface. Escape goes back.
+func (ss *settingsScreen) Key(k sdl.Keycode) bool {
+	if k == sdl.K_ESCAPE {
+		ss.e.screens.Pop()
+		return true
+	}
+	return ss.nav(k)
+}
+
+// Draw implements the Screen interface.
+func (ss *settingsScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	e := ss.e
+	f := newSettingsForm(*e.set, ss.section, e.prof)
+	a := e.m.DrawSettings(viewport, &ss.u, f)
+	ss.section = f.section
+	switch a {
+	case ApplyAction:
+		e.configure(f.settings())
+	case KeysAction:
+		e.screens.Push(&keysScreen{uiScreen: uiScreen{baseScreen: ss.baseScreen}})
+	case BackAction:
+		e.screens.Pop()
+	}
+}
+
+// keysScreen binds the keys of the profile playing, from the settings,
+// with the profile picker.
+type keysScreen struct {
+	uiScreen
+}
+
+// Enter implements the Screen interface.
+func (ks *keysScreen) Enter() {
+	ks.e.picker.Select(ks.e.prof)
+	ks.e.picker.Mode = profile.BindLeft
+}
+
+// Key implements the Screen interface. Escape goes back.
+func (ks *keysScreen) Key(k sdl.Keycode) bool {
+	e := ks.e
+	e.picker.Key(sdl.GetKeyName(k))
+	if e.picker.Changed {
+		if err := e.picker.Profiles.Save(e.st); err != nil {
+			log.Printf("failed to save profiles: %v", err)
+		}
+		e.picker.Changed = false
+	}
+	if e.picker.Mode == profile.Browse {
+		e.screens.Pop()
+	}
+	return true
+}
+
+// Draw implements the Screen interface.
+func (ks *keysScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	ks.e.m.DrawProfiles(viewport, &ks.u, ks.e.picker)
+}
+
+// nameScreen is the name entry of a new high score, which replaces
+// the game when it's over.
+type nameScreen struct {
+	baseScreen
+}
+
+// Enter implements the Screen interface.
+func (ns *nameScreen) Enter() {
+	sdl.StartTextInput()
+}
+
+// Exit implements the Screen interface.
+func (ns *nameScreen) Exit() {
+	sdl.StopTextInput()
+}
+
+// Key implements the Screen interface.
+func (ns *nameScreen) Key(k sdl.Keycode) bool {
+	e := ns.e
+	switch k {
+	case sdl.K_BACKSPACE:
+		e.m.Erase()
+	case sdl.K_RETURN, sdl.K_KP_ENTER:
+		e.m.Enter()
+		e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: ns.baseScreen}})
+	case sdl.K_ESCAPE:
+		e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: ns.baseScreen}})
+	}
+	return true
+}
+
+// Type implements the Screen interface.
+func (ns *nameScreen) Type(text string) {
+	ns.e.m.Type(text)
+}
+
+// Draw implements the Screen interface.
+func (ns *nameScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	ns.e.m.DrawNameEntry(viewport)
+}
+
+// highScoresScreen is the high scores, from the title or when the
+// game is over.
+type highScoresScreen struct {
+	uiScreen
+}
+
+// Key implements the Screen interface. Escape goes back.
+func (hs *highScoresScreen) Key(k sdl.Keycode) bool {
+	if k == sdl.K_ESCAPE {
+		hs.e.screens.Pop()
+		return true
+	}
+	return hs.nav(k)
+}
+
+// Draw implements the Screen interface.
+func (hs *highScoresScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	if hs.e.m.DrawHighScores(viewport, &hs.u) == BackAction {
+		hs.e.screens.Pop()
+	}
+}
+
+// statsScreen is the stats of the profile, which can be exported.
+type statsScreen struct {
+	baseScreen
+	msg string // message for the player, e.g. where the stats were exported
+}
+
+// Key implements the Screen interface. E exports the stats.
+func (ss *statsScreen) Key(k sdl.Keycode) bool {
+	switch {
+	case back(k):
+		ss.e.screens.Pop()
+	case k == sdl.K_e:
+		ss.export()
+	default:
+		return false
+	}
+	return true
+}
+
+// export exports the stats of the profile to a file in the store.
+func (ss *statsScreen) export() {
+	e := ss.e
+	ex := e.stats.Export(e.prof.Name, e.last)
+	b, err := ex.JSON()
+	if err == nil {
+		err = e.st.Save(ex.FileName(), b)
+	}
+	if err != nil {
+		log.Printf("failed to export stats: %v", err)
+		ss.msg = locale.T("Failed to export the stats")
+		return
+	}
+	name := ex.FileName()
+	if dir, ok := e.st.(store.Dir); ok {
+		name = filepath.Join(string(dir), name)
+	}
+	log.Printf("exported stats to %s", name)
+	ss.msg = locale.Sprintf("Exported to %s", name)
+}
+
+// Draw implements the Screen interface.
+func (ss *statsScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	e := ss.e
+	e.m.DrawStats(viewport, e.stats.Export(e.prof.Name, e.last), ss.msg)
+}
+
+// achievementsScreen is the gallery of achievements of the profile.
+type achievementsScreen struct {
+	baseScreen
+	sel int // achievement selected
+}
+
+// Key implements the Screen interface. Up and down select the
+// achievements.
+func (as *achievementsScreen) Key(k sdl.Keycode) bool {
+	switch {
+	case back(k):
+		as.e.screens.Pop()
+	case k == sdl.K_UP && as.sel > 0:
+		as.sel--
+	case k == sdl.K_DOWN && as.sel < len(as.e.defs)-1:
+		as.sel++
+	case k == sdl.K_UP || k == sdl.K_DOWN:
+	default:
+		return false
+	}
+	return true
+}
+
+// Draw implements the Screen interface.
+func (as *achievementsScreen) Draw(now time.Time, viewport *sdl.Rect) {
+	e := as.e
+	p := e.ach.Profile(e.prof.Name)
+	e.m.DrawAchievements(viewport, p.Status(e.defs, nil, e.stats.Profile(e.prof.Name)), as.sel)
+}
@@ -0,0 +1,167 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/settings"
+)
+
+// Sections of the settings screen.
+const (
+	videoSection = iota
+	gameplaySection
+	audioSection
+	controlsSection
+	accessibilitySection
+)
+
+// settingsSections are the names of the sections of the settings
+// screen, in order.
+var settingsSections = []string{"Video", "Gameplay", "Audio", "Controls", "Accessibility"}
+
+// settingsForm is the settings edited in the settings screen, with
+// the values of its widgets.
+type settingsForm struct {
+	settings.Settings
+	section     int                   // section shown
+	resolutions []settings.Resolution // window sizes to choose from
+	resolution  int                   // window size, in resolutions
+	fpsCaps     []int                 // frame rates to choose from
+	fps         int                   // frame rate, in fpsCaps
+	speed       float64               // player speed, from 0 (slowest) to 1
+	difficulty  int                   // in difficultyOptions, 0 is the profile's
+	keys        string                // keys of the profile, e.g. "Left/A, Right/D"
+}
+
+// speedStep is the step of the player speed slider, 5 pixels.
+const speedStep = 5.0 / (settings.MaxPlayerSpeed - settings.MinPlayerSpeed)
+
+// difficultyOptions returns the options of the difficulty, the first
+// is the difficulty of the profile.
+func difficultyOptions() []string {
+	return append([]string{"profile"}, difficulty.Names()...)
+}
+
+// newSettingsForm returns the form of the settings s in the section,
+// and of the keys of the profile p.
+func newSettingsForm(s settings.Settings, section int, p *profile.Profile) *settingsForm {
+	f := &settingsForm{Settings: s, section: section}
+	cur := settings.Resolution{Width: s.Video.Width, Height: s.Video.Height}
+	f.resolutions = settings.Resolutions
+	f.resolution = -1
+	for i, r := range f.resolutions {
+		if r == cur {
+			f.resolution = i
+		}
+	}
+	if f.resolution < 0 {
+		// window size from the command line
+		f.resolutions = append(f.resolutions[:len(f.resolutions):len(f.resolutions)], cur)
+		f.resolution = len(f.resolutions) - 1
+	}
+	f.fpsCaps = settings.FPSCaps
+	f.fps = slices.Index(f.fpsCaps, s.Video.FPS)
+	if f.fps < 0 {
+		// frame rate from the command line
+		f.fpsCaps = append(f.fpsCaps[:len(f.fpsCaps):len(f.fpsCaps)], s.Video.FPS)
+		f.fps = len(f.fpsCaps) - 1
+	}
+	f.speed = float64(s.Gameplay.PlayerSpeed-settings.MinPlayerSpeed) /
+		(settings.MaxPlayerSpeed - settings.MinPlayerSpeed)
+	for i, name := range difficultyOptions() {
+		if i > 0 && name == s.Gameplay.Difficulty {
+			f.difficulty = i
+		}
+	}
+	if p != nil {
+		f.keys = strings.Join(p.Bindings.Left, "/") + ", " + strings.Join(p.Bindings.Right, "/")
+	}
+	return f
+}
+
+// settings returns the settings of the form.
+func (f *settingsForm) settings() settings.Settings {
+	s := f.Settings
+	r := f.resolutions[f.resolution]
+	s.Video.Width, s.Video.Height = r.Width, r.Height
+	s.Video.FPS = f.fpsCaps[f.fps]
+	s.Gameplay.PlayerSpeed = settings.MinPlayerSpeed +
+		int(math.Round(f.speed*(settings.MaxPlayerSpeed-settings.MinPlayerSpeed)))
+	s.Gameplay.Difficulty = ""
+	if f.difficulty > 0 {
+		s.Gameplay.Difficulty = difficultyOptions()[f.difficulty]
+	}
+	return s
+}
+
+// override overrides the settings with the fields of the config that
+// are set, e.g. the flags given in the command line, clamped to the
+// range of the settings.
+func (c *Config) override(s *settings.Settings) {
+	defer s.Clamp()
+	if c.Set["width"] {
+		s.Video.Width = c.Width
+	}
+	if c.Set["height"] {
+		s.Video.Height = c.Height
+	}
+	if c.Set["fps"] {
+		s.Video.FPS = c.FPS
+	}
+	if c.Set["speed"] {
+		s.Gameplay.PlayerSpeed = c.PlayerSpeed
+	}
+	if c.Set["difficulty"] {
+		s.Gameplay.Difficulty = c.Difficulty
+	}
+	if c.Set["adaptive"] {
+		s.Gameplay.Adaptive = c.Adaptive
+	}
+	if c.Set["audio-cues"] {
+		s.Accessibility.AudioCues = c.AudioCues
+	}
+}
+
+// configure applies the settings, and saves the changes from the
+// settings in use, but not the config overriding them. The gameplay
+// settings apply from the next game.
+func (e *engine) configure(s settings.Settings) {
+	e.saved.Merge(*e.set, s)
+	old := e.set.Video
+	e.set.Video = s.Video
+	e.set.Gameplay = s.Gameplay
+	e.set.Accessibility = s.Accessibility
+	if v := s.Video; v.Width != old.Width || v.Height != old.Height {
+		e.w.SetSize(int32(v.Width), int32(v.Height))
+	}
+	if s.Video.Fullscreen != old.Fullscreen {
+		e.setFullscreen(s.Video.Fullscreen)
+	}
+	e.s.SetCues(nil)
+	if s.Accessibility.AudioCues {
+		e.s.SetCues(e.cues)
+	}
+	e.s.SetReduceMotion(s.Accessibility.ReduceMotion)
+	// sets the audio, and saves the settings
+	e.a.Set(s.Audio)
+}
+
+// setFullscreen sets the window to full screen, or back.
+func (e *engine) setFullscreen(on bool) {
+	var flags uint32
+	if on {
+		flags = sdl.WINDOW_FULLSCREEN
+	}
+	e.w.SetFullscreen(flags)
+}
@@ -0,0 +1,44 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"testing"
+
+	"github.com/fiorix/cat-o-licious/settings"
+)
+
+func TestConfigOverride(t *testing.T) {
+	saved := settings.Default()
+	saved.Video.Width, saved.Video.FPS = 1024, 60
+	saved.Gameplay.Adaptive = true
+	saved.Accessibility.AudioCues = true
+	for _, tc := range []struct {
+		name string
+		set  []string
+		want func(s *settings.Settings)
+	}{
+		{"none", nil, func(s *settings.Settings) {}},
+		{"default fps", []string{"fps"}, func(s *settings.Settings) { s.Video.FPS = 30 }},
+		{"adaptive off", []string{"adaptive"}, func(s *settings.Settings) { s.Gameplay.Adaptive = false }},
+		{"audio cues off", []string{"audio-cues"}, func(s *settings.Settings) { s.Accessibility.AudioCues = false }},
+		{"width", []string{"width"}, func(s *settings.Settings) { s.Video.Width = 800 }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := DefaultConfig
+			c.Set = make(map[string]bool)
+			for _, name := range tc.set {
+				c.Set[name] = true
+			}
+			got, want := *saved, *saved
+			c.override(&got)
+			tc.want(&want)
+			if got != want {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+	sdlmix "github.com/veandco/go-sdl2/mix"
+
+	"github.com/fiorix/cat-o-licious/tween"
+)
+
+// Toast timing: how long toasts take to slide in and out, and how long
+// they stay.
+const (
+	toastSlideTime = 300 * time.Millisecond
+	toastTime      = 3 * time.Second
+)
+
+// Toasts draws notifications over the screen, e.g. of achievements
+// unlocked, one at a time sliding in from the top.
+type Toasts interface {
+	// Show queues a toast with the given title and text.
+	Show(title, text string)
+
+	// Draw updates and draws the current toast, if any.
+	Draw(now time.Time, viewport *sdl.Rect)
+}
+
+type toast struct {
+	title, text string
+}
+
+type toasts struct {
+	r     *sdl.Renderer
+	f     *font         // text font
+	ft    *font         // title font
+	sfx   *sdlmix.Chunk // played as toasts slide in
+	queue []toast
+	cur   *toast
+	seq   *tween.Sequence
+	pos   float64 // how far the toast is in, from 0 to 1
+	last  time.Time
+}
+
+// NewToasts creates and initializes the toasts.
+func NewToasts(r *sdl.Renderer) (Toasts, error) {
+	f, err := openFont(24)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := openFont(32)
+	if err != nil {
+		return nil, err
+	}
+	sfx, err := loadSound("assets/snd/achievement.wav", "achievement")
+	if err != nil {
+		return nil, err
+	}
+	return &toasts{r: r, f: f, ft: ft, sfx: sfx}, nil
+}
+
+// Show implements the Toasts interface.
+func (ts *toasts) Show(title, text string) {
+	ts.queue = append(ts.queue, toast{title, text})
+}
+
+// Draw implements the Toasts interface.
+func (ts *toasts) Draw(now time.Time, viewport *sdl.Rect) {
+	dt := frameTime(ts.last, now)
+	ts.last = now
+	if ts.seq == nil || ts.seq.Done() {
+		if len(ts.queue) == 0 {
+			ts.cur = nil
+			return
+		}
+		ts.cur = &ts.queue[0]
+		ts.queue = ts.queue[1:]
+		ts.seq = tween.Seq(
+			ts.slide(0, 1, tween.OutQuad),
+			tween.Wait(toastTime),
+			ts.slide(1, 0, tween.InQuad),
+		)
+		playAt(ts.sfx, 0)
+	}
+	ts.seq.Update(dt)
+	const w, h = 440, 80
+	x := viewport.W/2 - w/2
+	y := int32(float64(h+10)*ts.pos) - h
+	ts.r.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	ts.r.SetDrawColor(0, 0, 0, 200)
+	ts.r.FillRect(&sdl.Rect{X: x, Y: y, W: w, H: h})
+	ts.r.SetDrawColor(255, 215, 0, 255)
+	ts.r.DrawRect(&sdl.Rect{X: x, Y: y, W: w, H: h})
+	ts.r.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	ts.drawText(ts.ft, ts.cur.title, sdl.Color{R: 255, G: 215, A: 255}, viewport.W/2, y+8)
+	ts.drawText(ts.f, ts.cur.text, sdl.Color{R: 255, G: 255, B: 255, A: 255}, viewport.W/2, y+46)
+}
+
+// slide returns a tween that moves the toast from and to the given
+// positions.
+func (ts *toasts) slide(from, to float64, ease tween.Ease) *tween.Tween {
+	t := tween.New(from, to, toastSlideTime, ease)
+	t.OnUpdate = func(v float64) { ts.pos = v }
+	return t
+}
+
+// drawText draws the text centered on x.
+func (ts *toasts) drawText(f *font, text string, c sdl.Color, x, y int32) {
+	s, err := f.RenderUTF8Blended(text, c)
+	if err != nil {
+		log.Println("failed to create toast surface:", err)
+		return
+	}
+	defer s.Free()
+	t, err := ts.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create toast texture:", err)
+		return
+	}
+	defer t.Destroy()
+	ts.r.Copy(t, nil, &sdl.Rect{X: x - s.W/2, Y: y, W: s.W, H: s.H})
+}
@@ -0,0 +1,91 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package game
+
+import (
+	"log"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"github.com/fiorix/cat-o-licious/ui"
+)
+
+// uiRenderer draws the widgets of the ui package with the renderer,
+// in the fonts of each size of text.
+type uiRenderer struct {
+	r     *sdl.Renderer
+	fonts map[ui.Size]*font
+}
+
+// MeasureText implements the ui.Renderer interface.
+func (ur *uiRenderer) MeasureText(text string, size ui.Size) (w, h int) {
+	f := ur.fonts[size]
+	w, h, err := f.SizeUTF8(text)
+	if err != nil {
+		return 0, f.Height()
+	}
+	return w, h
+}
+
+// DrawText implements the ui.Renderer interface.
+func (ur *uiRenderer) DrawText(text string, size ui.Size, c ui.Color, x, y int) {
+	s, err := ur.fonts[size].RenderUTF8Blended(text, sdlColor(c))
+	if err != nil {
+		log.Println("failed to create font surface:", err)
+		return
+	}
+	defer s.Free()
+	t, err := ur.r.CreateTextureFromSurface(s)
+	if err != nil {
+		log.Println("failed to create font texture:", err)
+		return
+	}
+	defer t.Destroy()
+	ur.r.Copy(t, nil, &sdl.Rect{X: int32(x), Y: int32(y), W: s.W, H: s.H})
+}
+
+// FillRect implements the ui.Renderer interface.
+func (ur *uiRenderer) FillRect(r ui.Rect, c ui.Color) {
+	ur.r.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	ur.r.SetDrawColor(c.R, c.G, c.B, c.A)
+	ur.r.FillRect(&sdl.Rect{X: int32(r.X), Y: int32(r.Y), W: int32(r.W), H: int32(r.H)})
+	ur.r.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+}
+
+// sdlColor returns the color of the ui package as an SDL color.
+func sdlColor(c ui.Color) sdl.Color {
+	return sdl.Color{R: c.R, G: c.G, B: c.B, A: c.A}
+}
+
+// uiNav returns the navigation of the widgets of the key, if any.
+func uiNav(k sdl.Keycode) ui.Nav {
+	switch k {
+	case sdl.K_UP:
+		return ui.NavUp
+	case sdl.K_DOWN:
+		return ui.NavDown
+	case sdl.K_LEFT:
+		return ui.NavLeft
+	case sdl.K_RIGHT:
+		return ui.NavRight
+	case sdl.K_RETURN, sdl.K_KP_ENTER, sdl.K_SPACE:
+		return ui.NavActivate
+	}
+	return ui.NavNone
+}
+
+// controllerKeys are the keys of the buttons of game controllers, so
+// gamepads navigate the menus and play like the keyboard.
+var controllerKeys = map[sdl.GameControllerButton]sdl.Keycode{
+	sdl.CONTROLLER_BUTTON_DPAD_UP:    sdl.K_UP,
+	sdl.CONTROLLER_BUTTON_DPAD_DOWN:  sdl.K_DOWN,
+	sdl.CONTROLLER_BUTTON_DPAD_LEFT:  sdl.K_LEFT,
+	sdl.CONTROLLER_BUTTON_DPAD_RIGHT: sdl.K_RIGHT,
+	sdl.CONTROLLER_BUTTON_A:          sdl.K_RETURN,
+	sdl.CONTROLLER_BUTTON_B:          sdl.K_ESCAPE,
+	sdl.CONTROLLER_BUTTON_BACK:       sdl.K_ESCAPE,
+	sdl.CONTROLLER_BUTTON_START:      sdl.K_p,
+}
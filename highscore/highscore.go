@@ -0,0 +1,178 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package highscore provides the high-score table of the game, kept
+// between runs. It is shared by the SDL and wasm versions of the game.
+package highscore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// File is the name of the high-score table in the game's store.
+const File = "highscores.json"
+
+// Version is the version of the high-score table format.
+const Version = 1
+
+// Size is the number of high scores kept per mode.
+const Size = 10
+
+// MaxName is the maximum length of a player name, in characters.
+const MaxName = 12
+
+// DefaultName is the name of players that don't type one.
+const DefaultName = "Cat"
+
+// Entry is a high score.
+type Entry struct {
+	Name    string    `json:"name"`
+	Profile string    `json:"profile,omitempty"` // profile of the player
+	Points  int64     `json:"points"`
+	Level   int       `json:"level"` // level reached, from 1
+	Date    time.Time `json:"date"`
+}
+
+// Table is the high-score table, with the top scores of each mode of
+// the game, e.g. "normal" or "hard/adaptive", sorted by points.
+type Table struct {
+	Version int                `json:"version"`
+	Modes   map[string][]Entry `json:"modes"`
+}
+
+// Mode returns the mode of the game for the given difficulty, with
+// or without the adaptive difficulty.
+func Mode(difficulty string, adaptive bool) string {
+	if adaptive {
+		return difficulty + "/adaptive"
+	}
+	return difficulty
+}
+
+// New returns an empty high-score table.
+func New() *Table {
+	return &Table{Version: Version, Modes: make(map[string][]Entry)}
+}
+
+// Load loads the high-score table from the store. A missing table is
+// an empty table. A table that fails to load is kept in the store as
+// File.bad for inspection, and replaced by an empty table returned
+// with an error, so the game can go on. Invalid entries are dropped.
+func Load(st store.Store) (*Table, error) {
+	b, err := st.Load(File)
+	switch {
+	case errors.Is(err, store.ErrNotExist):
+		return New(), nil
+	case err != nil:
+		return New(), err
+	}
+	t := New()
+	err = json.Unmarshal(b, t)
+	if err == nil && t.Version > Version {
+		err = fmt.Errorf("unsupported version %d", t.Version)
+	}
+	if err != nil {
+		st.Save(File+".bad", b)
+		return New(), fmt.Errorf("high scores: %v", err)
+	}
+	t.Version = Version
+	if t.Modes == nil {
+		t.Modes = make(map[string][]Entry)
+	}
+	for mode := range t.Modes {
+		t.fix(mode)
+	}
+	return t, nil
+}
+
+// Save saves the high-score table to the store.
+func (t *Table) Save(st store.Store) error {
+	b, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+	return st.Save(File, b)
+}
+
+// Top returns the high scores of the mode, best first.
+func (t *Table) Top(mode string) []Entry {
+	return t.Modes[mode]
+}
+
+// Qualifies returns true if the points make it to the high scores of
+// the mode.
+func (t *Table) Qualifies(mode string, points int64) bool {
+	top := t.Modes[mode]
+	return points > 0 && (len(top) < Size || points > top[len(top)-1].Points)
+}
+
+// Add adds the entry to the high scores of the mode, and returns its
+// rank from 0, or -1 if it didn't make it.
+func (t *Table) Add(mode string, e Entry) int {
+	if !t.Qualifies(mode, e.Points) {
+		return -1
+	}
+	e.Name = CleanName(e.Name)
+	top := t.Modes[mode]
+	// ties rank below the older scores
+	i := sort.Search(len(top), func(i int) bool { return top[i].Points < e.Points })
+	top = append(top, Entry{})
+	copy(top[i+1:], top[i:])
+	top[i] = e
+	if len(top) > Size {
+		top = top[:Size]
+	}
+	t.Modes[mode] = top
+	return i
+}
+
+// fix drops the invalid entries of the mode, and sorts and trims the
+// rest.
+func (t *Table) fix(mode string) {
+	var top []Entry
+	for _, e := range t.Modes[mode] {
+		if e.Points <= 0 {
+			continue
+		}
+		e.Name = CleanName(e.Name)
+		top = append(top, e)
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Points > top[j].Points })
+	if len(top) > Size {
+		top = top[:Size]
+	}
+	if len(top) == 0 {
+		delete(t.Modes, mode)
+		return
+	}
+	t.Modes[mode] = top
+}
+
+// CleanName returns the name without control characters and extra
+// spaces, up to MaxName characters, or DefaultName if empty.
+func CleanName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+	if r := []rune(name); len(r) > MaxName {
+		name = strings.TrimSpace(string(r[:MaxName]))
+	}
+	if name == "" {
+		return DefaultName
+	}
+	return name
+}
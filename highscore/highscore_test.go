@@ -0,0 +1,94 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package highscore
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string // saved table, empty for none
+		want map[string][]Entry
+		err  bool
+		bad  bool // kept as File.bad
+	}{
+		{"missing", "", map[string][]Entry{}, false, false},
+		{"empty", `{"version": 1}`, map[string][]Entry{}, false, false},
+		{"saved", `{"version": 1, "modes": {"normal": [{"name": "Tom", "points": 10, "level": 2}]}}`,
+			map[string][]Entry{"normal": {{Name: "Tom", Points: 10, Level: 2}}}, false, false},
+		{"unsorted", `{"modes": {"hard": [{"name": "A", "points": 1}, {"name": "B", "points": 5}]}}`,
+			map[string][]Entry{"hard": {{Name: "B", Points: 5}, {Name: "A", Points: 1}}}, false, false},
+		{"invalid entries", `{"modes": {"hard": [{"name": "A", "points": 0}, {"name": " \u0007B  b ", "points": 5}], "easy": [{"points": -1}]}}`,
+			map[string][]Entry{"hard": {{Name: "B b", Points: 5}}}, false, false},
+		{"corrupt", `{"modes": {"normal": [`, map[string][]Entry{}, true, true},
+		{"wrong type", `{"modes": []}`, map[string][]Entry{}, true, true},
+		{"newer version", `{"version": 2, "modes": {}}`, map[string][]Entry{}, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := store.Dir(t.TempDir())
+			if tc.data != "" {
+				st.Save(File, []byte(tc.data))
+			}
+			tb, err := Load(st)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			if tb.Version != Version || !reflect.DeepEqual(tb.Modes, tc.want) {
+				t.Fatalf("got %+v, want modes %+v", tb, tc.want)
+			}
+			bad, err := st.Load(File + ".bad")
+			if tc.bad && string(bad) != tc.data {
+				t.Fatalf("got %q kept as bad, want %q", bad, tc.data)
+			}
+			if !tc.bad && err != store.ErrNotExist {
+				t.Fatalf("got %q kept as bad, want none", bad)
+			}
+		})
+	}
+}
+
+func TestLoadTrims(t *testing.T) {
+	var entries []string
+	for i := range Size + 5 {
+		entries = append(entries, fmt.Sprintf(`{"name": "P%d", "points": %d}`, i, i+1))
+	}
+	st := store.Dir(t.TempDir())
+	st.Save(File, []byte(`{"modes": {"normal": [`+strings.Join(entries, ",")+`]}}`))
+	tb, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := tb.Top("normal")
+	if len(top) != Size || top[0].Points != Size+5 || top[Size-1].Points != 6 {
+		t.Fatalf("got %+v, want the top %d", top, Size)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tb := New()
+	for _, tc := range []struct {
+		name   string
+		points int64
+		rank   int
+	}{
+		{"first", 10, 0},
+		{"better", 20, 0},
+		{"tie", 10, 2},
+		{"worse", 5, 3},
+		{"none", 0, -1},
+	} {
+		if got := tb.Add("normal", Entry{Name: tc.name, Points: tc.points}); got != tc.rank {
+			t.Errorf("%s: got rank %d, want %d", tc.name, got, tc.rank)
+		}
+	}
+}
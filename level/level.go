@@ -0,0 +1,287 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package level provides the levels of the game: scripted timelines
+// of waves of drops, and the goals to complete each level. It is
+// shared by the SDL and wasm versions, which spawn the drops.
+package level
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/locale"
+)
+
+// DefaultFile is the level file, relative to the game.
+const DefaultFile = "assets/levels.json"
+
+// Time is a time offset from the start of a level. In JSON it is
+// either m:ss (e.g. 1:30) or a duration string (e.g. 90s).
+type Time time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *Time) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	d, err := parseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = Time(d)
+	return nil
+}
+
+// parseTime parses m:ss or a duration string.
+func parseTime(s string) (time.Duration, error) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return time.ParseDuration(s)
+	}
+	m, err := strconv.Atoi(s[:i])
+	if err != nil || m < 0 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	sec, err := strconv.Atoi(s[i+1:])
+	if err != nil || sec < 0 || sec > 59 || len(s[i+1:]) != 2 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(m)*time.Minute + time.Duration(sec)*time.Second, nil
+}
+
+// Formations of bursts of drops.
+const (
+	Random = "random" // drops at random positions
+	Line   = "line"   // drops evenly spaced across the screen
+)
+
+// Wave is a scripted event of a level. A wave with count spawns a
+// burst of drops at the given time, and a wave with until restricts
+// the random drops to the given ones from at until then.
+type Wave struct {
+	// At is the time the wave starts.
+	At Time `json:"at"`
+
+	// Until is the time the wave ends, for waves that restrict the
+	// random drops.
+	Until Time `json:"until"`
+
+	// Drops are the names of the drops of the wave, e.g. bacon,
+	// from the drop catalog. Bursts cycle through them.
+	Drops []string `json:"drops"`
+
+	// Count is the number of drops of a burst.
+	Count int `json:"count"`
+
+	// Formation is the formation of a burst, random (default) or
+	// line.
+	Formation string `json:"formation"`
+}
+
+// Goal is the goal of a level. All goals set must be met to complete
+// the level. Levels can't be failed, they go on until the goals are
+// met, so a level with only a survive goal is a timed level.
+type Goal struct {
+	// Catch is the number of good drops to catch.
+	Catch int `json:"catch"`
+
+	// Points is the number of points to make in the level.
+	Points int64 `json:"points"`
+
+	// Survive is the time to play the level, whatever is caught
+	// or missed in the meantime.
+	Survive Time `json:"survive"`
+}
+
+// Level is a level of the game.
+type Level struct {
+	Name  string `json:"name"`
+	Goal  Goal   `json:"goal"`
+	Waves []Wave `json:"waves"`
+}
+
+// Levels is the list of levels of the game, in order.
+type Levels []*Level
+
+// Parse reads and validates levels in JSON format.
+func Parse(r io.Reader) (Levels, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var ls Levels
+	if err := dec.Decode(&ls); err != nil {
+		return nil, fmt.Errorf("levels: %v", err)
+	}
+	if err := ls.Validate(); err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+// Validate returns an error if any of the levels is invalid.
+func (ls Levels) Validate() error {
+	for i, l := range ls {
+		if l == nil || l.Name == "" {
+			return fmt.Errorf("level %d: no name", i+1)
+		}
+		g := l.Goal
+		if g.Catch <= 0 && g.Points <= 0 && g.Survive <= 0 {
+			return fmt.Errorf("level %q: no goal", l.Name)
+		}
+		for j, w := range l.Waves {
+			err := w.validate()
+			if err != nil {
+				return fmt.Errorf("level %q: wave %d: %v", l.Name, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (w Wave) validate() error {
+	switch {
+	case len(w.Drops) == 0:
+		return fmt.Errorf("no drops")
+	case w.At < 0:
+		return fmt.Errorf("negative time")
+	case w.Count > 0 && w.Until > 0:
+		return fmt.Errorf("both count and until")
+	case w.Count <= 0 && w.Until <= w.At:
+		return fmt.Errorf("no count, or until not after at")
+	}
+	switch w.Formation {
+	case "", Random, Line:
+	default:
+		return fmt.Errorf("unknown formation %q", w.Formation)
+	}
+	return nil
+}
+
+// Check returns an error if any of the waves has drops that are not
+// in the given list of drop names.
+func (ls Levels) Check(names []string) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	for _, l := range ls {
+		for j, w := range l.Waves {
+			for _, name := range w.Drops {
+				if !known[name] {
+					return fmt.Errorf("level %q: wave %d: unknown drop %q", l.Name, j+1, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Spawn is a drop to spawn.
+type Spawn struct {
+	// Name is the name of the drop.
+	Name string
+
+	// X is the horizontal position of the drop, from 0 (left)
+	// to 1 (right).
+	X float64
+}
+
+// Run is a level being played.
+type Run struct {
+	Level  *Level
+	Caught int   // good drops caught
+	Points int64 // points made
+
+	start time.Time
+	fired []bool // bursts already spawned
+}
+
+// NewRun starts playing the level.
+func NewRun(l *Level, now time.Time) *Run {
+	return &Run{Level: l, start: now, fired: make([]bool, len(l.Waves))}
+}
+
+// Elapsed returns the time since the level started.
+func (r *Run) Elapsed(now time.Time) time.Duration {
+	return now.Sub(r.start)
+}
+
+// Score records points made in the level, from a drop caught.
+func (r *Run) Score(points int64) {
+	if points > 0 {
+		r.Caught++
+	}
+	r.Points += points
+}
+
+// Spawns returns the drops of the bursts due since the last call.
+func (r *Run) Spawns(now time.Time) []Spawn {
+	t := Time(r.Elapsed(now))
+	var s []Spawn
+	for i, w := range r.Level.Waves {
+		if w.Count <= 0 || r.fired[i] || w.At > t {
+			continue
+		}
+		r.fired[i] = true
+		for n := 0; n < w.Count; n++ {
+			x := rand.Float64()
+			if w.Formation == Line {
+				x = (float64(n) + .5) / float64(w.Count)
+			}
+			s = append(s, Spawn{Name: w.Drops[n%len(w.Drops)], X: x})
+		}
+	}
+	return s
+}
+
+// Pool returns the names of the drops the random drops are restricted
+// to, or nil when there is no restriction.
+func (r *Run) Pool(now time.Time) []string {
+	t := Time(r.Elapsed(now))
+	for _, w := range r.Level.Waves {
+		if w.Until > 0 && w.At <= t && t < w.Until {
+			return w.Drops
+		}
+	}
+	return nil
+}
+
+// Done returns true if the goals of the level have been met.
+func (r *Run) Done(now time.Time) bool {
+	g := r.Level.Goal
+	return r.Caught >= g.Catch &&
+		r.Points >= g.Points &&
+		r.Elapsed(now) >= time.Duration(g.Survive)
+}
+
+// Status returns the progress toward the goals of the level, in the
+// locale of the game.
+func (r *Run) Status(now time.Time) string {
+	var s []string
+	g := r.Level.Goal
+	if g.Catch > 0 {
+		s = append(s, locale.Sprintf("caught %d/%d", min(r.Caught, g.Catch), g.Catch))
+	}
+	if g.Points > 0 {
+		s = append(s, locale.Sprintf("points %d/%d", max(r.Points, 0), g.Points))
+	}
+	if g.Survive > 0 {
+		el := min(r.Elapsed(now), time.Duration(g.Survive))
+		s = append(s, locale.Sprintf("time %s/%s", clock(el), clock(time.Duration(g.Survive))))
+	}
+	return strings.Join(s, "  ")
+}
+
+// clock formats d as m:ss.
+func clock(d time.Duration) string {
+	sec := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", sec/60, sec%60)
+}
@@ -0,0 +1,164 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package level
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"0:00", 0, true},
+		{"1:30", 90 * time.Second, true},
+		{"12:05", 12*time.Minute + 5*time.Second, true},
+		{"45s", 45 * time.Second, true},
+		{"1m30s", 90 * time.Second, true},
+		{"1:60", 0, false},
+		{"1:5", 0, false},
+		{"-1:00", 0, false},
+		{"a:00", 0, false},
+		{"soon", 0, false},
+	} {
+		d, err := parseTime(tc.s)
+		if (err == nil) != tc.ok || d != tc.want {
+			t.Fatalf("%q: got %v, %v, want %v, ok %v", tc.s, d, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"empty", `[]`, true},
+		{"level", `[{"name": "One", "goal": {"catch": 10}, "waves": [
+			{"at": "0:10", "drops": ["bacon"], "count": 3, "formation": "line"},
+			{"at": "0:20", "until": "0:40", "drops": ["fish"]}
+		]}]`, true},
+		{"no name", `[{"goal": {"catch": 10}}]`, false},
+		{"no goal", `[{"name": "One"}]`, false},
+		{"no drops", `[{"name": "One", "goal": {"catch": 10}, "waves": [{"at": "0:10", "count": 3}]}]`, false},
+		{"count and until", `[{"name": "One", "goal": {"catch": 10}, "waves": [
+			{"at": "0:10", "until": "0:20", "drops": ["bacon"], "count": 3}
+		]}]`, false},
+		{"until before at", `[{"name": "One", "goal": {"catch": 10}, "waves": [
+			{"at": "0:20", "until": "0:10", "drops": ["bacon"]}
+		]}]`, false},
+		{"formation", `[{"name": "One", "goal": {"catch": 10}, "waves": [
+			{"at": "0:10", "drops": ["bacon"], "count": 3, "formation": "circle"}
+		]}]`, false},
+		{"bad time", `[{"name": "One", "goal": {"survive": "soon"}}]`, false},
+		{"unknown field", `[{"name": "One", "goal": {"catch": 10}, "boss": true}]`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ls := Levels{{Name: "One", Waves: []Wave{{Drops: []string{"bacon", "fish"}}}}}
+	if err := ls.Check([]string{"bacon", "fish", "tomato"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Check([]string{"bacon"}); err == nil {
+		t.Fatal("got no error for an unknown drop")
+	}
+}
+
+func TestRunSpawns(t *testing.T) {
+	l := &Level{Name: "One", Waves: []Wave{
+		{At: Time(10 * time.Second), Drops: []string{"bacon", "fish"}, Count: 4, Formation: Line},
+		{At: Time(20 * time.Second), Drops: []string{"tomato"}, Count: 2},
+		{At: Time(5 * time.Second), Until: Time(30 * time.Second), Drops: []string{"steak"}},
+	}}
+	now := time.Now()
+	r := NewRun(l, now)
+	if s := r.Spawns(now.Add(9 * time.Second)); len(s) != 0 {
+		t.Fatalf("got %d spawns before the first burst, want none", len(s))
+	}
+	s := r.Spawns(now.Add(10 * time.Second))
+	want := []Spawn{{"bacon", .125}, {"fish", .375}, {"bacon", .625}, {"fish", .875}}
+	if len(s) != len(want) {
+		t.Fatalf("got %d spawns, want %d", len(s), len(want))
+	}
+	for i := range s {
+		if s[i] != want[i] {
+			t.Fatalf("got spawn %d %+v, want %+v", i, s[i], want[i])
+		}
+	}
+	if s := r.Spawns(now.Add(15 * time.Second)); len(s) != 0 {
+		t.Fatalf("got %d spawns of the burst again, want none", len(s))
+	}
+	if s := r.Spawns(now.Add(time.Minute)); len(s) != 2 || s[0].Name != "tomato" {
+		t.Fatalf("got spawns %+v, want 2 tomatoes", s)
+	}
+}
+
+func TestRunPool(t *testing.T) {
+	l := &Level{Name: "One", Waves: []Wave{
+		{At: Time(5 * time.Second), Until: Time(30 * time.Second), Drops: []string{"steak"}},
+	}}
+	now := time.Now()
+	r := NewRun(l, now)
+	for _, tc := range []struct {
+		at   time.Duration
+		want int
+	}{
+		{0, 0},
+		{5 * time.Second, 1},
+		{29 * time.Second, 1},
+		{30 * time.Second, 0},
+	} {
+		if p := r.Pool(now.Add(tc.at)); len(p) != tc.want {
+			t.Fatalf("got pool %v at %v, want %d drops", p, tc.at, tc.want)
+		}
+	}
+}
+
+func TestRunDone(t *testing.T) {
+	l := &Level{Name: "One", Goal: Goal{Catch: 2, Points: 20, Survive: Time(time.Minute)}}
+	now := time.Now()
+	r := NewRun(l, now)
+	r.Score(15)
+	r.Score(-10)
+	r.Score(15)
+	if r.Caught != 2 || r.Points != 20 {
+		t.Fatalf("got %d caught and %d points, want 2 and 20", r.Caught, r.Points)
+	}
+	if r.Done(now.Add(30 * time.Second)) {
+		t.Fatal("got done before surviving the level")
+	}
+	if !r.Done(now.Add(time.Minute)) {
+		t.Fatal("got not done with all goals met")
+	}
+	if s, want := r.Status(now.Add(30*time.Second)), "caught 2/2  points 20/20  time 0:30/1:00"; s != want {
+		t.Fatalf("got status %q, want %q", s, want)
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
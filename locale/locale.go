@@ -0,0 +1,313 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package locale provides the translations of the text of the game,
+// from catalogs of messages in JSON, one per locale, e.g.
+//
+//	{
+//		"locale": "pt",
+//		"messages": {
+//			"Play": "Jogar",
+//			"%d points": {"one": "%d ponto", "other": "%d pontos"}
+//		}
+//	}
+//
+// Messages are looked up by their English text, so English needs no
+// translations, only the plural forms of its messages. Messages are
+// looked up in a chain of locales, from the most specific, e.g. pt-BR,
+// pt, then the fallback of the catalogs, and English, and messages
+// missing from all of them are shown in English.
+//
+// The game sets its locale once, at start, and the packages showing
+// text translate it with T, Sprintf and Pluralf. It is shared by the
+// SDL and wasm versions of the game.
+package locale
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultDir is the directory of the catalogs, relative to the game.
+// Catalogs are named by their locale, e.g. assets/locales/pt.json.
+const DefaultDir = "assets/locales"
+
+// Default is the locale of the messages, and the last fallback.
+const Default = "en"
+
+// Message is the translation of a message: its text, or its plural
+// forms by plural category, e.g. one and other.
+type Message struct {
+	Text  string
+	Forms map[string]string
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Messages
+// are strings, or objects of plural forms.
+func (m *Message) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &m.Text); err == nil {
+		return nil
+	}
+	return json.Unmarshal(b, &m.Forms)
+}
+
+// Catalog is the messages of a locale.
+type Catalog struct {
+	// Locale is the locale of the messages, e.g. pt or pt-BR.
+	Locale string `json:"locale"`
+
+	// Fallback is the locale to look up the messages missing from
+	// the catalog, before English, e.g. es for a catalog of gl.
+	Fallback string `json:"fallback,omitempty"`
+
+	// Font is the file of a font with the characters of the
+	// locale, e.g. for non-Latin scripts, relative to the fonts of
+	// the game.
+	Font string `json:"font,omitempty"`
+
+	// Messages are the translations of the messages, by their
+	// English text.
+	Messages map[string]Message `json:"messages"`
+}
+
+// verbs matches the verbs of format strings, e.g. %d or %+.1f.
+var verbs = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// Parse reads and validates a catalog in JSON format. Translations
+// must have the same format verbs as their message, and plural forms
+// must have the other form.
+func Parse(r io.Reader) (*Catalog, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var c Catalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("locale: %v", err)
+	}
+	c.Locale = Normalize(c.Locale)
+	if c.Locale == "" {
+		return nil, errors.New("locale: missing locale")
+	}
+	c.Fallback = Normalize(c.Fallback)
+	for id, m := range c.Messages {
+		if m.Forms == nil {
+			if err := checkVerbs(id, m.Text); err != nil {
+				return nil, fmt.Errorf("locale: %s: %v", c.Locale, err)
+			}
+			continue
+		}
+		if _, ok := m.Forms["other"]; !ok {
+			return nil, fmt.Errorf("locale: %s: %q: missing the other form", c.Locale, id)
+		}
+		for cat, text := range m.Forms {
+			if !slices.Contains(categories, cat) {
+				return nil, fmt.Errorf("locale: %s: %q: unknown plural category %q", c.Locale, id, cat)
+			}
+			if err := checkVerbs(id, text); err != nil {
+				return nil, fmt.Errorf("locale: %s: %v", c.Locale, err)
+			}
+		}
+	}
+	return &c, nil
+}
+
+// checkVerbs returns an error if the translation doesn't have the
+// format verbs of the message.
+func checkVerbs(id, text string) error {
+	want, got := verbs.FindAllString(id, -1), verbs.FindAllString(text, -1)
+	slices.Sort(want)
+	slices.Sort(got)
+	if !slices.Equal(want, got) {
+		return fmt.Errorf("%q: translation %q has verbs %v, want %v", id, text, got, want)
+	}
+	return nil
+}
+
+// Normalize returns the locale in the form of the catalogs, e.g. pt-BR
+// for pt_BR.UTF-8, or empty for the C and POSIX locales.
+func Normalize(s string) string {
+	if i := strings.IndexAny(s, ".@"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "C" || s == "POSIX" {
+		return ""
+	}
+	lang, region, _ := strings.Cut(strings.ReplaceAll(s, "_", "-"), "-")
+	lang = strings.ToLower(lang)
+	if region == "" {
+		return lang
+	}
+	return lang + "-" + strings.ToUpper(region)
+}
+
+// FromEnv returns the locale of the environment of getenv, e.g.
+// os.Getenv: LC_ALL, LC_MESSAGES or LANG, the first set.
+func FromEnv(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := Normalize(getenv(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Chain returns the locales to look up for the locale, from the most
+// specific, e.g. pt-BR and pt for pt-BR. The fallback of the catalogs,
+// and English, come after them.
+func Chain(locale string) []string {
+	var chain []string
+	for s := Normalize(locale); s != ""; {
+		chain = append(chain, s)
+		i := strings.LastIndex(s, "-")
+		if i < 0 {
+			break
+		}
+		s = s[:i]
+	}
+	return chain
+}
+
+// Locale is the catalogs of a locale and its fallbacks.
+type Locale struct {
+	catalogs []*Catalog // in the order looked up
+}
+
+// Load loads the catalogs of the locale and its fallbacks with load,
+// which returns the catalog in JSON of a locale, e.g. pt, or an error
+// that is fs.ErrNotExist if there's none. Catalogs that fail to load
+// are skipped, and returned with an error, so the game can go on.
+func Load(locale string, load func(locale string) ([]byte, error)) (*Locale, error) {
+	l := &Locale{}
+	var errs []error
+	queue := Chain(locale)
+	seen := map[string]bool{}
+	// English is always last, after the queue
+	for i := 0; i <= len(queue); i++ {
+		name := Default
+		if i < len(queue) {
+			name = queue[i]
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		b, err := load(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		var c *Catalog
+		if err == nil {
+			c, err = Parse(bytes.NewReader(b))
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l.catalogs = append(l.catalogs, c)
+		queue = append(queue, Chain(c.Fallback)...)
+	}
+	return l, errors.Join(errs...)
+}
+
+// Name returns the locale of the most specific catalog loaded, or
+// English if none.
+func (l *Locale) Name() string {
+	if len(l.catalogs) == 0 {
+		return Default
+	}
+	return l.catalogs[0].Locale
+}
+
+// Font returns the font of the most specific catalog that has one,
+// or empty if none.
+func (l *Locale) Font() string {
+	for _, c := range l.catalogs {
+		if c.Font != "" {
+			return c.Font
+		}
+	}
+	return ""
+}
+
+// lookup returns the message, and the locale of its catalog.
+func (l *Locale) lookup(id string) (Message, string, bool) {
+	for _, c := range l.catalogs {
+		if m, ok := c.Messages[id]; ok {
+			return m, c.Locale, true
+		}
+	}
+	return Message{}, "", false
+}
+
+// T returns the translation of the message.
+func (l *Locale) T(id string) string {
+	m, _, ok := l.lookup(id)
+	switch {
+	case !ok:
+		return id
+	case m.Forms != nil:
+		return m.Forms["other"]
+	}
+	return m.Text
+}
+
+// Sprintf formats the translation of the format.
+func (l *Locale) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Pluralf formats the plural form of the format for the count n, e.g.
+// "%d points" is "1 point" for 1 in English.
+func (l *Locale) Pluralf(n int64, format string, args ...any) string {
+	m, locale, ok := l.lookup(format)
+	switch {
+	case !ok:
+	case m.Forms == nil:
+		format = m.Text
+	default:
+		if f, ok := m.Forms[Category(locale, n)]; ok {
+			format = f
+		} else {
+			format = m.Forms["other"]
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// current is the locale of the game.
+var current = &Locale{}
+
+// Set sets the locale of the game.
+func Set(l *Locale) {
+	current = l
+}
+
+// Current returns the locale of the game.
+func Current() *Locale {
+	return current
+}
+
+// T returns the translation of the message in the locale of the game.
+func T(id string) string {
+	return current.T(id)
+}
+
+// Sprintf formats the translation of the format in the locale of the
+// game.
+func Sprintf(format string, args ...any) string {
+	return current.Sprintf(format, args...)
+}
+
+// Pluralf formats the plural form of the format for the count n in
+// the locale of the game.
+func Pluralf(n int64, format string, args ...any) string {
+	return current.Pluralf(n, format, args...)
+}
@@ -0,0 +1,231 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package locale
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		n      int64
+		want   string
+	}{
+		{"en", 0, "other"},
+		{"en", 1, "one"},
+		{"en", -1, "one"},
+		{"en", 2, "other"},
+		{"en-GB", 1, "one"},
+		{"de", 1, "one"},
+		{"", 1, "one"},
+		{"es", 0, "other"},
+		{"es", 1, "one"},
+		{"es", 2, "other"},
+		{"es", 1000000, "many"},
+		{"es-MX", 2000000, "many"},
+		{"pt", 0, "one"},
+		{"pt", 1, "one"},
+		{"pt", 2, "other"},
+		{"pt", 1000000, "many"},
+		{"pt-BR", 0, "one"},
+		{"pt-PT", 0, "other"},
+		{"pt_PT.UTF-8", 1, "one"},
+		{"pt-PT", 1000000, "many"},
+	} {
+		if got := Category(tc.locale, tc.n); got != tc.want {
+			t.Errorf("Category(%q, %d): got %q, want %q", tc.locale, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"":            "",
+		"C":           "",
+		"POSIX":       "",
+		"C.UTF-8":     "",
+		"pt":          "pt",
+		"PT":          "pt",
+		"pt_BR":       "pt-BR",
+		"pt_br.UTF-8": "pt-BR",
+		"de_DE@euro":  "de-DE",
+		"zh-Hant-TW":  "zh-HANT-TW",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		want   []string
+	}{
+		{"", nil},
+		{"C", nil},
+		{"en", []string{"en"}},
+		{"pt_BR.UTF-8", []string{"pt-BR", "pt"}},
+		{"zh-Hant-TW", []string{"zh-HANT-TW", "zh-HANT", "zh"}},
+	} {
+		if got := Chain(tc.locale); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Chain(%q): got %q, want %q", tc.locale, got, tc.want)
+		}
+	}
+}
+
+// catalogs returns a load function of the catalogs, by locale, that
+// records the locales loaded.
+func catalogs(cs map[string]string, loaded *[]string) func(string) ([]byte, error) {
+	return func(locale string) ([]byte, error) {
+		*loaded = append(*loaded, locale)
+		c, ok := cs[locale]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", locale, fs.ErrNotExist)
+		}
+		return []byte(c), nil
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cs := map[string]string{
+		"en":    `{"locale": "en", "messages": {"%d points": {"one": "%d point", "other": "%d points"}}}`,
+		"pt":    `{"locale": "pt", "messages": {"Play": "Jogar", "Quit": "Sair", "%d points": {"one": "%d ponto", "other": "%d pontos"}}}`,
+		"pt-PT": `{"locale": "pt-PT", "messages": {"Play": "Jogar!"}}`,
+		"gl":    `{"locale": "gl", "fallback": "es", "font": "gl.ttf", "messages": {"Play": "Xogar"}}`,
+		"es":    `{"locale": "es", "font": "es.ttf", "messages": {"Play": "Jugar", "Quit": "Salir"}}`,
+		"bad":   `{"locale": "bad", "messages": {"%d points": "%s puntos"}}`,
+	}
+	for _, tc := range []struct {
+		locale string
+		loaded []string // locales loaded, in order
+		name   string
+		font   string
+		play   string
+		quit   string
+		err    bool
+	}{
+		{"", []string{"en"}, "en", "", "Play", "Quit", false},
+		{"fr", []string{"fr", "en"}, "en", "", "Play", "Quit", false},
+		{"pt", []string{"pt", "en"}, "pt", "", "Jogar", "Sair", false},
+		{"pt-BR", []string{"pt-BR", "pt", "en"}, "pt", "", "Jogar", "Sair", false},
+		{"pt-PT", []string{"pt-PT", "pt", "en"}, "pt-PT", "", "Jogar!", "Sair", false},
+		{"gl", []string{"gl", "es", "en"}, "gl", "gl.ttf", "Xogar", "Salir", false},
+		{"bad", []string{"bad", "en"}, "en", "", "Play", "Quit", true},
+	} {
+		t.Run(tc.locale, func(t *testing.T) {
+			var loaded []string
+			l, err := Load(tc.locale, catalogs(cs, &loaded))
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			if !reflect.DeepEqual(loaded, tc.loaded) {
+				t.Fatalf("got %q loaded, want %q", loaded, tc.loaded)
+			}
+			if l.Name() != tc.name || l.Font() != tc.font {
+				t.Fatalf("got name %q font %q, want %q and %q", l.Name(), l.Font(), tc.name, tc.font)
+			}
+			if got := l.T("Play"); got != tc.play {
+				t.Fatalf("got %q for Play, want %q", got, tc.play)
+			}
+			if got := l.T("Quit"); got != tc.quit {
+				t.Fatalf("got %q for Quit, want %q", got, tc.quit)
+			}
+		})
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	l, err := Load("pt", func(locale string) ([]byte, error) {
+		if locale == "pt" {
+			return nil, errors.New("disk on fire")
+		}
+		return nil, fs.ErrNotExist
+	})
+	if err == nil || !strings.Contains(err.Error(), "disk on fire") {
+		t.Fatalf("got error %v, want the load error", err)
+	}
+	if l.Name() != Default || l.T("Play") != "Play" {
+		t.Fatalf("got locale %q, want English", l.Name())
+	}
+}
+
+func TestPluralf(t *testing.T) {
+	cs := map[string]string{
+		"en": `{"locale": "en", "messages": {"%d points": {"one": "%d point", "other": "%d points"}}}`,
+		"pt": `{"locale": "pt", "messages": {"%d points": {"one": "%d ponto", "other": "%d pontos"}, "%d lives": "%d vidas"}}`,
+	}
+	var loaded []string
+	en, _ := Load("en", catalogs(cs, &loaded))
+	pt, _ := Load("pt", catalogs(cs, &loaded))
+	for _, tc := range []struct {
+		l      *Locale
+		format string
+		n      int64
+		want   string
+	}{
+		{en, "%d points", 1, "1 point"},
+		{en, "%d points", 0, "0 points"},
+		{pt, "%d points", 0, "0 ponto"},
+		{pt, "%d points", 1000000, "1000000 pontos"}, // no many form
+		{pt, "%d lives", 1, "1 vidas"},
+		{pt, "%d cats", 1, "1 cats"},
+	} {
+		if got := tc.l.Pluralf(tc.n, tc.format, tc.n); got != tc.want {
+			t.Errorf("%s: Pluralf(%d, %q): got %q, want %q", tc.l.Name(), tc.n, tc.format, got, tc.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		err  bool
+	}{
+		{"valid", `{"locale": "pt_BR", "messages": {"%d of %s": "%s: %d"}}`, false},
+		{"no locale", `{"messages": {}}`, true},
+		{"unknown field", `{"locale": "pt", "lang": "pt"}`, true},
+		{"missing verb", `{"locale": "pt", "messages": {"%d points": "pontos"}}`, true},
+		{"other verb", `{"locale": "pt", "messages": {"%d points": "%s pontos"}}`, true},
+		{"no other form", `{"locale": "pt", "messages": {"%d points": {"one": "%d ponto"}}}`, true},
+		{"unknown category", `{"locale": "pt", "messages": {"%d points": {"other": "%d pontos", "dual": "%d"}}}`, true},
+		{"bad form", `{"locale": "pt", "messages": {"%d points": {"other": "pontos"}}}`, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tc.json)); (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+		})
+	}
+}
+
+// TestCatalogs checks the catalogs of the game.
+func TestCatalogs(t *testing.T) {
+	names, err := filepath.Glob(filepath.Join("..", DefaultDir, "*.json"))
+	if err != nil || len(names) == 0 {
+		t.Fatalf("no catalogs: %v", err)
+	}
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Parse(f)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package locale
+
+import (
+	"strings"
+	"unicode"
+)
+
+// categories are the plural categories of CLDR.
+var categories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// rule returns the plural category of the count n.
+type rule func(n int64) string
+
+// rules are the plural rules of the locales, by locale or language,
+// for counts, from CLDR. Languages without a rule use the English one.
+var rules = map[string]rule{
+	"en": func(n int64) string {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+	"es": func(n int64) string {
+		switch {
+		case n == 1:
+			return "one"
+		case n != 0 && n%1000000 == 0:
+			return "many" // e.g. 1 millón de puntos
+		}
+		return "other"
+	},
+	"pt": func(n int64) string {
+		switch {
+		case n == 0 || n == 1:
+			return "one"
+		case n%1000000 == 0:
+			return "many"
+		}
+		return "other"
+	},
+	"pt-PT": func(n int64) string {
+		switch {
+		case n == 1:
+			return "one"
+		case n != 0 && n%1000000 == 0:
+			return "many"
+		}
+		return "other"
+	},
+}
+
+// Category returns the plural category of the count n in the locale,
+// e.g. one for 1 in English.
+func Category(locale string, n int64) string {
+	if n < 0 {
+		n = -n
+	}
+	for _, s := range Chain(locale) {
+		if r, ok := rules[s]; ok {
+			return r(n)
+		}
+	}
+	return rules[Default](n)
+}
+
+// folds are the ASCII letters of the Latin letters with diacritics,
+// e.g. a for á, and of some punctuation.
+var folds = map[rune]string{}
+
+func init() {
+	for ascii, runes := range map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄ", "a": "àáâãäåāăą",
+		"C": "ÇĆĈĊČ", "c": "çćĉċč",
+		"E": "ÈÉÊËĒĔĖĘĚ", "e": "èéêëēĕėęě",
+		"I": "ÌÍÎÏĨĪĬĮİ", "i": "ìíîïĩīĭįı",
+		"N": "ÑŃŅŇ", "n": "ñńņň",
+		"O": "ÒÓÔÕÖØŌŎŐ", "o": "òóôõöøōŏő",
+		"U": "ÙÚÛÜŨŪŬŮŰŲ", "u": "ùúûüũūŭůűų",
+		"Y": "ÝŸ", "y": "ýÿ",
+		"ss": "ß", "AE": "Æ", "ae": "æ",
+		"!": "¡", "?": "¿", "\"": "«»“”", "'": "‘’", "-": "–—",
+		"a.": "ª", "o.": "º",
+	} {
+		for _, r := range runes {
+			folds[r] = ascii
+		}
+	}
+}
+
+// Fold returns the text in ASCII, for fonts without the characters of
+// the locale: letters lose their diacritics, e.g. "é" is "e", and other
+// characters are "?".
+func Fold(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch s, ok := folds[r]; {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case ok:
+			b.WriteString(s)
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -9,8 +9,10 @@ import (
 	"flag"
 	"log"
 	"math/rand"
+	"strings"
 	"time"
 
+	"github.com/fiorix/cat-o-licious/difficulty"
 	"github.com/fiorix/cat-o-licious/game"
 )
 
@@ -21,7 +23,22 @@ func main() {
 	flag.IntVar(&conf.Width, "width", conf.Width, "game width")
 	flag.IntVar(&conf.Height, "height", conf.Height, "game height")
 	flag.IntVar(&conf.PlayerSpeed, "speed", conf.PlayerSpeed, "player speed")
+	flag.StringVar(&conf.Difficulty, "difficulty", conf.Difficulty,
+		"game difficulty: "+strings.Join(difficulty.Names(), ", ")+
+			" (default: the player's)")
+	flag.BoolVar(&conf.Adaptive, "adaptive", conf.Adaptive,
+		"adjust difficulty to the player's performance")
+	flag.BoolVar(&conf.ShowAdaptive, "show-adaptive", conf.ShowAdaptive,
+		"show and log adaptive difficulty adjustments")
+	flag.BoolVar(&conf.AudioCues, "audio-cues", conf.AudioCues,
+		"play sounds for approaching drops")
+	flag.StringVar(&conf.Lang, "lang", conf.Lang,
+		"language of the game, e.g. en, pt or es (default: from LANG)")
+	flag.StringVar(&conf.ConfigDir, "config-dir", conf.ConfigDir,
+		"directory of the game settings (default: user config dir)")
 	flag.Parse()
+	conf.Set = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { conf.Set[f.Name] = true })
 	err := game.Run(&conf)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,229 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package motion provides movement behaviors of falling drops,
+// shared by the SDL and wasm versions. Behaviors are pure functions
+// of the drop's state, so they can be used and tested in isolation
+// from the renderer.
+//
+// Units are pixels and frames, like the rest of the game: speeds
+// are in pixels per frame, and time in frames scaled by the rain's
+// time scale (e.g. slow motion).
+package motion
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// State is the kinematic state of a falling drop.
+type State struct {
+	X, Y    float64 // top left position
+	W, H    float64 // size of the drop
+	VX, VY  float64 // velocity, in pixels per frame
+	T       float64 // frames since the drop was spawned
+	Width   float64 // width of the viewport, for the walls
+	Floor   float64 // vertical position of the floor
+	Bounces int     // number of times the drop bounced off the floor
+	Split   bool    // set when the drop should split in two
+}
+
+// Landed returns true if the drop has fallen through the floor.
+func (s *State) Landed() bool {
+	return s.Y+s.H > s.Floor
+}
+
+// Motion is a movement behavior of drops.
+type Motion interface {
+	// Start initializes the state of a new drop.
+	Start(s *State)
+
+	// Move updates the state of the drop, usually its velocity,
+	// for the next frame. The time scale is 1 for the normal speed.
+	Move(s *State, scale float64)
+}
+
+// Step advances the drop by one frame, applying the given motions
+// before integrating the velocity. Drops bounce off the walls.
+func Step(s *State, ms []Motion, scale float64) {
+	for _, m := range ms {
+		m.Move(s, scale)
+	}
+	s.X += s.VX * scale
+	s.Y += s.VY * scale
+	s.T += scale
+	if s.Width > 0 {
+		switch {
+		case s.X < 0:
+			s.X, s.VX = 0, math.Abs(s.VX)
+		case s.X+s.W > s.Width:
+			s.X, s.VX = s.Width-s.W, -math.Abs(s.VX)
+		}
+	}
+}
+
+// Start initializes a new drop with the given motions.
+func Start(s *State, ms []Motion) {
+	for _, m := range ms {
+		m.Start(s)
+	}
+}
+
+// Zigzag moves drops sideways in a sine wave. The wave is an offset
+// of the position, on top of the velocity set by other motions, e.g.
+// Drift, and by the walls.
+type Zigzag struct {
+	Amplitude float64 // in pixels
+	Period    float64 // in frames
+}
+
+// Start implements the Motion interface.
+func (z Zigzag) Start(s *State) {}
+
+// Move implements the Motion interface.
+func (z Zigzag) Move(s *State, scale float64) {
+	// the offset is A*sin(ωt), moved to its value at the next frame
+	w := 2 * math.Pi / z.Period
+	s.X += z.Amplitude * (math.Sin(w*(s.T+scale)) - math.Sin(w*s.T))
+}
+
+// Accelerate makes drops fall faster and faster.
+type Accelerate struct {
+	Gravity float64 // in pixels per frame²
+	Max     float64 // maximum fall speed, 0 for no limit
+}
+
+// Start implements the Motion interface.
+func (a Accelerate) Start(s *State) {}
+
+// Move implements the Motion interface.
+func (a Accelerate) Move(s *State, scale float64) {
+	s.VY += a.Gravity * scale
+	if a.Max > 0 && s.VY > a.Max {
+		s.VY = a.Max
+	}
+}
+
+// Drift blows drops sideways, either left or right at random.
+type Drift struct {
+	Wind float64 // in pixels per frame
+}
+
+// Start implements the Motion interface.
+func (d Drift) Start(s *State) {
+	s.VX = d.Wind
+	if rand.Intn(2) == 0 {
+		s.VX = -d.Wind
+	}
+}
+
+// Move implements the Motion interface.
+func (d Drift) Move(s *State, scale float64) {}
+
+// Split marks drops to split in two mid-air. The game replaces the
+// drop with two halves moving apart, see Halves.
+type Split struct {
+	At     float64 // split height, in pct of the floor
+	Spread float64 // lateral speed of the halves, in pixels per frame
+}
+
+// Start implements the Motion interface.
+func (sp Split) Start(s *State) {}
+
+// Move implements the Motion interface.
+func (sp Split) Move(s *State, scale float64) {
+	if s.Split || s.Y+s.H/2 < sp.At*s.Floor {
+		return
+	}
+	s.Split = true
+}
+
+// Halves returns the states of the two halves of a split drop. Each
+// half is half the size of the drop, side by side where the drop was.
+func (sp Split) Halves(s State) (left, right State) {
+	s.W, s.H = s.W/2, s.H/2
+	s.Y += s.H / 2
+	left, right = s, s
+	right.X += s.W
+	left.VX -= sp.Spread
+	right.VX += sp.Spread
+	return left, right
+}
+
+// Bounce makes drops bounce off the floor. Bouncing drops are
+// pulled back down by BounceGravity until they fall through.
+type Bounce struct {
+	Restitution float64 // pct of the speed kept on each bounce
+	Max         int     // maximum number of bounces
+}
+
+// BounceGravity is the vertical acceleration of drops after they
+// bounce off the floor, in pixels per frame².
+const BounceGravity = .5
+
+// Start implements the Motion interface.
+func (b Bounce) Start(s *State) {}
+
+// Move implements the Motion interface.
+func (b Bounce) Move(s *State, scale float64) {
+	if s.Bounces > 0 {
+		s.VY += BounceGravity * scale
+	}
+	if s.Bounces >= b.Max || s.VY <= 0 || s.Y+s.H+s.VY*scale <= s.Floor {
+		return
+	}
+	// bounce up, such that the drop touches the floor this frame
+	s.VY = -s.VY * b.Restitution
+	s.Y = s.Floor - s.H - s.VY*scale
+	s.Bounces++
+}
+
+// Def is the definition of a motion, as found in drop definitions.
+type Def struct {
+	Type        string  `json:"type"`
+	Amplitude   float64 `json:"amplitude,omitempty"`
+	Period      float64 `json:"period,omitempty"`
+	Gravity     float64 `json:"gravity,omitempty"`
+	Max         float64 `json:"max,omitempty"`
+	Wind        float64 `json:"wind,omitempty"`
+	At          float64 `json:"at,omitempty"`
+	Spread      float64 `json:"spread,omitempty"`
+	Restitution float64 `json:"restitution,omitempty"`
+	Bounces     int     `json:"bounces,omitempty"`
+}
+
+// New returns the motion for the definition, or an error if the
+// definition is invalid.
+func New(d Def) (Motion, error) {
+	switch d.Type {
+	case "zigzag":
+		if d.Amplitude <= 0 || d.Period <= 0 {
+			return nil, fmt.Errorf("zigzag: amplitude and period must be positive")
+		}
+		return Zigzag{Amplitude: d.Amplitude, Period: d.Period}, nil
+	case "accelerate":
+		if d.Gravity <= 0 || d.Max < 0 {
+			return nil, fmt.Errorf("accelerate: gravity must be positive and max not negative")
+		}
+		return Accelerate{Gravity: d.Gravity, Max: d.Max}, nil
+	case "drift":
+		if d.Wind <= 0 {
+			return nil, fmt.Errorf("drift: wind must be positive")
+		}
+		return Drift{Wind: d.Wind}, nil
+	case "split":
+		if d.At <= 0 || d.At >= 1 || d.Spread <= 0 {
+			return nil, fmt.Errorf("split: at must be within (0, 1) and spread positive")
+		}
+		return Split{At: d.At, Spread: d.Spread}, nil
+	case "bounce":
+		if d.Restitution <= 0 || d.Restitution >= 1 || d.Bounces < 1 {
+			return nil, fmt.Errorf("bounce: restitution must be within (0, 1) and bounces at least 1")
+		}
+		return Bounce{Restitution: d.Restitution, Max: d.Bounces}, nil
+	}
+	return nil, fmt.Errorf("unknown motion %q", d.Type)
+}
@@ -0,0 +1,232 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package motion
+
+import (
+	"math"
+	"testing"
+)
+
+// near returns true if the numbers are equal, give or take rounding.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestStep(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		s      State
+		scale  float64
+		x, y   float64
+		vx, tt float64
+	}{
+		{"still", State{X: 10, Y: 10, W: 10, Width: 100}, 1, 10, 10, 0, 1},
+		{"falls", State{X: 10, Y: 10, W: 10, VY: 2, Width: 100}, 1, 10, 12, 0, 1},
+		{"scaled", State{X: 10, Y: 10, W: 10, VX: 2, VY: 2, Width: 100}, .5, 11, 11, 2, .5},
+		{"left wall", State{X: 1, W: 10, VX: -3, Width: 100}, 1, 0, 0, 3, 1},
+		{"right wall", State{X: 88, W: 10, VX: 3, Width: 100}, 1, 90, 0, -3, 1},
+		{"no walls", State{X: 1, W: 10, VX: -3}, 1, -2, 0, -3, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.s
+			Step(&s, nil, tc.scale)
+			if !near(s.X, tc.x) || !near(s.Y, tc.y) || !near(s.VX, tc.vx) || !near(s.T, tc.tt) {
+				t.Fatalf("got x=%v y=%v vx=%v t=%v, want x=%v y=%v vx=%v t=%v",
+					s.X, s.Y, s.VX, s.T, tc.x, tc.y, tc.vx, tc.tt)
+			}
+		})
+	}
+}
+
+func TestZigzag(t *testing.T) {
+	z := Zigzag{Amplitude: 10, Period: 40}
+	for _, tc := range []struct {
+		name   string
+		frames int
+		vx     float64
+		x      float64
+	}{
+		{"quarter period", 10, 0, 60},
+		{"half period", 20, 0, 50},
+		{"full period", 40, 0, 50},
+		{"drift, full period", 40, 1, 90},
+		{"drift back, full period", 40, -1, 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := State{X: 50, W: 10, VX: tc.vx, Width: 1000}
+			for range tc.frames {
+				Step(&s, []Motion{z}, 1)
+			}
+			if !near(s.X, tc.x) {
+				t.Fatalf("got x=%v, want %v", s.X, tc.x)
+			}
+			if s.VX != tc.vx {
+				t.Fatalf("got vx=%v, want %v", s.VX, tc.vx)
+			}
+		})
+	}
+}
+
+func TestZigzagKeepsWallReflection(t *testing.T) {
+	z := Zigzag{Amplitude: 1, Period: 40}
+	s := State{X: 1, W: 10, VX: -5, Width: 100}
+	Step(&s, []Motion{z}, 1)
+	Step(&s, []Motion{z}, 1)
+	if s.VX != 5 {
+		t.Fatalf("got vx=%v after the wall, want 5", s.VX)
+	}
+}
+
+func TestAccelerate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		a    Accelerate
+		vy   float64
+		want float64
+	}{
+		{"falls faster", Accelerate{Gravity: .5}, 2, 2.5},
+		{"up to max", Accelerate{Gravity: .5, Max: 4}, 3.8, 4},
+		{"at max", Accelerate{Gravity: .5, Max: 4}, 4, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := State{VY: tc.vy}
+			tc.a.Move(&s, 1)
+			if !near(s.VY, tc.want) {
+				t.Fatalf("got vy=%v, want %v", s.VY, tc.want)
+			}
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	d := Drift{Wind: 2}
+	left, right := 0, 0
+	for range 100 {
+		s := State{}
+		Start(&s, []Motion{d})
+		switch s.VX {
+		case -2:
+			left++
+		case 2:
+			right++
+		default:
+			t.Fatalf("got vx=%v, want ±2", s.VX)
+		}
+		Step(&s, []Motion{d}, 1)
+		if math.Abs(s.VX) != 2 {
+			t.Fatalf("got vx=%v after a frame, want ±2", s.VX)
+		}
+	}
+	if left == 0 || right == 0 {
+		t.Fatalf("got %d drops left and %d right, want both", left, right)
+	}
+}
+
+func TestBounce(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		b       Bounce
+		s       State
+		bounces int
+		vy      float64
+	}{
+		{"falling", Bounce{Restitution: .5, Max: 1}, State{Y: 0, H: 10, VY: 4, Floor: 100}, 0, 4},
+		{"bounces", Bounce{Restitution: .5, Max: 1}, State{Y: 88, H: 10, VY: 4, Floor: 100}, 1, -2},
+		{"no more", Bounce{Restitution: .5, Max: 1}, State{Y: 88, H: 10, VY: 4, Floor: 100, Bounces: 1}, 1, 4 + BounceGravity},
+		{"going up", Bounce{Restitution: .5, Max: 2}, State{Y: 50, H: 10, VY: -4, Floor: 100, Bounces: 1}, 1, -4 + BounceGravity},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.s
+			tc.b.Move(&s, 1)
+			if s.Bounces != tc.bounces || !near(s.VY, tc.vy) {
+				t.Fatalf("got bounces=%d vy=%v, want bounces=%d vy=%v", s.Bounces, s.VY, tc.bounces, tc.vy)
+			}
+			if s.Bounces > tc.s.Bounces && !near(s.Y+s.H+s.VY, tc.s.Floor) {
+				t.Fatalf("got the drop at %v after the bounce, want it at the floor", s.Y+s.H+s.VY)
+			}
+		})
+	}
+}
+
+func TestBounceFallsThrough(t *testing.T) {
+	b := Bounce{Restitution: .5, Max: 2}
+	s := State{Y: 0, H: 10, VY: 5, Floor: 100}
+	for i := 0; i < 1000 && !s.Landed(); i++ {
+		Step(&s, []Motion{b}, 1)
+	}
+	if !s.Landed() || s.Bounces != 2 {
+		t.Fatalf("got landed=%v bounces=%d, want landed after 2 bounces", s.Landed(), s.Bounces)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	sp := Split{At: .5, Spread: 3}
+	for _, tc := range []struct {
+		name  string
+		y     float64
+		split bool
+	}{
+		{"above", 30, false},
+		{"at", 45, true},
+		{"below", 80, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := State{Y: tc.y, H: 10, Floor: 100}
+			sp.Move(&s, 1)
+			if s.Split != tc.split {
+				t.Fatalf("got split=%v, want %v", s.Split, tc.split)
+			}
+		})
+	}
+}
+
+func TestSplitHalves(t *testing.T) {
+	sp := Split{At: .5, Spread: 3}
+	s := State{X: 20, Y: 40, W: 10, H: 20, VX: 1, VY: 4, Split: true}
+	left, right := sp.Halves(s)
+	for _, tc := range []struct {
+		name string
+		got  State
+		want State
+	}{
+		{"left", left, State{X: 20, Y: 45, W: 5, H: 10, VX: -2, VY: 4, Split: true}},
+		{"right", right, State{X: 25, Y: 45, W: 5, H: 10, VX: 4, VY: 4, Split: true}},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		def  Def
+		want Motion
+	}{
+		{"zigzag", Def{Type: "zigzag", Amplitude: 20, Period: 60}, Zigzag{Amplitude: 20, Period: 60}},
+		{"flat zigzag", Def{Type: "zigzag", Period: 60}, nil},
+		{"accelerate", Def{Type: "accelerate", Gravity: .2, Max: 12}, Accelerate{Gravity: .2, Max: 12}},
+		{"no gravity", Def{Type: "accelerate"}, nil},
+		{"drift", Def{Type: "drift", Wind: 2}, Drift{Wind: 2}},
+		{"no wind", Def{Type: "drift"}, nil},
+		{"split", Def{Type: "split", At: .4, Spread: 2}, Split{At: .4, Spread: 2}},
+		{"split at the floor", Def{Type: "split", At: 1, Spread: 2}, nil},
+		{"bounce", Def{Type: "bounce", Restitution: .6, Bounces: 2}, Bounce{Restitution: .6, Max: 2}},
+		{"no bounces", Def{Type: "bounce", Restitution: .6}, nil},
+		{"unknown", Def{Type: "teleport"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(tc.def)
+			if (err == nil) != (tc.want != nil) {
+				t.Fatalf("got error %v, want ok %v", err, tc.want != nil)
+			}
+			if m != tc.want {
+				t.Fatalf("got %#v, want %#v", m, tc.want)
+			}
+		})
+	}
+}
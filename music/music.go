@@ -0,0 +1,217 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package music provides the game's playlist, and decides which track
+// plays and how loud: tracks switch on score or level milestones with
+// a crossfade. It is shared by the SDL and wasm versions of the game,
+// which play the tracks.
+package music
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultFile is the playlist file, relative to the game.
+const DefaultFile = "assets/music.json"
+
+// Duration is a duration in JSON, e.g. "2s".
+type Duration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Track is a music track.
+type Track struct {
+	Name string `json:"name"`
+	File string `json:"file"` // relative to the game
+}
+
+// Playlist is the list of tracks of the game, played in order and in
+// a loop, and when to switch between them.
+type Playlist struct {
+	Tracks []Track `json:"tracks"`
+
+	// Points switches to the next track every N points.
+	Points int64 `json:"points"`
+
+	// Levels switches to the next track on every new level.
+	Levels bool `json:"levels"`
+
+	// Crossfade is the duration of the crossfade between tracks.
+	Crossfade Duration `json:"crossfade"`
+
+	// Volume is the volume of the tracks, from 0 to 1, relative to
+	// the volume set by the player.
+	Volume float64 `json:"volume"`
+}
+
+// Parse reads and validates a playlist in JSON format.
+func Parse(r io.Reader) (*Playlist, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var pl Playlist
+	if err := dec.Decode(&pl); err != nil {
+		return nil, fmt.Errorf("playlist: %v", err)
+	}
+	if err := pl.Validate(); err != nil {
+		return nil, err
+	}
+	return &pl, nil
+}
+
+// Validate returns an error if the playlist is invalid.
+func (pl *Playlist) Validate() error {
+	switch {
+	case len(pl.Tracks) == 0:
+		return fmt.Errorf("playlist: no tracks")
+	case pl.Points < 0:
+		return fmt.Errorf("playlist: negative points")
+	case pl.Crossfade < 0:
+		return fmt.Errorf("playlist: negative crossfade")
+	case pl.Volume < 0 || pl.Volume > 1:
+		return fmt.Errorf("playlist: volume not within 0 and 1")
+	}
+	for i, t := range pl.Tracks {
+		if t.File == "" {
+			return fmt.Errorf("playlist: track %d: no file", i+1)
+		}
+	}
+	return nil
+}
+
+// Mixer decides which track of a playlist plays, and the volumes of
+// the tracks during crossfades.
+type Mixer struct {
+	pl     *Playlist
+	cur    int           // current track
+	prev   int           // track fading out, -1 if none
+	fade   time.Duration // time into the crossfade
+	next   int64         // points of the next milestone
+	level  int           // current level
+	volume float64
+	paused bool
+}
+
+// NewMixer creates a mixer that starts with the first track of the
+// playlist.
+func NewMixer(pl *Playlist) *Mixer {
+	return &Mixer{pl: pl, prev: -1, next: pl.Points, volume: 1}
+}
+
+// Current returns the index of the current track.
+func (m *Mixer) Current() int {
+	return m.cur
+}
+
+// Previous returns the index of the track fading out, or -1 if none.
+func (m *Mixer) Previous() int {
+	return m.prev
+}
+
+// Next switches to the next track with a crossfade. Playlists with a
+// single track keep playing it.
+func (m *Mixer) Next() {
+	if len(m.pl.Tracks) < 2 {
+		return
+	}
+	m.prev = m.cur
+	m.cur = (m.cur + 1) % len(m.pl.Tracks)
+	m.fade = 0
+	if m.pl.Crossfade == 0 {
+		m.prev = -1
+	}
+}
+
+// Reset restarts the milestones for a new game, and keeps playing the
+// current track.
+func (m *Mixer) Reset() {
+	m.next = m.pl.Points
+	m.level = 0
+}
+
+// Score switches to the next track when the points reach the next
+// milestone of the playlist.
+func (m *Mixer) Score(points int64) {
+	if m.pl.Points <= 0 || points < m.next {
+		return
+	}
+	m.next = (points/m.pl.Points + 1) * m.pl.Points
+	m.Next()
+}
+
+// Level switches to the next track when the level changes, if the
+// playlist switches on levels.
+func (m *Mixer) Level(n int) {
+	if n == m.level {
+		return
+	}
+	m.level = n
+	if m.pl.Levels {
+		m.Next()
+	}
+}
+
+// Update advances the crossfade by dt, unless paused.
+func (m *Mixer) Update(dt time.Duration) {
+	if m.paused || m.prev < 0 {
+		return
+	}
+	m.fade += dt
+	if m.fade >= time.Duration(m.pl.Crossfade) {
+		m.prev = -1
+	}
+}
+
+// Volumes returns the volumes of the current track and of the track
+// fading out, from 0 to 1, scaled by the volumes of the playlist and
+// of the mixer.
+func (m *Mixer) Volumes() (cur, prev float64) {
+	v := m.volume * m.pl.Volume
+	if m.prev < 0 {
+		return v, 0
+	}
+	p := float64(m.fade) / float64(m.pl.Crossfade)
+	return v * p, v * (1 - p)
+}
+
+// SetVolume sets the volume of the music, from 0 to 1, on top of the
+// volume of the playlist.
+func (m *Mixer) SetVolume(v float64) {
+	if v < 0 {
+		v = 0
+	} else if v > 1 {
+		v = 1
+	}
+	m.volume = v
+}
+
+// Volume returns the volume of the music.
+func (m *Mixer) Volume() float64 {
+	return m.volume
+}
+
+// SetPaused pauses or resumes the music.
+func (m *Mixer) SetPaused(paused bool) {
+	m.paused = paused
+}
+
+// Paused returns true if the music is paused.
+func (m *Mixer) Paused() bool {
+	return m.paused
+}
@@ -0,0 +1,175 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package music
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"playlist", `{"tracks": [{"name": "One", "file": "one.wav"}], "points": 1000, "crossfade": "2s", "volume": 0.8}`, true},
+		{"no tracks", `{"tracks": []}`, false},
+		{"no file", `{"tracks": [{"name": "One"}]}`, false},
+		{"negative points", `{"tracks": [{"file": "one.wav"}], "points": -1}`, false},
+		{"negative crossfade", `{"tracks": [{"file": "one.wav"}], "crossfade": "-1s"}`, false},
+		{"bad crossfade", `{"tracks": [{"file": "one.wav"}], "crossfade": "soon"}`, false},
+		{"volume", `{"tracks": [{"file": "one.wav"}], "volume": 2}`, false},
+		{"unknown field", `{"tracks": [{"file": "one.wav"}], "shuffle": true}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+// playlist returns a playlist of n tracks.
+func playlist(n int, points int64, levels bool, crossfade time.Duration) *Playlist {
+	pl := &Playlist{Points: points, Levels: levels, Crossfade: Duration(crossfade), Volume: 1}
+	for i := 0; i < n; i++ {
+		pl.Tracks = append(pl.Tracks, Track{File: "track.wav"})
+	}
+	return pl
+}
+
+func TestMixerScore(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		pl     *Playlist
+		points []int64
+		cur    int
+	}{
+		{"below", playlist(3, 1000, false, 0), []int64{999}, 0},
+		{"milestone", playlist(3, 1000, false, 0), []int64{1000}, 1},
+		{"once per milestone", playlist(3, 1000, false, 0), []int64{1000, 1500}, 1},
+		{"next milestone", playlist(3, 1000, false, 0), []int64{1000, 2000}, 2},
+		{"skipped milestones", playlist(3, 1000, false, 0), []int64{3500, 3900}, 1},
+		{"loop", playlist(2, 1000, false, 0), []int64{1000, 2000}, 0},
+		{"single track", playlist(1, 1000, false, 0), []int64{1000}, 0},
+		{"no points", playlist(3, 0, false, 0), []int64{1000}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMixer(tc.pl)
+			for _, p := range tc.points {
+				m.Score(p)
+			}
+			if m.Current() != tc.cur {
+				t.Fatalf("got track %d, want %d", m.Current(), tc.cur)
+			}
+		})
+	}
+}
+
+func TestMixerLevel(t *testing.T) {
+	m := NewMixer(playlist(3, 0, true, 0))
+	m.Level(0)
+	if m.Current() != 0 {
+		t.Fatalf("got track %d on the same level, want 0", m.Current())
+	}
+	m.Level(1)
+	m.Level(1)
+	if m.Current() != 1 {
+		t.Fatalf("got track %d on a new level, want 1", m.Current())
+	}
+	m = NewMixer(playlist(3, 0, false, 0))
+	m.Level(1)
+	if m.Current() != 0 {
+		t.Fatalf("got track %d, want 0 with playlists that don't switch on levels", m.Current())
+	}
+}
+
+func TestMixerCrossfade(t *testing.T) {
+	m := NewMixer(playlist(2, 0, false, 2*time.Second))
+	if cur, prev := m.Volumes(); cur != 1 || prev != 0 || m.Previous() != -1 {
+		t.Fatalf("got volumes %v, %v of track %d, want 1, 0 of none", cur, prev, m.Previous())
+	}
+	m.Next()
+	m.Update(500 * time.Millisecond)
+	if cur, prev := m.Volumes(); cur != .25 || prev != .75 || m.Previous() != 0 {
+		t.Fatalf("got volumes %v, %v of track %d, want .25, .75 of 0", cur, prev, m.Previous())
+	}
+	m.SetPaused(true)
+	m.Update(time.Second)
+	if cur, _ := m.Volumes(); cur != .25 || !m.Paused() {
+		t.Fatalf("got volume %v while paused, want .25", cur)
+	}
+	m.SetPaused(false)
+	m.Update(2 * time.Second)
+	if cur, prev := m.Volumes(); cur != 1 || prev != 0 || m.Previous() != -1 {
+		t.Fatalf("got volumes %v, %v of track %d after the crossfade, want 1, 0 of none", cur, prev, m.Previous())
+	}
+}
+
+func TestMixerVolume(t *testing.T) {
+	for _, tc := range []struct {
+		v, want float64
+	}{
+		{.5, .5},
+		{-1, 0},
+		{2, 1},
+	} {
+		m := NewMixer(playlist(1, 0, false, 0))
+		m.SetVolume(tc.v)
+		if m.Volume() != tc.want {
+			t.Fatalf("got volume %v for %v, want %v", m.Volume(), tc.v, tc.want)
+		}
+	}
+}
+
+func TestMixerReset(t *testing.T) {
+	m := NewMixer(playlist(3, 1000, true, 0))
+	m.Score(1000)
+	m.Level(1)
+	m.Reset()
+	if m.Current() != 2 {
+		t.Fatalf("got track %d after reset, want 2", m.Current())
+	}
+	m.Score(999)
+	m.Level(1)
+	if m.Current() != 0 {
+		t.Fatalf("got track %d after reset, want 0", m.Current())
+	}
+	m.Score(1000)
+	if m.Current() != 1 {
+		t.Fatalf("got track %d at the first milestone, want 1", m.Current())
+	}
+}
+
+func TestMixerPlaylistVolume(t *testing.T) {
+	pl := playlist(2, 0, false, 2*time.Second)
+	pl.Volume = .5
+	m := NewMixer(pl)
+	m.SetVolume(.5)
+	if cur, _ := m.Volumes(); cur != .25 {
+		t.Fatalf("got volume %v, want .25", cur)
+	}
+	m.Next()
+	m.Update(time.Second)
+	if cur, prev := m.Volumes(); cur != .125 || prev != .125 {
+		t.Fatalf("got volumes %v, %v, want .125, .125", cur, prev)
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
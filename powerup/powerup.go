@@ -0,0 +1,110 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package powerup provides the power-ups of the game, special drops
+// with timed effects on the player or the rain. It is shared by the
+// SDL and wasm versions.
+package powerup
+
+import "time"
+
+// Kind is the kind of a power-up.
+type Kind int
+
+// Power-ups. Their images are loaded from powerup_{n}.png, where n
+// is the kind.
+const (
+	None         Kind = iota
+	Magnet            // pulls good drops toward the cat
+	Shield            // absorbs the next bad drop
+	SlowMotion        // slows down the rain
+	DoublePoints      // doubles points of good drops
+)
+
+// Kinds lists all power-ups, in the order of their images.
+var Kinds = []Kind{Magnet, Shield, SlowMotion, DoublePoints}
+
+var kindNames = map[Kind]string{
+	None:         "none",
+	Magnet:       "magnet",
+	Shield:       "shield",
+	SlowMotion:   "slow motion",
+	DoublePoints: "double points",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+var kindDurations = map[Kind]time.Duration{
+	Magnet:       8 * time.Second,
+	Shield:       15 * time.Second,
+	SlowMotion:   6 * time.Second,
+	DoublePoints: 10 * time.Second,
+}
+
+// Duration returns how long the power-up's effect lasts.
+func (k Kind) Duration() time.Duration {
+	return kindDurations[k]
+}
+
+// Power-up settings.
+const (
+	// Chance is the chance of a new drop being a power-up.
+	Chance = .04
+
+	// MagnetSpeed is the lateral speed of good drops pulled by
+	// the magnet, in pixels per frame.
+	MagnetSpeed = 6
+
+	// SlowMotionScale is the speed of the rain in slow motion.
+	SlowMotionScale = .5
+)
+
+// Effects tracks the active power-ups.
+type Effects struct {
+	until map[Kind]time.Time
+}
+
+// Activate activates the power-up at the given time. Activating
+// an active power-up restarts its timer.
+func (e *Effects) Activate(k Kind, now time.Time) {
+	if e.until == nil {
+		e.until = make(map[Kind]time.Time)
+	}
+	e.until[k] = now.Add(k.Duration())
+}
+
+// Deactivate deactivates the power-up, e.g. when the shield is used.
+func (e *Effects) Deactivate(k Kind) {
+	delete(e.until, k)
+}
+
+// Active returns true if the power-up is active at the given time.
+func (e *Effects) Active(k Kind, now time.Time) bool {
+	return e.Remaining(k, now) > 0
+}
+
+// Remaining returns the remaining time of the power-up.
+func (e *Effects) Remaining(k Kind, now time.Time) time.Duration {
+	until, ok := e.until[k]
+	if !ok {
+		return 0
+	}
+	d := until.Sub(now)
+	if d <= 0 {
+		delete(e.until, k)
+		return 0
+	}
+	return d
+}
+
+// TimeScale returns the speed of the rain at the given time.
+func (e *Effects) TimeScale(now time.Time) float64 {
+	if e.Active(SlowMotion, now) {
+		return SlowMotionScale
+	}
+	return 1
+}
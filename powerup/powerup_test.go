@@ -0,0 +1,81 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package powerup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKinds(t *testing.T) {
+	for _, k := range Kinds {
+		if k.String() == "" || k.Duration() <= 0 {
+			t.Fatalf("power-up %d: got name %q and duration %v, want both", k, k, k.Duration())
+		}
+	}
+	if None.Duration() != 0 {
+		t.Fatalf("got duration %v for none, want 0", None.Duration())
+	}
+}
+
+func TestEffects(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name      string
+		at        time.Duration // since the activation
+		remaining time.Duration
+	}{
+		{"activated", 0, Magnet.Duration()},
+		{"running", time.Second, Magnet.Duration() - time.Second},
+		{"expired", Magnet.Duration(), 0},
+		{"long expired", time.Hour, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var e Effects
+			e.Activate(Magnet, now)
+			at := now.Add(tc.at)
+			if r := e.Remaining(Magnet, at); r != tc.remaining {
+				t.Fatalf("got %v remaining, want %v", r, tc.remaining)
+			}
+			if a := e.Active(Magnet, at); a != (tc.remaining > 0) {
+				t.Fatalf("got active %v with %v remaining", a, tc.remaining)
+			}
+			if e.Active(Shield, at) {
+				t.Fatal("got the shield active, want only the magnet")
+			}
+		})
+	}
+}
+
+func TestEffectsRestart(t *testing.T) {
+	now := time.Now()
+	var e Effects
+	e.Activate(Shield, now)
+	later := now.Add(10 * time.Second)
+	e.Activate(Shield, later)
+	if r := e.Remaining(Shield, later); r != Shield.Duration() {
+		t.Fatalf("got %v remaining, want the timer restarted at %v", r, Shield.Duration())
+	}
+	e.Deactivate(Shield)
+	if e.Active(Shield, later) {
+		t.Fatal("got the shield active, want it deactivated")
+	}
+}
+
+func TestEffectsTimeScale(t *testing.T) {
+	now := time.Now()
+	var e Effects
+	if s := e.TimeScale(now); s != 1 {
+		t.Fatalf("got time scale %v, want 1", s)
+	}
+	e.Activate(SlowMotion, now)
+	if s := e.TimeScale(now); s != SlowMotionScale {
+		t.Fatalf("got time scale %v in slow motion, want %v", s, SlowMotionScale)
+	}
+	if s := e.TimeScale(now.Add(SlowMotion.Duration())); s != 1 {
+		t.Fatalf("got time scale %v after slow motion, want 1", s)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package profile
+
+import (
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+)
+
+// Modes of the picker.
+const (
+	Browse    = iota // choosing a profile
+	Naming           // typing the name of a new profile
+	BindLeft         // waiting for the key to move left
+	BindRight        // waiting for the key to move right
+)
+
+// Picker is the profile picker: players pick their profile, add new
+// ones, and change the color of their cat, their difficulty and their
+// keys. The frontends feed it the SDL names of the keys pressed, e.g.
+// "Up" or "Return", and the text typed, and draw it.
+type Picker struct {
+	Profiles *Profiles
+	Selected int    // index of the selected profile
+	Mode     int    // one of Browse, Naming, BindLeft or BindRight
+	Name     []rune // name being typed
+	Message  string // feedback for the player, e.g. a name taken
+	Changed  bool   // profiles changed, and should be saved
+
+	left string // key to move left, while binding keys
+}
+
+// NewPicker returns a picker of the profiles, with the last profile
+// played selected.
+func NewPicker(ps *Profiles) *Picker {
+	p := &Picker{Profiles: ps}
+	for i, pr := range ps.Profiles {
+		if pr.Name == ps.Last {
+			p.Selected = i
+		}
+	}
+	return p
+}
+
+// Current returns the selected profile, or nil if there are none.
+func (p *Picker) Current() *Profile {
+	if p.Selected >= len(p.Profiles.Profiles) {
+		return nil
+	}
+	return p.Profiles.Profiles[p.Selected]
+}
+
+// Select selects the given profile, if it's one of the profiles.
+func (p *Picker) Select(pr *Profile) {
+	for i, cur := range p.Profiles.Profiles {
+		if cur == pr {
+			p.Selected = i
+		}
+	}
+}
+
+// Key handles a key pressed, and returns true when the player picks
+// the selected profile.
+func (p *Picker) Key(key string) (picked bool) {
+	p.Message = ""
+	switch p.Mode {
+	case Naming:
+		p.nameKey(key)
+	case BindLeft, BindRight:
+		p.bindKey(key)
+	default:
+		return p.browseKey(key)
+	}
+	return false
+}
+
+// Type types text into the name of a new profile.
+func (p *Picker) Type(text string) {
+	if p.Mode != Naming {
+		return
+	}
+	for _, c := range text {
+		if len(p.Name) < highscore.MaxName {
+			p.Name = append(p.Name, c)
+		}
+	}
+}
+
+// Help returns the keys of the current mode, in the locale of the
+// game.
+func (p *Picker) Help() string {
+	switch p.Mode {
+	case Naming:
+		return locale.T("Type your name and press Enter")
+	case BindLeft:
+		return locale.T("Press the key to move left")
+	case BindRight:
+		return locale.T("Press the key to move right")
+	}
+	if len(p.Profiles.Profiles) == 0 {
+		return locale.T("Press Enter to add a player")
+	}
+	return locale.T("Enter to play, N new player, C color, D difficulty, B keys")
+}
+
+func (p *Picker) browseKey(key string) bool {
+	cur := p.Current()
+	switch key {
+	case "Up":
+		if p.Selected > 0 {
+			p.Selected--
+		}
+	case "Down":
+		if p.Selected < len(p.Profiles.Profiles)-1 {
+			p.Selected++
+		}
+	case "Return", "Space":
+		if cur == nil {
+			p.startNaming()
+			return false
+		}
+		p.Profiles.Last = cur.Name
+		p.Changed = true
+		return true
+	case "N":
+		p.startNaming()
+	case "C":
+		if cur != nil {
+			cur.Color = next(colorNames(), cur.Color)
+			p.Changed = true
+		}
+	case "D":
+		if cur != nil {
+			cur.Difficulty = next(difficulty.Names(), cur.Difficulty)
+			p.Changed = true
+		}
+	case "B":
+		if cur != nil {
+			p.Mode = BindLeft
+		}
+	}
+	return false
+}
+
+func (p *Picker) startNaming() {
+	if len(p.Profiles.Profiles) >= Max {
+		p.Message = locale.Sprintf("No room for more than %d players", Max)
+		return
+	}
+	p.Mode = Naming
+	p.Name = p.Name[:0]
+}
+
+func (p *Picker) nameKey(key string) {
+	switch key {
+	case "Backspace":
+		if len(p.Name) > 0 {
+			p.Name = p.Name[:len(p.Name)-1]
+		}
+	case "Escape":
+		p.Mode = Browse
+	case "Return":
+		if _, err := p.Profiles.Add(string(p.Name)); err != nil {
+			p.Message = err.Error()
+			return
+		}
+		p.Selected = len(p.Profiles.Profiles) - 1
+		p.Mode = Browse
+		p.Changed = true
+	}
+}
+
+func (p *Picker) bindKey(key string) {
+	switch {
+	case key == "Escape":
+		p.Mode = Browse
+	case has(Reserved, key):
+		p.Message = locale.Sprintf("%s is used by the game, try another key", key)
+	case p.Mode == BindLeft:
+		p.left = key
+		p.Mode = BindRight
+	case key == p.left:
+		p.Message = locale.Sprintf("%s moves left, try another key", key)
+	default:
+		p.Current().Bindings = Bindings{Left: []string{p.left}, Right: []string{key}}
+		p.Mode = Browse
+		p.Changed = true
+	}
+}
+
+// colorNames returns the names of the colors.
+func colorNames() []string {
+	names := make([]string, len(Colors))
+	for i, c := range Colors {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// next returns the item after cur in the list, in a loop.
+func next(list []string, cur string) string {
+	for i, v := range list {
+		if v == cur {
+			return list[(i+1)%len(list)]
+		}
+	}
+	return list[0]
+}
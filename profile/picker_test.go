@@ -0,0 +1,149 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+// profiles returns the profiles with the given names, and the last
+// one played.
+func profiles(last string, names ...string) *Profiles {
+	ps := &Profiles{Version: Version, Last: last}
+	for _, name := range names {
+		ps.Add(name)
+	}
+	return ps
+}
+
+func TestNewPicker(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ps   *Profiles
+		want string
+	}{
+		{"none", profiles(""), ""},
+		{"first", profiles("", "Tom", "Ana"), "Tom"},
+		{"last played", profiles("Ana", "Tom", "Ana"), "Ana"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if cur := NewPicker(tc.ps).Current(); cur != nil {
+				got = cur.Name
+			}
+			if got != tc.want {
+				t.Fatalf("got %q selected, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPickerBrowse(t *testing.T) {
+	p := NewPicker(profiles("", "Tom", "Ana"))
+	for _, tc := range []struct {
+		key    string
+		picked bool
+		want   string
+	}{
+		{"Up", false, "Tom"},
+		{"Down", false, "Ana"},
+		{"Down", false, "Ana"},
+		{"Return", true, "Ana"},
+	} {
+		if picked := p.Key(tc.key); picked != tc.picked || p.Current().Name != tc.want {
+			t.Fatalf("%s: got %q picked %v, want %q picked %v", tc.key, p.Current().Name, picked, tc.want, tc.picked)
+		}
+	}
+	if p.Profiles.Last != "Ana" || !p.Changed {
+		t.Fatalf("got last %q changed %v, want Ana changed", p.Profiles.Last, p.Changed)
+	}
+}
+
+func TestPickerSettings(t *testing.T) {
+	p := NewPicker(profiles("", "Tom"))
+	cur := p.Current()
+	color, diff := cur.Color, cur.Difficulty
+	p.Key("C")
+	p.Key("D")
+	if cur.Color == color || cur.Difficulty == diff || !p.Changed {
+		t.Fatalf("got color %q difficulty %q, want them changed", cur.Color, cur.Difficulty)
+	}
+	for range Colors {
+		p.Key("C")
+	}
+	if cur.Color != Colors[1].Name {
+		t.Fatalf("got color %q after a loop, want %q", cur.Color, Colors[1].Name)
+	}
+}
+
+func TestPickerNaming(t *testing.T) {
+	p := NewPicker(profiles("", "Tom"))
+	for _, tc := range []struct {
+		key, text string
+		mode      int
+		message   bool
+	}{
+		{"N", "", Naming, false},
+		{"", "Tomm", Naming, false},
+		{"Backspace", "", Naming, false},
+		{"Return", "", Naming, true}, // taken
+		{"Backspace", "by", Naming, false},
+		{"Return", "", Browse, false},
+	} {
+		if tc.key != "" {
+			p.Key(tc.key)
+		}
+		p.Type(tc.text)
+		if p.Mode != tc.mode || (p.Message != "") != tc.message {
+			t.Fatalf("%s %q: got mode %d message %q, want mode %d message %v", tc.key, tc.text, p.Mode, p.Message, tc.mode, tc.message)
+		}
+	}
+	if cur := p.Current(); cur.Name != "Toby" || len(p.Profiles.Profiles) != 2 {
+		t.Fatalf("got %q selected of %d, want the new Toby", cur.Name, len(p.Profiles.Profiles))
+	}
+}
+
+func TestPickerBind(t *testing.T) {
+	p := NewPicker(profiles("", "Tom"))
+	for _, tc := range []struct {
+		key     string
+		mode    int
+		message bool
+	}{
+		{"B", BindLeft, false},
+		{"Q", BindLeft, true}, // reserved
+		{"J", BindRight, false},
+		{"J", BindRight, true}, // moves left
+		{"L", Browse, false},
+	} {
+		p.Key(tc.key)
+		if p.Mode != tc.mode || (p.Message != "") != tc.message {
+			t.Fatalf("%s: got mode %d message %q, want mode %d message %v", tc.key, p.Mode, p.Message, tc.mode, tc.message)
+		}
+	}
+	want := Bindings{Left: []string{"J"}, Right: []string{"L"}}
+	if got := p.Current().Bindings; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got bindings %+v, want %+v", got, want)
+	}
+	p.Key("B")
+	p.Key("Escape")
+	if p.Mode != Browse || !reflect.DeepEqual(p.Current().Bindings, want) {
+		t.Fatalf("got mode %d bindings %+v after escape, want %+v", p.Mode, p.Current().Bindings, want)
+	}
+}
+
+func TestPickerSelect(t *testing.T) {
+	p := NewPicker(profiles("Tom", "Tom", "Ana"))
+	p.Select(p.Profiles.Profiles[1])
+	if p.Current().Name != "Ana" {
+		t.Fatalf("got %q selected, want Ana", p.Current().Name)
+	}
+	p.Select(New("Bob"))
+	if p.Current().Name != "Ana" {
+		t.Fatalf("got %q selected after a profile not listed, want Ana", p.Current().Name)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package profile provides the player profiles of the game, for the
+// players sharing the game on one machine, and the profile picker.
+// It is shared by the SDL and wasm versions of the game.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// File is the name of the profiles in the game's store.
+const File = "profiles.json"
+
+// Version is the version of the profiles format.
+const Version = 1
+
+// Max is the maximum number of profiles.
+const Max = 8
+
+// Color is a color of the cat, as a tint of the cat images. SDL tints
+// with the RGB color modulation, and the browser with a CSS filter.
+type Color struct {
+	Name    string
+	R, G, B uint8
+	Filter  string
+}
+
+// Colors are the colors of the cat, the first is the default.
+var Colors = []Color{
+	{"ginger", 255, 255, 255, "none"},
+	{"gray", 190, 190, 190, "grayscale(1)"},
+	{"pink", 255, 170, 210, "hue-rotate(300deg)"},
+	{"blue", 160, 190, 255, "hue-rotate(180deg)"},
+	{"black", 90, 90, 90, "brightness(.4)"},
+}
+
+// LookupColor returns the named color, or the default color.
+func LookupColor(name string) Color {
+	for _, c := range Colors {
+		if c.Name == name {
+			return c
+		}
+	}
+	return Colors[0]
+}
+
+// Bindings are the keys that move the cat, by key name, e.g. "Left"
+// or "A". Key names are the SDL key names.
+type Bindings struct {
+	Left  []string `json:"left"`
+	Right []string `json:"right"`
+}
+
+// DefaultBindings are the arrows, and A and D.
+var DefaultBindings = Bindings{
+	Left:  []string{"Left", "A"},
+	Right: []string{"Right", "D"},
+}
+
+// Reserved are the keys that can't be bound, because they control
+// the game.
+var Reserved = []string{
+	"Return", "Escape", "Space", "Backspace",
+	"Q", "F", "M", "H", "P", "S", "E", "G", "-", "=",
+}
+
+// Direction returns -1 if the key moves the cat left, 1 if right, or
+// 0 if the key is not bound.
+func (b Bindings) Direction(key string) int {
+	switch {
+	case has(b.Left, key):
+		return -1
+	case has(b.Right, key):
+		return 1
+	}
+	return 0
+}
+
+// Profile is a player's profile.
+type Profile struct {
+	Name       string    `json:"name"`
+	Color      string    `json:"color"`
+	Difficulty string    `json:"difficulty"`
+	Bindings   Bindings  `json:"bindings"`
+	Created    time.Time `json:"created"`
+}
+
+// New returns a new profile with the given name, and the defaults.
+func New(name string) *Profile {
+	return &Profile{
+		Name:       highscore.CleanName(name),
+		Color:      Colors[0].Name,
+		Difficulty: difficulty.Default,
+		Bindings:   DefaultBindings,
+		Created:    time.Now(),
+	}
+}
+
+// fix replaces the invalid settings of the profile by the defaults.
+func (p *Profile) fix() {
+	p.Name = highscore.CleanName(p.Name)
+	p.Color = LookupColor(p.Color).Name
+	if _, err := difficulty.Lookup(p.Difficulty); err != nil {
+		p.Difficulty = difficulty.Default
+	}
+	if len(p.Bindings.Left) == 0 || len(p.Bindings.Right) == 0 {
+		p.Bindings = DefaultBindings
+	}
+}
+
+// Profiles is the list of profiles, and the last one played.
+type Profiles struct {
+	Version  int        `json:"version"`
+	Last     string     `json:"last"`
+	Profiles []*Profile `json:"profiles"`
+}
+
+// Load loads the profiles from the store. Missing profiles are an
+// empty list. Profiles that fail to load are kept in the store as
+// File.bad for inspection, and replaced by an empty list returned
+// with an error, so the game can go on. Invalid settings of the
+// profiles are replaced by the defaults.
+func Load(st store.Store) (*Profiles, error) {
+	ps := &Profiles{Version: Version}
+	b, err := st.Load(File)
+	switch {
+	case errors.Is(err, store.ErrNotExist):
+		return ps, nil
+	case err != nil:
+		return ps, err
+	}
+	err = json.Unmarshal(b, ps)
+	if err == nil && ps.Version > Version {
+		err = fmt.Errorf("unsupported version %d", ps.Version)
+	}
+	if err != nil {
+		st.Save(File+".bad", b)
+		return &Profiles{Version: Version}, fmt.Errorf("profiles: %v", err)
+	}
+	// drop the empty and duplicate profiles
+	ok := &Profiles{Version: Version, Last: ps.Last}
+	for _, p := range ps.Profiles {
+		if p == nil {
+			continue
+		}
+		p.fix()
+		if ok.Find(p.Name) == nil && len(ok.Profiles) < Max {
+			ok.Profiles = append(ok.Profiles, p)
+		}
+	}
+	return ok, nil
+}
+
+// Save saves the profiles to the store.
+func (ps *Profiles) Save(st store.Store) error {
+	b, err := json.MarshalIndent(ps, "", "\t")
+	if err != nil {
+		return err
+	}
+	return st.Save(File, b)
+}
+
+// Find returns the named profile, or nil. Names are case insensitive.
+func (ps *Profiles) Find(name string) *Profile {
+	for _, p := range ps.Profiles {
+		if p != nil && strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Add adds a new profile with the given name. Errors are for the
+// players, in the locale of the game.
+func (ps *Profiles) Add(name string) (*Profile, error) {
+	p := New(name)
+	switch {
+	case len(ps.Profiles) >= Max:
+		return nil, errors.New(locale.Sprintf("No room for more than %d players", Max))
+	case ps.Find(p.Name) != nil:
+		return nil, errors.New(locale.Sprintf("%s is taken", p.Name))
+	}
+	ps.Profiles = append(ps.Profiles, p)
+	return p, nil
+}
+
+// has returns true if the list has the key.
+func has(list []string, key string) bool {
+	for _, k := range list {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
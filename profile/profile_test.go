@@ -0,0 +1,158 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package profile
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		data  string // saved profiles, empty for none
+		names []string
+		err   bool
+		bad   bool // kept as File.bad
+	}{
+		{"missing", "", nil, false, false},
+		{"saved", `{"version": 1, "last": "Tom", "profiles": [{"name": "Tom"}, {"name": "Ana"}]}`, []string{"Tom", "Ana"}, false, false},
+		{"duplicates", `{"profiles": [{"name": "Tom"}, null, {"name": "tom"}]}`, []string{"Tom"}, false, false},
+		{"corrupt", `{"profiles": [`, nil, true, true},
+		{"newer version", `{"version": 2}`, nil, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := store.Dir(t.TempDir())
+			if tc.data != "" {
+				st.Save(File, []byte(tc.data))
+			}
+			ps, err := Load(st)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			var names []string
+			for _, p := range ps.Profiles {
+				names = append(names, p.Name)
+			}
+			if ps.Version != Version || !reflect.DeepEqual(names, tc.names) {
+				t.Fatalf("got profiles %v, want %v", names, tc.names)
+			}
+			bad, err := st.Load(File + ".bad")
+			if tc.bad && string(bad) != tc.data {
+				t.Fatalf("got %q kept as bad, want %q", bad, tc.data)
+			}
+			if !tc.bad && err != store.ErrNotExist {
+				t.Fatalf("got %q kept as bad, want none", bad)
+			}
+		})
+	}
+}
+
+func TestLoadFixes(t *testing.T) {
+	st := store.Dir(t.TempDir())
+	st.Save(File, []byte(`{"profiles": [{"name": " Tom ", "color": "plaid", "difficulty": "nightmare", "bindings": {"left": ["J"]}}]}`))
+	ps, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Profile{
+		Name:       "Tom",
+		Color:      Colors[0].Name,
+		Difficulty: difficulty.Default,
+		Bindings:   DefaultBindings,
+	}
+	if !reflect.DeepEqual(ps.Profiles[0], want) {
+		t.Fatalf("got %+v, want %+v", ps.Profiles[0], want)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	st := store.Dir(t.TempDir())
+	ps := &Profiles{Version: Version, Last: "Ana"}
+	for _, name := range []string{"Tom", "Ana"} {
+		if _, err := ps.Add(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ps.Profiles[1].Color = "pink"
+	ps.Profiles[1].Bindings = Bindings{Left: []string{"J"}, Right: []string{"L"}}
+	if err := ps.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Last != "Ana" || len(got.Profiles) != 2 {
+		t.Fatalf("got %+v, want %+v", got, ps)
+	}
+	for i, p := range got.Profiles {
+		if p.Name != ps.Profiles[i].Name || p.Color != ps.Profiles[i].Color ||
+			!reflect.DeepEqual(p.Bindings, ps.Profiles[i].Bindings) ||
+			!p.Created.Equal(ps.Profiles[i].Created) {
+			t.Fatalf("got %+v, want %+v", p, ps.Profiles[i])
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ps := &Profiles{}
+	for _, tc := range []struct {
+		name string
+		ok   bool
+	}{
+		{"Tom", true},
+		{"TOM", false},
+		{"Ana", true},
+	} {
+		if _, err := ps.Add(tc.name); (err == nil) != tc.ok {
+			t.Fatalf("%s: got error %v, want ok %v", tc.name, err, tc.ok)
+		}
+	}
+	for i := len(ps.Profiles); i < Max; i++ {
+		if _, err := ps.Add(fmt.Sprintf("P%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ps.Add("Extra"); err == nil {
+		t.Fatalf("got no error with %d profiles, want no room", Max)
+	}
+}
+
+func TestDirection(t *testing.T) {
+	for _, tc := range []struct {
+		key  string
+		want int
+	}{
+		{"Left", -1},
+		{"a", -1},
+		{"Right", 1},
+		{"D", 1},
+		{"Up", 0},
+	} {
+		if got := DefaultBindings.Direction(tc.key); got != tc.want {
+			t.Fatalf("got direction %d for %q, want %d", got, tc.key, tc.want)
+		}
+	}
+}
+
+func TestLookupColor(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+	}{
+		{"pink", "pink"},
+		{"plaid", Colors[0].Name},
+		{"", Colors[0].Name},
+	} {
+		if got := LookupColor(tc.name).Name; got != tc.want {
+			t.Fatalf("got color %q for %q, want %q", got, tc.name, tc.want)
+		}
+	}
+}
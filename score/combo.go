@@ -0,0 +1,67 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package score provides the scoring rules of the game, shared by
+// the SDL and wasm versions.
+package score
+
+// Combo settings.
+const (
+	// ComboStep is the number of consecutive good catches that
+	// increase the multiplier by one.
+	ComboStep = 5
+
+	// MaxMultiplier is the maximum combo multiplier.
+	MaxMultiplier = 5
+
+	// MinCombo is the minimum number of consecutive good catches
+	// displayed as a combo, and whose break is notified.
+	MinCombo = 3
+)
+
+// Combo tracks consecutive good catches, the combo, that increase
+// the multiplier of points. Bad catches and missed good drops reset
+// the combo.
+type Combo struct {
+	count int
+	best  int
+}
+
+// Catch records a good catch.
+func (c *Combo) Catch() {
+	c.count++
+	if c.count > c.best {
+		c.best = c.count
+	}
+}
+
+// Break resets the combo, and returns the number of consecutive
+// catches lost if the combo was at least MinCombo long.
+func (c *Combo) Break() (lost int) {
+	lost, c.count = c.count, 0
+	if lost < MinCombo {
+		return 0
+	}
+	return lost
+}
+
+// Count returns the number of consecutive good catches.
+func (c *Combo) Count() int {
+	return c.count
+}
+
+// Best returns the longest combo so far.
+func (c *Combo) Best() int {
+	return c.best
+}
+
+// Multiplier returns the current multiplier of points.
+func (c *Combo) Multiplier() int64 {
+	m := 1 + c.count/ComboStep
+	if m > MaxMultiplier {
+		m = MaxMultiplier
+	}
+	return int64(m)
+}
@@ -0,0 +1,72 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package score
+
+import "testing"
+
+func TestComboMultiplier(t *testing.T) {
+	for _, tc := range []struct {
+		catches int
+		want    int64
+	}{
+		{0, 1},
+		{ComboStep - 1, 1},
+		{ComboStep, 2},
+		{2*ComboStep + 1, 3},
+		{(MaxMultiplier - 1) * ComboStep, MaxMultiplier},
+		{10 * ComboStep, MaxMultiplier},
+	} {
+		var c Combo
+		for range tc.catches {
+			c.Catch()
+		}
+		if m := c.Multiplier(); m != tc.want {
+			t.Fatalf("got multiplier %d after %d catches, want %d", m, tc.catches, tc.want)
+		}
+	}
+}
+
+func TestComboBreak(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		catches int
+		lost    int
+	}{
+		{"none", 0, 0},
+		{"short", MinCombo - 1, 0},
+		{"combo", MinCombo, MinCombo},
+		{"long", 12, 12},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var c Combo
+			for range tc.catches {
+				c.Catch()
+			}
+			if lost := c.Break(); lost != tc.lost {
+				t.Fatalf("got %d lost, want %d", lost, tc.lost)
+			}
+			if c.Count() != 0 || c.Multiplier() != 1 {
+				t.Fatalf("got count %d and multiplier %d after the break, want 0 and 1", c.Count(), c.Multiplier())
+			}
+			if c.Best() != tc.catches {
+				t.Fatalf("got best %d, want %d", c.Best(), tc.catches)
+			}
+		})
+	}
+}
+
+func TestComboBest(t *testing.T) {
+	var c Combo
+	for _, n := range []int{4, 7, 2} {
+		for range n {
+			c.Catch()
+		}
+		c.Break()
+	}
+	if c.Best() != 7 {
+		t.Fatalf("got best %d, want 7", c.Best())
+	}
+}
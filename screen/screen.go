@@ -0,0 +1,121 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package screen provides the scene manager of the game: a stack of
+// screens, such as the title, the game itself and the pause overlay,
+// of which the one on top gets the input. Screens are told when they
+// enter and exit the stack, and when they're covered by a screen
+// pushed on top of them and uncovered again. Screens are defined by
+// the SDL and wasm versions of the game, which handle their input and
+// draw them.
+package screen
+
+// Screen is a screen of the game, as seen by the stack.
+type Screen interface {
+	// Enter is called when the screen is put on the stack.
+	Enter()
+
+	// Exit is called when the screen is taken off the stack.
+	Exit()
+
+	// Pause is called when a screen is pushed on top of the
+	// screen.
+	Pause()
+
+	// Resume is called when the screen is on top again, after the
+	// screen on top of it is taken off.
+	Resume()
+
+	// Overlay returns true if the screens under the screen show
+	// through it, e.g. the game under the pause overlay.
+	Overlay() bool
+}
+
+// Base is a screen with no-op lifecycle and no overlay, for screens
+// to embed.
+type Base struct{}
+
+// Enter implements the Screen interface.
+func (Base) Enter() {}
+
+// Exit implements the Screen interface.
+func (Base) Exit() {}
+
+// Pause implements the Screen interface.
+func (Base) Pause() {}
+
+// Resume implements the Screen interface.
+func (Base) Resume() {}
+
+// Overlay implements the Screen interface.
+func (Base) Overlay() bool { return false }
+
+// Stack is a stack of screens. The zero value is an empty stack.
+type Stack[S Screen] struct {
+	screens []S
+}
+
+// Push pushes s on top of the stack, pausing the screen on top.
+func (st *Stack[S]) Push(s S) {
+	if top, ok := st.Top(); ok {
+		top.Pause()
+	}
+	st.screens = append(st.screens, s)
+	s.Enter()
+}
+
+// Pop takes the screen on top off the stack, and resumes the screen
+// under it, if any.
+func (st *Stack[S]) Pop() {
+	top, ok := st.Top()
+	if !ok {
+		return
+	}
+	st.screens = st.screens[:len(st.screens)-1]
+	top.Exit()
+	if top, ok = st.Top(); ok {
+		top.Resume()
+	}
+}
+
+// Replace replaces the screen on top of the stack with s, or pushes s
+// if the stack is empty. The screen under it stays paused.
+func (st *Stack[S]) Replace(s S) {
+	top, ok := st.Top()
+	if !ok {
+		st.Push(s)
+		return
+	}
+	st.screens[len(st.screens)-1] = s
+	top.Exit()
+	s.Enter()
+}
+
+// Top returns the screen on top of the stack, if any.
+func (st *Stack[S]) Top() (S, bool) {
+	if len(st.screens) == 0 {
+		var s S
+		return s, false
+	}
+	return st.screens[len(st.screens)-1], true
+}
+
+// Len returns the number of screens in the stack.
+func (st *Stack[S]) Len() int {
+	return len(st.screens)
+}
+
+// Visible returns the screens to draw, from the bottom up: the screen
+// on top, and the screens under it that show through overlays.
+func (st *Stack[S]) Visible() []S {
+	i := len(st.screens) - 1
+	for i > 0 && st.screens[i].Overlay() {
+		i--
+	}
+	if i < 0 {
+		return nil
+	}
+	return st.screens[i:]
+}
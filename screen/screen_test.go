@@ -0,0 +1,89 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package screen
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testScreen is a screen that logs its lifecycle.
+type testScreen struct {
+	name    string
+	overlay bool
+	log     *[]string
+}
+
+func (s *testScreen) Enter()        { *s.log = append(*s.log, s.name+" enter") }
+func (s *testScreen) Exit()         { *s.log = append(*s.log, s.name+" exit") }
+func (s *testScreen) Pause()        { *s.log = append(*s.log, s.name+" pause") }
+func (s *testScreen) Resume()       { *s.log = append(*s.log, s.name+" resume") }
+func (s *testScreen) Overlay() bool { return s.overlay }
+
+func TestStackLifecycle(t *testing.T) {
+	var log []string
+	screens := make(map[string]*testScreen)
+	for _, name := range []string{"title", "play", "pause", "over"} {
+		screens[name] = &testScreen{name: name, log: &log}
+	}
+	var st Stack[*testScreen]
+	for _, tc := range []struct {
+		name string
+		do   func()
+		log  []string
+		top  string // empty for none
+		len  int
+	}{
+		{"pop empty", st.Pop, nil, "", 0},
+		{"push", func() { st.Push(screens["title"]) }, []string{"title enter"}, "title", 1},
+		{"push on top", func() { st.Push(screens["play"]) }, []string{"title pause", "play enter"}, "play", 2},
+		{"push again", func() { st.Push(screens["pause"]) }, []string{"play pause", "pause enter"}, "pause", 3},
+		{"pop", st.Pop, []string{"pause exit", "play resume"}, "play", 2},
+		{"replace", func() { st.Replace(screens["over"]) }, []string{"play exit", "over enter"}, "over", 2},
+		{"pop to bottom", st.Pop, []string{"over exit", "title resume"}, "title", 1},
+		{"pop last", st.Pop, []string{"title exit"}, "", 0},
+		{"replace empty", func() { st.Replace(screens["title"]) }, []string{"title enter"}, "title", 1},
+	} {
+		log = nil
+		tc.do()
+		if !reflect.DeepEqual(log, tc.log) {
+			t.Fatalf("%s: got %q, want %q", tc.name, log, tc.log)
+		}
+		top, ok := st.Top()
+		if ok != (tc.top != "") || ok && top.name != tc.top || st.Len() != tc.len {
+			t.Fatalf("%s: got top %v and %d screens, want %q and %d", tc.name, top, st.Len(), tc.top, tc.len)
+		}
+	}
+}
+
+func TestStackVisible(t *testing.T) {
+	var log []string
+	for _, tc := range []struct {
+		name    string
+		overlay []bool // of the screens, from the bottom up
+		visible int    // number of screens visible, from the top
+	}{
+		{"empty", nil, 0},
+		{"one", []bool{false}, 1},
+		{"covered", []bool{false, false}, 1},
+		{"overlay", []bool{false, true}, 2},
+		{"overlays", []bool{false, true, true}, 3},
+		{"overlay covered", []bool{false, true, false}, 1},
+		{"overlay at the bottom", []bool{true}, 1},
+		{"overlay on an overlay at the bottom", []bool{true, true}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var st Stack[*testScreen]
+			for _, o := range tc.overlay {
+				st.Push(&testScreen{overlay: o, log: &log})
+			}
+			want := st.screens[len(st.screens)-tc.visible:]
+			if got := st.Visible(); len(got) != len(want) || len(got) > 0 && got[0] != want[0] {
+				t.Fatalf("got %d screens visible, want %d", len(got), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package settings provides the player's settings of the game, kept
+// between runs. It is shared by the SDL and wasm versions of the game.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// File is the name of the settings in the game's store.
+const File = "settings.json"
+
+// Version is the version of the settings format.
+const Version = 1
+
+// VolumeStep is how much the volume changes per key press.
+const VolumeStep = .1
+
+// Audio is the audio settings. Volumes go from 0 to 1, and the music
+// and sound effects volumes are relative to the master volume.
+type Audio struct {
+	Master float64 `json:"master"`
+	Music  float64 `json:"music"`
+	SFX    float64 `json:"sfx"`
+	Muted  bool    `json:"muted"`
+}
+
+// MusicVolume returns the volume the music plays at.
+func (a Audio) MusicVolume() float64 {
+	if a.Muted {
+		return 0
+	}
+	return a.Master * a.Music
+}
+
+// SFXVolume returns the volume the sound effects play at.
+func (a Audio) SFXVolume() float64 {
+	if a.Muted {
+		return 0
+	}
+	return a.Master * a.SFX
+}
+
+// Step changes the master volume by the given number of steps, and
+// unmutes the audio.
+func (a *Audio) Step(n int) {
+	// round to the step, so that volumes don't drift
+	v := (math.Round(a.Master/VolumeStep) + float64(n)) / (1 / VolumeStep)
+	a.Master = clamp(v)
+	a.Muted = false
+}
+
+// Validate returns an error if any of the volumes is out of range.
+func (a Audio) Validate() error {
+	for _, v := range []float64{a.Master, a.Music, a.SFX} {
+		if v < 0 || v > 1 {
+			return fmt.Errorf("volume %v out of range", v)
+		}
+	}
+	return nil
+}
+
+// Resolution is a size of the game window.
+type Resolution struct {
+	Width, Height int
+}
+
+// String returns the resolution as width x height, e.g. "800x600".
+func (r Resolution) String() string {
+	return fmt.Sprintf("%dx%d", r.Width, r.Height)
+}
+
+// Resolutions are the sizes of the game window to choose from.
+var Resolutions = []Resolution{
+	{800, 600},
+	{1024, 768},
+	{1280, 720},
+	{1280, 960},
+	{1600, 900},
+	{1920, 1080},
+}
+
+// FPSCaps are the frame rates to choose from.
+var FPSCaps = []int{30, 60, 120}
+
+// Limits of the video settings.
+const (
+	MinWidth  = 320
+	MinHeight = 240
+	MinFPS    = 10
+	MaxFPS    = 240
+)
+
+// Video is the video settings. The wasm version of the game is sized
+// by the page, and drawn by the browser, so it doesn't use them.
+type Video struct {
+	Width      int  `json:"width"`
+	Height     int  `json:"height"`
+	Fullscreen bool `json:"fullscreen"`
+	FPS        int  `json:"fps"`
+}
+
+// Validate returns an error if the window is too small, or the frame
+// rate out of range.
+func (v Video) Validate() error {
+	if v.Width < MinWidth || v.Height < MinHeight {
+		return fmt.Errorf("window %dx%d too small", v.Width, v.Height)
+	}
+	if v.FPS < MinFPS || v.FPS > MaxFPS {
+		return fmt.Errorf("fps %d out of range", v.FPS)
+	}
+	return nil
+}
+
+// Player speeds, in pixels per key press.
+const (
+	MinPlayerSpeed = 5
+	MaxPlayerSpeed = 50
+)
+
+// Gameplay is the gameplay settings.
+type Gameplay struct {
+	// PlayerSpeed is the speed of the player's lateral movement.
+	PlayerSpeed int `json:"player_speed"`
+
+	// Difficulty is the name of the difficulty preset. Empty uses
+	// the difficulty of the player's profile.
+	Difficulty string `json:"difficulty"`
+
+	// Adaptive enables the adaptive difficulty.
+	Adaptive bool `json:"adaptive"`
+}
+
+// Validate returns an error if the player speed is out of range, or
+// the difficulty unknown.
+func (g Gameplay) Validate() error {
+	if g.PlayerSpeed < MinPlayerSpeed || g.PlayerSpeed > MaxPlayerSpeed {
+		return fmt.Errorf("player speed %d out of range", g.PlayerSpeed)
+	}
+	if g.Difficulty == "" {
+		return nil
+	}
+	_, err := difficulty.Lookup(g.Difficulty)
+	return err
+}
+
+// Accessibility is the accessibility settings.
+type Accessibility struct {
+	// AudioCues plays sounds for the drops approaching the player,
+	// panned to where they fall.
+	AudioCues bool `json:"audio_cues"`
+
+	// ReduceMotion turns off the camera shake on bad catches.
+	ReduceMotion bool `json:"reduce_motion"`
+}
+
+// Settings is the player's settings.
+type Settings struct {
+	Version       int           `json:"version"`
+	Audio         Audio         `json:"audio"`
+	Video         Video         `json:"video"`
+	Gameplay      Gameplay      `json:"gameplay"`
+	Accessibility Accessibility `json:"accessibility"`
+}
+
+// Default returns the default settings.
+func Default() *Settings {
+	return &Settings{
+		Version:  Version,
+		Audio:    Audio{Master: 1, Music: .8, SFX: 1},
+		Video:    Video{Width: 800, Height: 600, FPS: 30},
+		Gameplay: Gameplay{PlayerSpeed: 20},
+	}
+}
+
+// Load loads the settings from the store. Missing settings are the
+// default settings. Invalid settings are replaced by the default
+// settings, and returned with an error, so the game can go on.
+func Load(st store.Store) (*Settings, error) {
+	b, err := st.Load(File)
+	switch {
+	case errors.Is(err, store.ErrNotExist):
+		return Default(), nil
+	case err != nil:
+		return Default(), err
+	}
+	s := Default()
+	if err = json.Unmarshal(b, s); err != nil {
+		return Default(), fmt.Errorf("settings: %v", err)
+	}
+	if s.Version > Version {
+		return Default(), fmt.Errorf("settings: unsupported version %d", s.Version)
+	}
+	if err = s.Validate(); err != nil {
+		return Default(), fmt.Errorf("settings: %v", err)
+	}
+	s.Version = Version
+	return s, nil
+}
+
+// Validate returns an error if any of the settings is invalid.
+func (s *Settings) Validate() error {
+	if err := s.Audio.Validate(); err != nil {
+		return fmt.Errorf("audio: %v", err)
+	}
+	if err := s.Video.Validate(); err != nil {
+		return fmt.Errorf("video: %v", err)
+	}
+	if err := s.Gameplay.Validate(); err != nil {
+		return fmt.Errorf("gameplay: %v", err)
+	}
+	return nil
+}
+
+// Clamp clamps the volumes, the window size, the frame rate and the
+// player speed to their ranges, e.g. for settings given in the command
+// line.
+func (s *Settings) Clamp() {
+	s.Audio.Master = clamp(s.Audio.Master)
+	s.Audio.Music = clamp(s.Audio.Music)
+	s.Audio.SFX = clamp(s.Audio.SFX)
+	s.Video.Width = max(s.Video.Width, MinWidth)
+	s.Video.Height = max(s.Video.Height, MinHeight)
+	s.Video.FPS = min(max(s.Video.FPS, MinFPS), MaxFPS)
+	s.Gameplay.PlayerSpeed = min(max(s.Gameplay.PlayerSpeed, MinPlayerSpeed), MaxPlayerSpeed)
+}
+
+// Merge sets the settings of s that changed from old to cur, and
+// leaves the others as they are. The game plays with the settings
+// saved overridden for the run, e.g. by the command line, and merges
+// the changes of the player into the settings saved, so they don't
+// take the overrides.
+func (s *Settings) Merge(old, cur Settings) {
+	merge(&s.Audio, old.Audio, cur.Audio)
+	merge(&s.Video.Width, old.Video.Width, cur.Video.Width)
+	merge(&s.Video.Height, old.Video.Height, cur.Video.Height)
+	merge(&s.Video.Fullscreen, old.Video.Fullscreen, cur.Video.Fullscreen)
+	merge(&s.Video.FPS, old.Video.FPS, cur.Video.FPS)
+	merge(&s.Gameplay.PlayerSpeed, old.Gameplay.PlayerSpeed, cur.Gameplay.PlayerSpeed)
+	merge(&s.Gameplay.Difficulty, old.Gameplay.Difficulty, cur.Gameplay.Difficulty)
+	merge(&s.Gameplay.Adaptive, old.Gameplay.Adaptive, cur.Gameplay.Adaptive)
+	merge(&s.Accessibility.AudioCues, old.Accessibility.AudioCues, cur.Accessibility.AudioCues)
+	merge(&s.Accessibility.ReduceMotion, old.Accessibility.ReduceMotion, cur.Accessibility.ReduceMotion)
+}
+
+// merge sets v to cur if it changed from old.
+func merge[T comparable](v *T, old, cur T) {
+	if cur != old {
+		*v = cur
+	}
+}
+
+// Save saves the settings to the store.
+func (s *Settings) Save(st store.Store) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	return st.Save(File, b)
+}
+
+// clamp returns v clamped to the 0 to 1 range.
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
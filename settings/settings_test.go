@@ -0,0 +1,222 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package settings
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+func TestAudio(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		audio      Audio
+		music, sfx float64
+	}{
+		{"default", Default().Audio, .8, 1},
+		{"half", Audio{Master: .5, Music: .8, SFX: 1}, .4, .5},
+		{"muted", Audio{Master: 1, Music: .8, SFX: 1, Muted: true}, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := tc.audio.MusicVolume(); math.Abs(v-tc.music) > 1e-9 {
+				t.Fatalf("got music volume %v, want %v", v, tc.music)
+			}
+			if v := tc.audio.SFXVolume(); math.Abs(v-tc.sfx) > 1e-9 {
+				t.Fatalf("got sfx volume %v, want %v", v, tc.sfx)
+			}
+		})
+	}
+}
+
+func TestAudioStep(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		master float64
+		muted  bool
+		steps  []int
+		want   float64
+	}{
+		{"down", 1, false, []int{-1, -1, -1}, .7},
+		{"up", .5, false, []int{1}, .6},
+		{"no drift", .33, false, []int{1, -1}, .3},
+		{"floor", .1, false, []int{-5}, 0},
+		{"ceiling", .9, false, []int{5}, 1},
+		{"unmute", .5, true, []int{0}, .5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Audio{Master: tc.master, Muted: tc.muted}
+			for _, n := range tc.steps {
+				a.Step(n)
+			}
+			if math.Abs(a.Master-tc.want) > 1e-9 || a.Muted {
+				t.Fatalf("got master %v muted %v, want %v unmuted", a.Master, a.Muted, tc.want)
+			}
+		})
+	}
+}
+
+// failStore is a store that fails to load.
+type failStore struct{ store.Mem }
+
+func (failStore) Load(name string) ([]byte, error) {
+	return nil, errors.New("disk on fire")
+}
+
+func TestLoad(t *testing.T) {
+	custom := Default()
+	custom.Audio.Master = .5
+	custom.Video.FPS = 60
+	custom.Gameplay.PlayerSpeed = 30
+	partial := Default()
+	partial.Audio.Muted = true
+	for _, tc := range []struct {
+		name string
+		data string // saved settings, empty for none
+		want *Settings
+		err  bool
+	}{
+		{"missing", "", Default(), false},
+		{"saved", `{"version": 1, "audio": {"master": 0.5, "music": 0.8, "sfx": 1}, "video": {"width": 800, "height": 600, "fps": 60}, "gameplay": {"player_speed": 30}}`, custom, false},
+		{"partial", `{"audio": {"master": 1, "music": 0.8, "sfx": 1, "muted": true}}`, partial, false},
+		{"corrupt", `{"audio": `, Default(), true},
+		{"newer version", `{"version": 2}`, Default(), true},
+		{"invalid volume", `{"audio": {"master": 2}}`, Default(), true},
+		{"invalid fps", `{"video": {"width": 800, "height": 600, "fps": 1}}`, Default(), true},
+		{"invalid speed", `{"gameplay": {"player_speed": 500}}`, Default(), true},
+		{"unknown difficulty", `{"gameplay": {"player_speed": 20, "difficulty": "nightmare"}}`, Default(), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := store.Mem{}
+			if tc.data != "" {
+				st.Save(File, []byte(tc.data))
+			}
+			s, err := Load(st)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			if !reflect.DeepEqual(s, tc.want) {
+				t.Fatalf("got %+v, want %+v", s, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadStoreError(t *testing.T) {
+	s, err := Load(failStore{})
+	if err == nil {
+		t.Fatal("got no error, want the store error")
+	}
+	if !reflect.DeepEqual(s, Default()) {
+		t.Fatalf("got %+v, want the defaults", s)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	st := store.Mem{}
+	want := Default()
+	want.Audio.Step(-3)
+	want.Video.Fullscreen = true
+	want.Accessibility.ReduceMotion = true
+	if err := want.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		edit func(s *Settings)
+		err  bool
+	}{
+		{"default", func(s *Settings) {}, false},
+		{"muted", func(s *Settings) { s.Audio = Audio{Muted: true} }, false},
+		{"volume too low", func(s *Settings) { s.Audio.Music = -.1 }, true},
+		{"volume too high", func(s *Settings) { s.Audio.SFX = 1.1 }, true},
+		{"smallest window", func(s *Settings) { s.Video.Width, s.Video.Height = MinWidth, MinHeight }, false},
+		{"window too narrow", func(s *Settings) { s.Video.Width = MinWidth - 1 }, true},
+		{"window too short", func(s *Settings) { s.Video.Height = MinHeight - 1 }, true},
+		{"fps not a cap", func(s *Settings) { s.Video.FPS = 45 }, false},
+		{"fps too low", func(s *Settings) { s.Video.FPS = MinFPS - 1 }, true},
+		{"fps too high", func(s *Settings) { s.Video.FPS = MaxFPS + 1 }, true},
+		{"slowest", func(s *Settings) { s.Gameplay.PlayerSpeed = MinPlayerSpeed }, false},
+		{"too slow", func(s *Settings) { s.Gameplay.PlayerSpeed = MinPlayerSpeed - 1 }, true},
+		{"too fast", func(s *Settings) { s.Gameplay.PlayerSpeed = MaxPlayerSpeed + 1 }, true},
+		{"difficulty", func(s *Settings) { s.Gameplay.Difficulty = "hard" }, false},
+		{"unknown difficulty", func(s *Settings) { s.Gameplay.Difficulty = "nightmare" }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Default()
+			tc.edit(s)
+			if err := s.Validate(); (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestClamp(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		edit, want func(s *Settings)
+	}{
+		{"valid", func(s *Settings) { s.Video.FPS = 45 }, func(s *Settings) { s.Video.FPS = 45 }},
+		{"volumes", func(s *Settings) { s.Audio = Audio{Master: 2, Music: -1, SFX: .5} },
+			func(s *Settings) { s.Audio = Audio{Master: 1, Music: 0, SFX: .5} }},
+		{"window", func(s *Settings) { s.Video.Width, s.Video.Height = 100, 0 },
+			func(s *Settings) { s.Video.Width, s.Video.Height = MinWidth, MinHeight }},
+		{"slow fps", func(s *Settings) { s.Video.FPS = 5 }, func(s *Settings) { s.Video.FPS = MinFPS }},
+		{"no fps", func(s *Settings) { s.Video.FPS = 0 }, func(s *Settings) { s.Video.FPS = MinFPS }},
+		{"fast fps", func(s *Settings) { s.Video.FPS = 1000 }, func(s *Settings) { s.Video.FPS = MaxFPS }},
+		{"slow", func(s *Settings) { s.Gameplay.PlayerSpeed = 4 }, func(s *Settings) { s.Gameplay.PlayerSpeed = MinPlayerSpeed }},
+		{"fast", func(s *Settings) { s.Gameplay.PlayerSpeed = 99 }, func(s *Settings) { s.Gameplay.PlayerSpeed = MaxPlayerSpeed }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, want := Default(), Default()
+			tc.edit(got)
+			tc.want(want)
+			got.Clamp()
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v, want %+v", got, want)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("got error %v after clamping", err)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	saved := Default()
+	// in use: the settings saved, overridden for the run
+	old := *saved
+	old.Video.FPS = 10
+	old.Gameplay.PlayerSpeed = 40
+	old.Gameplay.Adaptive = true
+	// changed by the player
+	cur := old
+	cur.Audio.Master = .5
+	cur.Video.Fullscreen = true
+	cur.Gameplay.PlayerSpeed = 30
+	saved.Merge(old, cur)
+	want := Default()
+	want.Audio.Master = .5
+	want.Video.Fullscreen = true
+	want.Gameplay.PlayerSpeed = 30
+	if !reflect.DeepEqual(saved, want) {
+		t.Fatalf("got %+v, want %+v", saved, want)
+	}
+}
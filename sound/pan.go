@@ -0,0 +1,42 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package sound provides the sound helpers of the game, shared by the
+// SDL and wasm versions of the game, which play the sounds.
+package sound
+
+// PanWidth is how far sounds are panned to the sides, from 0 (always
+// centered) to 1 (all the way to the left or right speaker). Sounds
+// at the edges of the screen are still heard on both speakers.
+const PanWidth = .8
+
+// Pan returns the stereo position of x within the width w of the
+// screen, from -1 (left) to 1 (right).
+func Pan(x, w float64) float64 {
+	if w <= 0 {
+		return 0
+	}
+	p := (2*x/w - 1) * PanWidth
+	if p < -1 {
+		return -1
+	}
+	if p > 1 {
+		return 1
+	}
+	return p
+}
+
+// Gains returns the volumes of the left and right speakers, from 0
+// to 1, for the stereo position p. Centered sounds play at full
+// volume on both speakers.
+func Gains(p float64) (left, right float64) {
+	left, right = 1, 1
+	if p > 0 {
+		left -= p
+	} else {
+		right += p
+	}
+	return left, right
+}
@@ -0,0 +1,52 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sound
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPan(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		x, w float64
+		want float64
+	}{
+		{"center", 400, 800, 0},
+		{"left edge", 0, 800, -PanWidth},
+		{"right edge", 800, 800, PanWidth},
+		{"quarter", 200, 800, -PanWidth / 2},
+		{"off screen", -800, 800, -1},
+		{"no width", 100, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if p := Pan(tc.x, tc.w); math.Abs(p-tc.want) > 1e-9 {
+				t.Fatalf("got %v, want %v", p, tc.want)
+			}
+		})
+	}
+}
+
+func TestGains(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		p           float64
+		left, right float64
+	}{
+		{"center", 0, 1, 1},
+		{"left", -1, 1, 0},
+		{"right", 1, 0, 1},
+		{"half right", .5, .5, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			left, right := Gains(tc.p)
+			if left != tc.left || right != tc.right {
+				t.Fatalf("got %v, %v, want %v, %v", left, right, tc.left, tc.right)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sound
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// SampleRate is the sample rate of the synthesized sounds, in Hz.
+const SampleRate = 22050
+
+// Wave is the waveform of a synthesized sound.
+type Wave int
+
+// Waveforms.
+const (
+	Square Wave = iota
+	Triangle
+	Sine
+	Saw
+	Noise
+)
+
+// Preset is a retro sound effect: a wave that slides from one
+// frequency to another, optionally stepping through the notes of an
+// arpeggio, shaped by a volume envelope.
+type Preset struct {
+	Wave     Wave
+	Freq     float64       // start frequency, in Hz
+	Slide    float64       // end frequency, in Hz, or 0 to keep Freq
+	Notes    []float64     // arpeggio, as multipliers of the frequency
+	Duty     float64       // duty cycle of square waves, or 0 for .5
+	Duration time.Duration // total duration
+	Attack   time.Duration // fade in
+	Release  time.Duration // fade out, at the end
+	Volume   float64       // from 0 to 1
+}
+
+// Presets are the synthesized sounds of the game, by sound event.
+// They are played when a sample is missing, and for events that
+// don't have samples of their own.
+var Presets = map[string]Preset{
+	"win": {
+		Wave: Square, Freq: 660, Notes: []float64{1, 1.25, 1.5, 2},
+		Duty: .25, Duration: 200 * time.Millisecond,
+		Release: 60 * time.Millisecond, Volume: .5,
+	},
+	"lose": {
+		Wave: Noise, Freq: 1200, Slide: 200,
+		Duration: 300 * time.Millisecond,
+		Release:  100 * time.Millisecond, Volume: .5,
+	},
+	"combo": {
+		Wave: Square, Freq: 880, Notes: []float64{1, 1.5, 2},
+		Duration: 150 * time.Millisecond,
+		Release:  40 * time.Millisecond, Volume: .4,
+	},
+	"combo_break": {
+		Wave: Triangle, Freq: 600, Slide: 150, Notes: []float64{1, .75},
+		Duration: 350 * time.Millisecond,
+		Release:  150 * time.Millisecond, Volume: .6,
+	},
+	"powerup": {
+		Wave: Square, Freq: 440, Slide: 660, Notes: []float64{1, 1.25, 1.5, 2, 2.5, 3},
+		Duty: .125, Duration: 400 * time.Millisecond,
+		Release: 100 * time.Millisecond, Volume: .4,
+	},
+	"achievement": {
+		Wave: Triangle, Freq: 523, Notes: []float64{1, 1.25, 1.5, 2, 1.5, 2},
+		Duration: 600 * time.Millisecond,
+		Release:  200 * time.Millisecond, Volume: .6,
+	},
+	"cue_good": {
+		Wave: Sine, Freq: 880, Slide: 1320,
+		Duration: 90 * time.Millisecond,
+		Attack:   5 * time.Millisecond, Release: 60 * time.Millisecond, Volume: .5,
+	},
+	"cue_bad": {
+		Wave: Noise, Freq: 2000, Slide: 800,
+		Duration: 140 * time.Millisecond,
+		Attack:   5 * time.Millisecond, Release: 100 * time.Millisecond, Volume: .3,
+	},
+}
+
+// Samples returns the sound as 16-bit mono samples at SampleRate.
+func (p Preset) Samples() []int16 {
+	n := int(p.Duration.Seconds() * SampleRate)
+	out := make([]int16, n)
+	duty := p.Duty
+	if duty <= 0 || duty >= 1 {
+		duty = .5
+	}
+	var phase, noise float64
+	seed := uint32(1)
+	for i := range out {
+		t := float64(i) / float64(n) // progress, from 0 to 1
+		f := p.Freq
+		if p.Slide > 0 {
+			f += (p.Slide - p.Freq) * t
+		}
+		if len(p.Notes) > 0 {
+			f *= p.Notes[int(t*float64(len(p.Notes)))]
+		}
+		prev := phase
+		phase = math.Mod(phase+f/SampleRate, 1)
+		var v float64
+		switch p.Wave {
+		case Square:
+			v = 1
+			if phase >= duty {
+				v = -1
+			}
+		case Triangle:
+			v = 1 - 4*math.Abs(phase-.5)
+		case Sine:
+			v = math.Sin(2 * math.Pi * phase)
+		case Saw:
+			v = 2*phase - 1
+		case Noise:
+			// new random value once per cycle, for a pitched noise
+			if phase < prev || i == 0 {
+				seed = seed*1664525 + 1013904223
+				noise = float64(seed>>16)/32768 - 1
+			}
+			v = noise
+		}
+		out[i] = int16(v * p.envelope(i, n) * p.Volume * math.MaxInt16)
+	}
+	return out
+}
+
+// envelope returns the volume of sample i of n, fading in during the
+// attack and out during the release.
+func (p Preset) envelope(i, n int) float64 {
+	e := 1.0
+	if a := p.Attack.Seconds() * SampleRate; float64(i) < a {
+		e = float64(i) / a
+	}
+	if r := p.Release.Seconds() * SampleRate; float64(n-i) < r {
+		e *= float64(n-i) / r
+	}
+	return e
+}
+
+// WAV returns the sound as a WAV file.
+func (p Preset) WAV() []byte {
+	return WAV(p.Samples())
+}
+
+// WAV encodes 16-bit mono samples at SampleRate as a WAV file.
+func WAV(samples []int16) []byte {
+	size := 2 * len(samples)
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+size))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{
+		uint32(16),             // fmt chunk size
+		uint16(1),              // PCM
+		uint16(1),              // mono
+		uint32(SampleRate),     // sample rate
+		uint32(2 * SampleRate), // byte rate
+		uint16(2),              // block align
+		uint16(16),             // bits per sample
+	} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(size))
+	binary.Write(&b, binary.LittleEndian, samples)
+	return b.Bytes()
+}
@@ -0,0 +1,87 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sound
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPresets(t *testing.T) {
+	for name, p := range Presets {
+		t.Run(name, func(t *testing.T) {
+			samples := p.Samples()
+			if want := int(p.Duration.Seconds() * SampleRate); len(samples) != want {
+				t.Fatalf("got %d samples, want %d", len(samples), want)
+			}
+			var peak int
+			for _, v := range samples {
+				peak = max(peak, int(math.Abs(float64(v))))
+			}
+			if peak == 0 || peak > int(p.Volume*math.MaxInt16)+1 {
+				t.Fatalf("got peak %d, want up to %v", peak, p.Volume*math.MaxInt16)
+			}
+			if last := samples[len(samples)-1]; p.Release > 0 && math.Abs(float64(last)) > math.MaxInt16/100 {
+				t.Fatalf("got last sample %d, want the sound faded out", last)
+			}
+		})
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	p := Preset{Duration: time.Second, Attack: 100 * time.Millisecond, Release: 100 * time.Millisecond}
+	n := SampleRate
+	for _, tc := range []struct {
+		name string
+		i    int
+		want float64
+	}{
+		{"start", 0, 0},
+		{"attack", n / 20, .5},
+		{"sustain", n / 2, 1},
+		{"release", n - n/20, .5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if e := p.envelope(tc.i, n); math.Abs(e-tc.want) > .01 {
+				t.Fatalf("got %v, want %v", e, tc.want)
+			}
+		})
+	}
+}
+
+func TestWAV(t *testing.T) {
+	samples := []int16{0, 1000, -1000, math.MaxInt16}
+	b := WAV(samples)
+	if len(b) != 44+2*len(samples) {
+		t.Fatalf("got %d bytes, want %d", len(b), 44+2*len(samples))
+	}
+	for _, tc := range []struct {
+		off  int
+		want string
+	}{
+		{0, "RIFF"},
+		{8, "WAVE"},
+		{12, "fmt "},
+		{36, "data"},
+	} {
+		if got := string(b[tc.off : tc.off+4]); got != tc.want {
+			t.Fatalf("got %q at %d, want %q", got, tc.off, tc.want)
+		}
+	}
+	if rate := binary.LittleEndian.Uint32(b[24:]); rate != SampleRate {
+		t.Fatalf("got sample rate %d, want %d", rate, SampleRate)
+	}
+	got := make([]int16, len(samples))
+	binary.Read(bytes.NewReader(b[44:]), binary.LittleEndian, got)
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Fatalf("got samples %v, want %v", got, samples)
+		}
+	}
+}
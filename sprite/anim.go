@@ -0,0 +1,202 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sprite
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Key is a key frame of an animation clip. Key frames show a frame
+// of the image set for some time, optionally transformed.
+type Key struct {
+	Frame int     `json:"frame"` // frame of the image set, from 1; 0 is the first
+	MS    int     `json:"ms"`    // duration, in milliseconds
+	Angle float64 `json:"angle"` // rotation, in degrees clockwise
+	Scale float64 `json:"scale"` // scale, where 0 is the same as 1
+	DY    float64 `json:"dy"`    // vertical offset, in pct of the img height
+	Event string  `json:"event"` // event fired when the key frame starts
+}
+
+// Duration returns the duration of the key frame.
+func (k Key) Duration() time.Duration {
+	return time.Duration(k.MS) * time.Millisecond
+}
+
+// Clip is a named animation of an image set.
+type Clip struct {
+	// Keys are the key frames of the clip, in order.
+	Keys []Key `json:"keys"`
+
+	// Loop plays the clip in a loop. Clips that don't loop are
+	// played once and stop at the last key frame, or play the
+	// next clip if set.
+	Loop bool `json:"loop"`
+
+	// Next is the clip to play after a clip that doesn't loop.
+	Next string `json:"next"`
+
+	// Spin is a continuous rotation of the clip, in degrees
+	// per second clockwise.
+	Spin float64 `json:"spin"`
+}
+
+// validate returns an error if the clip is invalid.
+func (c *Clip) validate(clips map[string]*Clip) error {
+	if len(c.Keys) == 0 {
+		return errors.New("no key frames")
+	}
+	for i, k := range c.Keys {
+		switch {
+		case k.Frame < 0:
+			return fmt.Errorf("key %d: invalid frame %d", i, k.Frame)
+		case k.MS <= 0:
+			return fmt.Errorf("key %d: no duration", i)
+		case k.Scale < 0:
+			return fmt.Errorf("key %d: negative scale", i)
+		}
+	}
+	if c.Next == "" {
+		return nil
+	}
+	if c.Loop {
+		return errors.New("looping clip with next")
+	}
+	if _, ok := clips[c.Next]; !ok {
+		return fmt.Errorf("unknown next clip %q", c.Next)
+	}
+	return nil
+}
+
+// Animation plays the clips of an image set. It is driven by time
+// rather than frames drawn, so it plays at the same speed regardless
+// of the frame rate of the game.
+type Animation struct {
+	clips   map[string]*Clip
+	name    string        // name of the current clip
+	clip    *Clip         // current clip, nil if none
+	key     int           // current key frame
+	elapsed time.Duration // time in the current key frame
+	total   time.Duration // time in the current clip
+	done    bool          // one-shot clip finished
+	started bool          // events of the first key fired
+}
+
+// NewAnimation creates an animation with the given clips, which may
+// be nil for image sets without clips.
+func NewAnimation(clips map[string]*Clip) Animation {
+	return Animation{clips: clips}
+}
+
+// Play plays the named clip from the start, and returns false if the
+// clip does not exist.
+func (a *Animation) Play(name string) bool {
+	c, ok := a.clips[name]
+	if !ok {
+		return false
+	}
+	a.name, a.clip = name, c
+	a.key, a.elapsed, a.total = 0, 0, 0
+	a.done, a.started = false, false
+	return true
+}
+
+// Playing returns the name of the current clip, or an empty string
+// if no clip is playing.
+func (a *Animation) Playing() string {
+	return a.name
+}
+
+// Done returns true if a clip played once has finished.
+func (a *Animation) Done() bool {
+	return a.done
+}
+
+// Update advances the animation by dt, and returns the events of
+// the key frames started since the last update.
+func (a *Animation) Update(dt time.Duration) []string {
+	if a.clip == nil {
+		return nil
+	}
+	var events []string
+	if !a.started {
+		a.started = true
+		events = a.fire(events)
+	}
+	if a.done {
+		return events
+	}
+	a.total += dt
+	a.elapsed += dt
+	for {
+		d := a.clip.Keys[a.key].Duration()
+		if a.elapsed < d {
+			break
+		}
+		a.elapsed -= d
+		switch {
+		case a.key+1 < len(a.clip.Keys):
+			a.key++
+		case a.clip.Loop:
+			a.key = 0
+		case a.clip.Next != "":
+			a.Play(a.clip.Next)
+			a.started = true
+			return a.fire(events)
+		default:
+			a.elapsed = 0
+			a.done = true
+			return events
+		}
+		events = a.fire(events)
+	}
+	return events
+}
+
+// fire appends the event of the current key frame to events.
+func (a *Animation) fire(events []string) []string {
+	if ev := a.clip.Keys[a.key].Event; ev != "" {
+		events = append(events, ev)
+	}
+	return events
+}
+
+// Pose is the transformation of the current key frame.
+type Pose struct {
+	Frame int     // frame of the image set, from 1; 0 is the first
+	Angle float64 // rotation, in degrees clockwise
+	Scale float64 // scale, 1 is the original size
+	DY    float64 // vertical offset, in pct of the img height
+}
+
+// Pose returns the pose of the current key frame. Animations without
+// a clip return the identity pose.
+func (a *Animation) Pose() Pose {
+	if a.clip == nil {
+		return Pose{Scale: 1}
+	}
+	k := a.clip.Keys[a.key]
+	p := Pose{
+		Frame: k.Frame,
+		Angle: k.Angle + a.clip.Spin*a.total.Seconds(),
+		Scale: k.Scale,
+		DY:    k.DY,
+	}
+	if p.Scale == 0 {
+		p.Scale = 1
+	}
+	return p
+}
+
+// Clips returns the animation clips of an image set, or nil if the
+// set has no clips.
+func (c Catalog) Clips(name string) map[string]*Clip {
+	if s, ok := c[name]; ok {
+		return s.Clips
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sprite
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClips(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		clips string
+		ok    bool
+	}{
+		{"loop", `{"idle": {"loop": true, "keys": [{"frame": 1, "ms": 100}]}}`, true},
+		{"next", `{"idle": {"loop": true, "keys": [{"ms": 100}]}, "eat": {"next": "idle", "keys": [{"frame": 2, "ms": 100}]}}`, true},
+		{"no clip", `{"idle": null}`, false},
+		{"no keys", `{"idle": {"loop": true, "keys": []}}`, false},
+		{"negative frame", `{"idle": {"keys": [{"frame": -1, "ms": 100}]}}`, false},
+		{"no duration", `{"idle": {"keys": [{"frame": 1}]}}`, false},
+		{"negative scale", `{"idle": {"keys": [{"ms": 100, "scale": -1}]}}`, false},
+		{"loop and next", `{"idle": {"loop": true, "next": "idle", "keys": [{"ms": 100}]}}`, false},
+		{"unknown next", `{"eat": {"next": "idle", "keys": [{"ms": 100}]}}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(`{"cat": {"clips": ` + tc.clips + `}}`))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestAnimation(t *testing.T) {
+	clips := map[string]*Clip{
+		"idle": {Loop: true, Keys: []Key{{Frame: 1, MS: 100}, {Frame: 2, MS: 100, Event: "blink"}}},
+		"eat":  {Next: "idle", Keys: []Key{{Frame: 3, MS: 100, Event: "win"}, {Frame: 4, MS: 100}}},
+		"die":  {Keys: []Key{{Frame: 5, MS: 100, Event: "lose"}}},
+	}
+	ms := time.Millisecond
+	for _, tc := range []struct {
+		name    string
+		clip    string
+		updates []time.Duration
+		events  []string
+		frame   int
+		playing string
+		done    bool
+	}{
+		{"start", "idle", []time.Duration{0}, nil, 1, "idle", false},
+		{"next key", "idle", []time.Duration{150 * ms}, []string{"blink"}, 2, "idle", false},
+		{"loop", "idle", []time.Duration{150 * ms, 100 * ms}, []string{"blink"}, 1, "idle", false},
+		{"loops in one update", "idle", []time.Duration{450 * ms}, []string{"blink", "blink"}, 1, "idle", false},
+		{"first event", "eat", []time.Duration{10 * ms}, []string{"win"}, 3, "eat", false},
+		{"next clip", "eat", []time.Duration{10 * ms, 200 * ms}, []string{"win"}, 1, "idle", false},
+		{"once", "die", []time.Duration{10 * ms, time.Second}, []string{"lose"}, 5, "die", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAnimation(clips)
+			if !a.Play(tc.clip) {
+				t.Fatalf("got no clip %q", tc.clip)
+			}
+			var events []string
+			for _, dt := range tc.updates {
+				events = append(events, a.Update(dt)...)
+			}
+			if !reflect.DeepEqual(events, tc.events) {
+				t.Fatalf("got events %q, want %q", events, tc.events)
+			}
+			if f := a.Pose().Frame; f != tc.frame || a.Playing() != tc.playing || a.Done() != tc.done {
+				t.Fatalf("got frame %d of %q done %v, want %d of %q done %v",
+					f, a.Playing(), a.Done(), tc.frame, tc.playing, tc.done)
+			}
+		})
+	}
+}
+
+func TestAnimationPose(t *testing.T) {
+	var none Animation
+	if p := none.Pose(); p != (Pose{Scale: 1}) {
+		t.Fatalf("got pose %+v without clips, want the identity", p)
+	}
+	if none.Play("idle") {
+		t.Fatal("got a clip played without clips")
+	}
+	a := NewAnimation(map[string]*Clip{
+		"spin": {Loop: true, Spin: 90, Keys: []Key{{MS: 1000, Angle: 10, DY: -.1}}},
+	})
+	a.Play("spin")
+	a.Update(500 * time.Millisecond)
+	if p, want := a.Pose(), (Pose{Angle: 55, Scale: 1, DY: -.1}); p != want {
+		t.Fatalf("got pose %+v, want %+v", p, want)
+	}
+}
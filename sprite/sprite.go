@@ -0,0 +1,141 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package sprite provides the sprite metadata of the game assets,
+// shared by the SDL and wasm versions of the game.
+package sprite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultFile is the sprite metadata file, relative to the game.
+const DefaultFile = "assets/sprites.json"
+
+// Hitbox defines the hit area within a sprite image, in percentages.
+type Hitbox struct {
+	X          float32 `json:"x"`          // left offset
+	Y          float32 `json:"y"`          // top offset
+	W          float32 `json:"w"`          // pct of the img width
+	H          float32 `json:"h"`          // pct of the img height
+	FlipOffset float32 `json:"flipOffset"` // X offset for the horizontal flip
+}
+
+// DefaultHitbox is used for sprites without metadata, and covers
+// the entire image.
+var DefaultHitbox = Hitbox{W: 1, H: 1}
+
+// Rect returns the hit area of a sprite image of size w, h placed
+// at x, y. The flip offset is applied to horizontally flipped images.
+func (hb Hitbox) Rect(x, y, w, h int, flipped bool) (rx, ry, rw, rh int) {
+	rx = x + int(float32(w)*hb.X)
+	ry = y + int(float32(h)*hb.Y)
+	rw = int(float32(w) * hb.W)
+	rh = int(float32(h) * hb.H)
+	if flipped {
+		rx -= int(float32(w) * hb.FlipOffset)
+	}
+	return rx, ry, rw, rh
+}
+
+// Validate returns an error if the hit box is not within the image.
+func (hb Hitbox) Validate() error {
+	switch {
+	case hb.X < 0 || hb.Y < 0:
+		return errors.New("negative offset")
+	case hb.W <= 0 || hb.H <= 0:
+		return errors.New("empty area")
+	case hb.X+hb.W > 1 || hb.Y+hb.H > 1:
+		return errors.New("area exceeds the image")
+	case hb.FlipOffset < -1 || hb.FlipOffset > 1:
+		return errors.New("flip offset exceeds the image")
+	}
+	return nil
+}
+
+// Frame is the metadata of a single frame of an image set.
+type Frame struct {
+	Hitbox *Hitbox `json:"hitbox"`
+}
+
+// Sprite is the metadata of an image set, as loaded by the game from
+// files named {name}_{n}.png. Frames are indexed from 1 like the
+// files, and override the set's metadata. Clips are the named
+// animations of the set.
+type Sprite struct {
+	Hitbox *Hitbox          `json:"hitbox"`
+	Frames map[int]*Frame   `json:"frames"`
+	Clips  map[string]*Clip `json:"clips"`
+}
+
+// Catalog is the sprite metadata indexed by image set name.
+type Catalog map[string]*Sprite
+
+// Parse reads and validates a sprite catalog in JSON format.
+func Parse(r io.Reader) (Catalog, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var c Catalog
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("sprite catalog: %v", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error if any of the sprites is invalid.
+func (c Catalog) Validate() error {
+	for name, s := range c {
+		if s == nil {
+			return fmt.Errorf("sprite %q: no metadata", name)
+		}
+		if s.Hitbox != nil {
+			if err := s.Hitbox.Validate(); err != nil {
+				return fmt.Errorf("sprite %q: hitbox: %v", name, err)
+			}
+		}
+		for n, f := range s.Frames {
+			if n < 1 {
+				return fmt.Errorf("sprite %q: invalid frame %d", name, n)
+			}
+			if f == nil || f.Hitbox == nil {
+				continue
+			}
+			if err := f.Hitbox.Validate(); err != nil {
+				return fmt.Errorf("sprite %q frame %d: hitbox: %v", name, n, err)
+			}
+		}
+		for cn, clip := range s.Clips {
+			if clip == nil {
+				return fmt.Errorf("sprite %q clip %q: no keys", name, cn)
+			}
+			if err := clip.validate(s.Clips); err != nil {
+				return fmt.Errorf("sprite %q clip %q: %v", name, cn, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Hitbox returns the hit box of the given frame of an image set,
+// falling back to the set's hit box and then DefaultHitbox.
+func (c Catalog) Hitbox(name string, frame int) Hitbox {
+	s, ok := c[name]
+	if !ok {
+		return DefaultHitbox
+	}
+	if f, ok := s.Frames[frame]; ok && f != nil && f.Hitbox != nil {
+		return *f.Hitbox
+	}
+	if s.Hitbox != nil {
+		return *s.Hitbox
+	}
+	return DefaultHitbox
+}
@@ -0,0 +1,114 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package sprite
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHitboxRect(t *testing.T) {
+	hb := Hitbox{X: .4, Y: .8, W: .3, H: .15, FlipOffset: .1}
+	for _, tc := range []struct {
+		name           string
+		hb             Hitbox
+		flipped        bool
+		rx, ry, rw, rh int
+	}{
+		{"default", DefaultHitbox, false, 10, 20, 100, 200},
+		{"hitbox", hb, false, 50, 180, 30, 30},
+		{"flipped", hb, true, 40, 180, 30, 30},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rx, ry, rw, rh := tc.hb.Rect(10, 20, 100, 200, tc.flipped)
+			if rx != tc.rx || ry != tc.ry || rw != tc.rw || rh != tc.rh {
+				t.Fatalf("got %d,%d %dx%d, want %d,%d %dx%d",
+					rx, ry, rw, rh, tc.rx, tc.ry, tc.rw, tc.rh)
+			}
+		})
+	}
+}
+
+func TestHitboxValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		hb   Hitbox
+		ok   bool
+	}{
+		{"default", DefaultHitbox, true},
+		{"inside", Hitbox{X: .1, Y: .1, W: .8, H: .8, FlipOffset: -.2}, true},
+		{"negative offset", Hitbox{X: -.1, W: .5, H: .5}, false},
+		{"empty", Hitbox{X: .1, Y: .1}, false},
+		{"too wide", Hitbox{X: .5, W: .6, H: 1}, false},
+		{"flip offset", Hitbox{W: 1, H: 1, FlipOffset: 1.5}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.hb.Validate(); (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"empty", `{}`, true},
+		{"hitbox", `{"cat": {"hitbox": {"x": 0.1, "y": 0.1, "w": 0.8, "h": 0.8}}}`, true},
+		{"frames", `{"cat": {"frames": {"2": {"hitbox": {"w": 0.5, "h": 0.5}}}}}`, true},
+		{"no metadata", `{"cat": null}`, false},
+		{"bad hitbox", `{"cat": {"hitbox": {"w": 0}}}`, false},
+		{"frame 0", `{"cat": {"frames": {"0": {}}}}`, false},
+		{"bad frame hitbox", `{"cat": {"frames": {"1": {"hitbox": {"x": 1, "w": 1, "h": 1}}}}}`, false},
+		{"unknown field", `{"cat": {"hitbx": {}}}`, false},
+		{"not json", `cat`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestCatalogHitbox(t *testing.T) {
+	set := Hitbox{X: .1, Y: .1, W: .8, H: .8}
+	frame := Hitbox{X: .2, W: .6, H: .9}
+	c := Catalog{"drop": {Hitbox: &set, Frames: map[int]*Frame{2: {Hitbox: &frame}, 3: {}}}}
+	for _, tc := range []struct {
+		name  string
+		set   string
+		frame int
+		want  Hitbox
+	}{
+		{"unknown", "cat", 1, DefaultHitbox},
+		{"set", "drop", 1, set},
+		{"frame", "drop", 2, frame},
+		{"frame without hitbox", "drop", 3, set},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if hb := c.Hitbox(tc.set, tc.frame); hb != tc.want {
+				t.Fatalf("got %+v, want %+v", hb, tc.want)
+			}
+		})
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package stats provides the statistics of the game: of each game
+// played, the session, and of all games played by each profile, the
+// lifetime statistics, kept between runs. Drops are counted by their
+// name in the drop catalog. It is shared by the SDL and wasm versions
+// of the game.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// File is the name of the lifetime statistics in the game's store.
+const File = "stats.json"
+
+// Version is the version of the statistics format.
+const Version = 1
+
+// Duration is a duration in JSON, e.g. "1m30s".
+type Duration time.Duration
+
+// MarshalJSON implements the json.Marshaler interface.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Round(time.Second).String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Counts are numbers of drops by name, e.g. "bacon".
+type Counts map[string]int
+
+// Total returns the number of drops of all names.
+func (c Counts) Total() int {
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	return n
+}
+
+// Names returns the names counted, sorted.
+func (c Counts) Names() []string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// add adds the counts of o.
+func (c Counts) add(o Counts) {
+	for name, v := range o {
+		c[name] += v
+	}
+}
+
+// Session is the statistics of a game.
+type Session struct {
+	Caught   Counts   `json:"caught"`    // good drops caught
+	Missed   Counts   `json:"missed"`    // good drops that hit the floor
+	BadHits  Counts   `json:"bad_hits"`  // bad drops caught
+	PowerUps Counts   `json:"power_ups"` // power-ups caught
+	Combo    int      `json:"combo"`     // longest combo
+	PlayTime Duration `json:"play_time"` // time played
+	PeakRate float64  `json:"peak_rate"` // most drops per second
+	Points   int64    `json:"points"`
+}
+
+// NewSession returns the statistics of a new game.
+func NewSession() *Session {
+	return &Session{Caught: Counts{}, Missed: Counts{}, BadHits: Counts{}, PowerUps: Counts{}}
+}
+
+// Catch records the named drop caught, a bad drop if bad.
+func (s *Session) Catch(name string, bad bool) {
+	if bad {
+		s.BadHits[name]++
+		return
+	}
+	s.Caught[name]++
+}
+
+// CatchPowerUp records the named power-up caught.
+func (s *Session) CatchPowerUp(name string) {
+	s.PowerUps[name]++
+}
+
+// Miss records the named good drop that hit the floor.
+func (s *Session) Miss(name string) {
+	s.Missed[name]++
+}
+
+// SetCombo records the length of a combo, kept if the longest.
+func (s *Session) SetCombo(n int) {
+	if n > s.Combo {
+		s.Combo = n
+	}
+}
+
+// SetInterval records the time between new drops of the rain, kept as
+// the peak rate if the shortest.
+func (s *Session) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if r := float64(time.Second) / float64(d); r > s.PeakRate {
+		s.PeakRate = r
+	}
+}
+
+// Play adds dt to the time played.
+func (s *Session) Play(dt time.Duration) {
+	s.PlayTime += Duration(dt)
+}
+
+// Lifetime is the statistics of all games of a profile. The counts and
+// the play time are the totals of all games, the combo and the peak
+// rate are the best of all games, and the points are the total.
+type Lifetime struct {
+	Games int   `json:"games"`
+	Best  int64 `json:"best"` // most points in a game
+	Session
+}
+
+// NewLifetime returns the statistics of no games.
+func NewLifetime() *Lifetime {
+	return &Lifetime{Session: *NewSession()}
+}
+
+// Add adds the statistics of a game.
+func (l *Lifetime) Add(s *Session) {
+	l.Games++
+	if s.Points > l.Best {
+		l.Best = s.Points
+	}
+	l.Caught.add(s.Caught)
+	l.Missed.add(s.Missed)
+	l.BadHits.add(s.BadHits)
+	l.PowerUps.add(s.PowerUps)
+	l.SetCombo(s.Combo)
+	if s.PeakRate > l.PeakRate {
+		l.PeakRate = s.PeakRate
+	}
+	l.PlayTime += s.PlayTime
+	l.Points += s.Points
+}
+
+// Stats is the lifetime statistics of each profile.
+type Stats struct {
+	Version  int                  `json:"version"`
+	Profiles map[string]*Lifetime `json:"profiles"`
+}
+
+// New returns empty statistics.
+func New() *Stats {
+	return &Stats{Version: Version, Profiles: make(map[string]*Lifetime)}
+}
+
+// Load loads the statistics from the store. Missing statistics are
+// empty. Statistics that fail to load are kept in the store as
+// File.bad for inspection, and replaced by empty statistics returned
+// with an error, so the game can go on.
+func Load(st store.Store) (*Stats, error) {
+	b, err := st.Load(File)
+	switch {
+	case errors.Is(err, store.ErrNotExist):
+		return New(), nil
+	case err != nil:
+		return New(), err
+	}
+	s := New()
+	err = json.Unmarshal(b, s)
+	if err == nil && s.Version > Version {
+		err = fmt.Errorf("unsupported version %d", s.Version)
+	}
+	if err != nil {
+		st.Save(File+".bad", b)
+		return New(), fmt.Errorf("stats: %v", err)
+	}
+	s.Version = Version
+	if s.Profiles == nil {
+		s.Profiles = make(map[string]*Lifetime)
+	}
+	for name, l := range s.Profiles {
+		if l == nil {
+			delete(s.Profiles, name)
+			continue
+		}
+		for _, c := range []*Counts{&l.Caught, &l.Missed, &l.BadHits, &l.PowerUps} {
+			if *c == nil {
+				*c = Counts{}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Save saves the statistics to the store.
+func (s *Stats) Save(st store.Store) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	return st.Save(File, b)
+}
+
+// Profile returns the lifetime statistics of the named profile.
+func (s *Stats) Profile(name string) *Lifetime {
+	l, ok := s.Profiles[name]
+	if !ok {
+		l = NewLifetime()
+		s.Profiles[name] = l
+	}
+	return l
+}
+
+// Export is the statistics of a profile, exported for the players.
+type Export struct {
+	Profile  string    `json:"profile"`
+	Date     time.Time `json:"date"`
+	Session  *Session  `json:"session,omitempty"` // last game, if any
+	Lifetime *Lifetime `json:"lifetime"`
+}
+
+// Export returns the export of the named profile, with its last game
+// if any.
+func (s *Stats) Export(profile string, last *Session) *Export {
+	return &Export{
+		Profile:  profile,
+		Date:     time.Now(),
+		Session:  last,
+		Lifetime: s.Profile(profile),
+	}
+}
+
+// JSON returns the export in JSON format.
+func (e *Export) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "\t")
+}
+
+// FileName returns the name of the file of the export, e.g.
+// "stats-Ana.json".
+func (e *Export) FileName() string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, e.Profile)
+	return "stats-" + name + ".json"
+}
+
+// Row is a row of the statistics shown to the players: what's counted,
+// in the locale of the game, and its value in the last game and in all
+// games.
+type Row struct {
+	Label, Game, All string
+}
+
+// Summary returns the rows of the totals of the export. The values of
+// the last game are empty if none.
+func (e *Export) Summary() []Row {
+	s, l := e.Session, e.Lifetime
+	rows := []Row{
+		{locale.T("Games"), "", fmt.Sprint(l.Games)},
+		{locale.T("Points"), "", fmt.Sprint(l.Points)},
+		{locale.T("Best"), "", fmt.Sprint(l.Best)},
+		{locale.T("Caught"), "", fmt.Sprint(l.Caught.Total())},
+		{locale.T("Missed"), "", fmt.Sprint(l.Missed.Total())},
+		{locale.T("Bad hits"), "", fmt.Sprint(l.BadHits.Total())},
+		{locale.T("Best combo"), "", fmt.Sprint(l.Combo)},
+		{locale.T("Play time"), "", clock(l.PlayTime)},
+		{locale.T("Peak rate"), "", rate(l.PeakRate)},
+	}
+	if s == nil {
+		return rows
+	}
+	for i, v := range []string{
+		"1",
+		fmt.Sprint(s.Points),
+		fmt.Sprint(s.Points),
+		fmt.Sprint(s.Caught.Total()),
+		fmt.Sprint(s.Missed.Total()),
+		fmt.Sprint(s.BadHits.Total()),
+		fmt.Sprint(s.Combo),
+		clock(s.PlayTime),
+		rate(s.PeakRate),
+	} {
+		rows[i].Game = v
+	}
+	return rows
+}
+
+// Drops returns the rows of the drops caught, good or bad or
+// power-ups, by name.
+// The values of the last game are empty if none.
+func (e *Export) Drops() []Row {
+	all := Counts{}
+	all.add(e.Lifetime.Caught)
+	all.add(e.Lifetime.BadHits)
+	all.add(e.Lifetime.PowerUps)
+	var rows []Row
+	for _, name := range all.Names() {
+		r := Row{Label: locale.T(name), All: fmt.Sprint(all[name])}
+		if e.Session != nil {
+			s := e.Session
+			r.Game = fmt.Sprint(s.Caught[name] + s.BadHits[name] + s.PowerUps[name])
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// clock returns the duration as h:mm:ss, or m:ss under an hour.
+func clock(d Duration) string {
+	s := int(time.Duration(d) / time.Second)
+	if s < 3600 {
+		return fmt.Sprintf("%d:%02d", s/60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// rate returns the rate of drops per second.
+func rate(r float64) string {
+	return fmt.Sprintf("%.1f/s", r)
+}
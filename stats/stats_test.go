@@ -0,0 +1,201 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package stats
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// game returns the statistics of a game.
+func game(points int64, combo int, interval, played time.Duration) *Session {
+	s := NewSession()
+	s.Catch("bacon", false)
+	s.Catch("bacon", false)
+	s.Catch("pepper", true)
+	s.CatchPowerUp("shield")
+	s.Miss("donut")
+	s.SetCombo(combo)
+	s.SetInterval(interval)
+	s.Play(played)
+	s.Points = points
+	return s
+}
+
+func TestSession(t *testing.T) {
+	s := game(30, 5, 500*time.Millisecond, time.Minute)
+	s.SetCombo(3)
+	s.SetInterval(time.Second)
+	s.SetInterval(0)
+	want := &Session{
+		Caught:   Counts{"bacon": 2},
+		Missed:   Counts{"donut": 1},
+		BadHits:  Counts{"pepper": 1},
+		PowerUps: Counts{"shield": 1},
+		Combo:    5,
+		PlayTime: Duration(time.Minute),
+		PeakRate: 2,
+		Points:   30,
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Fatalf("got %+v, want %+v", s, want)
+	}
+}
+
+func TestLifetimeAdd(t *testing.T) {
+	l := NewLifetime()
+	l.Add(game(30, 5, time.Second, time.Minute))
+	l.Add(game(10, 8, 250*time.Millisecond, time.Minute))
+	want := &Lifetime{
+		Games: 2,
+		Best:  30,
+		Session: Session{
+			Caught:   Counts{"bacon": 4},
+			Missed:   Counts{"donut": 2},
+			BadHits:  Counts{"pepper": 2},
+			PowerUps: Counts{"shield": 2},
+			Combo:    8,
+			PlayTime: Duration(2 * time.Minute),
+			PeakRate: 4,
+			Points:   40,
+		},
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Fatalf("got %+v, want %+v", l, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		data  string // saved statistics, empty for none
+		games map[string]int
+		err   bool
+		bad   bool // kept as File.bad
+	}{
+		{"missing", "", map[string]int{}, false, false},
+		{"saved", `{"version": 1, "profiles": {"Tom": {"games": 3, "play_time": "1m30s"}, "Ana": null}}`, map[string]int{"Tom": 3}, false, false},
+		{"no profiles", `{"version": 1}`, map[string]int{}, false, false},
+		{"corrupt", `{"profiles": {`, map[string]int{}, true, true},
+		{"bad duration", `{"profiles": {"Tom": {"play_time": "forever"}}}`, map[string]int{}, true, true},
+		{"newer version", `{"version": 2}`, map[string]int{}, true, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			st := store.Dir(t.TempDir())
+			if tc.data != "" {
+				st.Save(File, []byte(tc.data))
+			}
+			s, err := Load(st)
+			if (err != nil) != tc.err {
+				t.Fatalf("got error %v, want error %v", err, tc.err)
+			}
+			games := map[string]int{}
+			for name, l := range s.Profiles {
+				games[name] = l.Games
+				// counts are never nil, so the game can count
+				l.Caught["bacon"]++
+				l.Missed["bacon"]++
+				l.BadHits["bacon"]++
+				l.PowerUps["bacon"]++
+			}
+			if s.Version != Version || !reflect.DeepEqual(games, tc.games) {
+				t.Fatalf("got games %v, want %v", games, tc.games)
+			}
+			bad, err := st.Load(File + ".bad")
+			if tc.bad && string(bad) != tc.data {
+				t.Fatalf("got %q kept as bad, want %q", bad, tc.data)
+			}
+			if !tc.bad && err != store.ErrNotExist {
+				t.Fatalf("got %q kept as bad, want none", bad)
+			}
+		})
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	st := store.Dir(t.TempDir())
+	want := New()
+	want.Profile("Tom").Add(game(30, 5, time.Second, 90*time.Second))
+	if err := want.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	s := New()
+	last := game(30, 5, 500*time.Millisecond, 75*time.Second)
+	s.Profile("Ana").Add(game(10, 8, time.Second, time.Hour))
+	s.Profile("Ana").Add(last)
+	e := s.Export("Ana", last)
+	for _, tc := range []struct {
+		label     string
+		game, all string
+	}{
+		{"Games", "1", "2"},
+		{"Points", "30", "40"},
+		{"Best", "30", "30"},
+		{"Caught", "2", "4"},
+		{"Missed", "1", "2"},
+		{"Bad hits", "1", "2"},
+		{"Best combo", "5", "8"},
+		{"Play time", "1:15", "1:01:15"},
+		{"Peak rate", "2.0/s", "2.0/s"},
+	} {
+		var row *Row
+		for _, r := range e.Summary() {
+			if r.Label == tc.label {
+				row = &r
+			}
+		}
+		if row == nil || row.Game != tc.game || row.All != tc.all {
+			t.Fatalf("got row %+v, want %s %s %s", row, tc.label, tc.game, tc.all)
+		}
+	}
+	wantDrops := []Row{{"bacon", "2", "4"}, {"pepper", "1", "2"}, {"shield", "1", "2"}}
+	if drops := e.Drops(); !reflect.DeepEqual(drops, wantDrops) {
+		t.Fatalf("got drops %+v, want %+v", drops, wantDrops)
+	}
+	if rows := s.Export("Ana", nil).Summary(); rows[0].Game != "" {
+		t.Fatalf("got %q games in the last game of none, want empty", rows[0].Game)
+	}
+	b, err := e.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err = json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["profile"] != "Ana" || m["session"] == nil {
+		t.Fatalf("got export %s, want the profile and the last game", b)
+	}
+}
+
+func TestExportFileName(t *testing.T) {
+	for _, tc := range []struct {
+		profile, want string
+	}{
+		{"Ana", "stats-Ana.json"},
+		{"Tom 2", "stats-Tom_2.json"},
+		{"../Joé", "stats-___Joé.json"},
+	} {
+		e := New().Export(tc.profile, nil)
+		if got := e.FileName(); got != tc.want {
+			t.Fatalf("got %q for %q, want %q", got, tc.profile, tc.want)
+		}
+	}
+}
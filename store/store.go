@@ -0,0 +1,92 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package store provides storage for the game data that is kept
+// between runs, such as settings. The SDL version of the game stores
+// files in the user's config directory, and the wasm version stores
+// them in the browser's local storage.
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotExist is returned when loading data that was never saved.
+var ErrNotExist = errors.New("store: not found")
+
+// Store loads and saves named data, e.g. "settings.json".
+type Store interface {
+	// Load returns the data saved with the given name, or
+	// ErrNotExist.
+	Load(name string) ([]byte, error)
+
+	// Save saves the data with the given name, replacing any data
+	// previously saved with the same name.
+	Save(name string, data []byte) error
+}
+
+// Dir is a store of files in a directory.
+type Dir string
+
+// UserDir returns the store of the game in the user's config
+// directory, e.g. ~/.config/cat-o-licious on Linux.
+func UserDir() (Dir, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return Dir(filepath.Join(dir, "cat-o-licious")), nil
+}
+
+// Load implements the Store interface.
+func (d Dir) Load(name string) ([]byte, error) {
+	b, err := os.ReadFile(filepath.Join(string(d), name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return b, err
+}
+
+// Save implements the Store interface. Data is written to a temporary
+// file first, so a crash while saving leaves the old data intact.
+func (d Dir) Save(name string, data []byte) error {
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(string(d), name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(string(d), name))
+}
+
+// Mem is a store in memory, for when there is nowhere to save the
+// data. Data saved in it is lost when the game quits.
+type Mem map[string][]byte
+
+// Load implements the Store interface.
+func (m Mem) Load(name string) ([]byte, error) {
+	b, ok := m[name]
+	if !ok {
+		return nil, ErrNotExist
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// Save implements the Store interface.
+func (m Mem) Save(name string, data []byte) error {
+	m[name] = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	d := Dir(filepath.Join(t.TempDir(), "cat-o-licious"))
+	if _, err := d.Load("data.json"); err != ErrNotExist {
+		t.Fatalf("got error %v, want %v", err, ErrNotExist)
+	}
+	for _, data := range []string{"first", "second"} {
+		if err := d.Save("data.json", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		b, err := d.Load("data.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != data {
+			t.Fatalf("got %q, want %q", b, data)
+		}
+	}
+	files, err := os.ReadDir(string(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want only the data saved", len(files))
+	}
+}
+
+func TestMem(t *testing.T) {
+	m := Mem{}
+	if _, err := m.Load("data.json"); err != ErrNotExist {
+		t.Fatalf("got error %v, want %v", err, ErrNotExist)
+	}
+	data := []byte("first")
+	if err := m.Save("data.json", data); err != nil {
+		t.Fatal(err)
+	}
+	// the store keeps a copy of the data
+	data[0] = 'F'
+	b, err := m.Load("data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "first" {
+		t.Fatalf("got %q, want %q", b, "first")
+	}
+}
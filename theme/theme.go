@@ -0,0 +1,154 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package theme provides the theme of the game's background: layers
+// of images that scroll at different speeds, and the weather, such
+// as drifting clouds and rain during storms. It is shared by the SDL
+// and wasm versions of the game, which draw the background.
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultFile is the theme file, relative to the game.
+const DefaultFile = "assets/theme.json"
+
+// Duration is a duration in JSON, e.g. "45s".
+type Duration time.Duration
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Layer is a background layer. Layers are drawn in order, scaled to
+// their height and repeated horizontally.
+type Layer struct {
+	// Image is the image file of the layer, relative to the game.
+	Image string `json:"image"`
+
+	// Y is the top of the layer, in pct of the viewport height.
+	Y float64 `json:"y"`
+
+	// H is the height of the layer, in pct of the viewport height.
+	// Zero is the height from Y to the bottom of the viewport.
+	H float64 `json:"h"`
+
+	// Stretch stretches the image to the viewport width instead
+	// of keeping its aspect ratio.
+	Stretch bool `json:"stretch"`
+
+	// Speed is the scroll speed of the layer, in pixels per
+	// second. Positive speeds scroll to the left.
+	Speed float64 `json:"speed"`
+
+	// Parallax is how much the layer moves opposite to the
+	// player, from 0 (still) to 1 (as much as the player).
+	Parallax float64 `json:"parallax"`
+}
+
+// Rect returns the vertical position and height of the layer, in a
+// viewport of height h.
+func (l Layer) Rect(h float64) (y, lh float64) {
+	y = l.Y * h
+	lh = l.H * h
+	if lh == 0 {
+		lh = h - y
+	}
+	return y, lh
+}
+
+// Offset returns how much the layer has scrolled to the left, in
+// pixels, after time t and with the player at focus pixels from the
+// center of the viewport.
+func (l Layer) Offset(t time.Duration, focus float64) float64 {
+	return l.Speed*t.Seconds() + l.Parallax*focus
+}
+
+// Phase is a weather phase. Phases play in order and in a loop.
+type Phase struct {
+	Name     string   `json:"name"`
+	Duration Duration `json:"duration"`
+
+	// Clouds is the number of clouds in the sky.
+	Clouds int `json:"clouds"`
+
+	// CloudSpeed is the speed of the clouds, in pixels per second.
+	CloudSpeed float64 `json:"cloudSpeed"`
+
+	// Rain is the number of rain streaks per second.
+	Rain float64 `json:"rain"`
+
+	// Wind slants the rain, as the ratio of lateral to vertical
+	// speed. Positive wind blows to the right.
+	Wind float64 `json:"wind"`
+
+	// Dim darkens the background, from 0 to 1.
+	Dim float64 `json:"dim"`
+}
+
+// Theme is the theme of the game's background.
+type Theme struct {
+	Layers  []Layer `json:"layers"`
+	Cloud   string  `json:"cloud"` // image file of the clouds
+	Weather []Phase `json:"weather"`
+}
+
+// Parse reads and validates a theme in JSON format.
+func Parse(r io.Reader) (*Theme, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var t Theme
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("theme: %v", err)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Validate returns an error if the theme is invalid.
+func (t *Theme) Validate() error {
+	if len(t.Layers) == 0 {
+		return fmt.Errorf("theme: no layers")
+	}
+	for i, l := range t.Layers {
+		switch {
+		case l.Image == "":
+			return fmt.Errorf("theme: layer %d: no image", i)
+		case l.Y < 0 || l.H < 0 || l.Y+l.H > 1:
+			return fmt.Errorf("theme: layer %d: not within the viewport", i)
+		case l.Parallax < 0 || l.Parallax > 1:
+			return fmt.Errorf("theme: layer %d: parallax not within 0 and 1", i)
+		}
+	}
+	for i, p := range t.Weather {
+		switch {
+		case p.Duration <= 0:
+			return fmt.Errorf("theme: phase %d: no duration", i)
+		case p.Clouds < 0 || p.Rain < 0:
+			return fmt.Errorf("theme: phase %d: negative clouds or rain", i)
+		case p.Clouds > 0 && t.Cloud == "":
+			return fmt.Errorf("theme: phase %d: clouds without cloud image", i)
+		case p.Dim < 0 || p.Dim > 1:
+			return fmt.Errorf("theme: phase %d: dim not within 0 and 1", i)
+		}
+	}
+	return nil
+}
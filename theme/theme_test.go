@@ -0,0 +1,114 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package theme
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		ok   bool
+	}{
+		{"layers", `{"layers": [{"image": "sky.png"}, {"image": "ground.png", "y": 0.8, "speed": 10, "parallax": 0.5}]}`, true},
+		{"weather", `{"layers": [{"image": "sky.png"}], "cloud": "cloud.png", "weather": [
+			{"name": "clear", "duration": "30s", "clouds": 2, "cloudSpeed": 10},
+			{"name": "storm", "duration": "1m", "rain": 40, "wind": 0.2, "dim": 0.4}
+		]}`, true},
+		{"no layers", `{}`, false},
+		{"no image", `{"layers": [{"y": 0.5}]}`, false},
+		{"off the viewport", `{"layers": [{"image": "sky.png", "y": 0.8, "h": 0.4}]}`, false},
+		{"parallax", `{"layers": [{"image": "sky.png", "parallax": 2}]}`, false},
+		{"no duration", `{"layers": [{"image": "sky.png"}], "weather": [{"name": "clear"}]}`, false},
+		{"bad duration", `{"layers": [{"image": "sky.png"}], "weather": [{"name": "clear", "duration": "soon"}]}`, false},
+		{"no cloud image", `{"layers": [{"image": "sky.png"}], "weather": [{"name": "clear", "duration": "1s", "clouds": 1}]}`, false},
+		{"dim", `{"layers": [{"image": "sky.png"}], "weather": [{"name": "clear", "duration": "1s", "dim": 2}]}`, false},
+		{"unknown field", `{"layers": [{"image": "sky.png", "alpha": 1}]}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.json))
+			if (err == nil) != tc.ok {
+				t.Fatalf("got error %v, want ok %v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestLayer(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		l     Layer
+		y, h  float64
+		offst float64
+	}{
+		{"full", Layer{}, 0, 600, 0},
+		{"to the bottom", Layer{Y: .75}, 450, 150, 0},
+		{"band", Layer{Y: .25, H: .5}, 150, 300, 0},
+		{"scrolling", Layer{Speed: 10}, 0, 600, 20},
+		{"parallax", Layer{Speed: 10, Parallax: .5}, 0, 600, 70},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			y, h := tc.l.Rect(600)
+			if y != tc.y || h != tc.h {
+				t.Fatalf("got y %v height %v, want %v %v", y, h, tc.y, tc.h)
+			}
+			if o := tc.l.Offset(2*time.Second, 100); o != tc.offst {
+				t.Fatalf("got offset %v, want %v", o, tc.offst)
+			}
+		})
+	}
+}
+
+func TestWeather(t *testing.T) {
+	th := &Theme{Cloud: "cloud.png", Weather: []Phase{
+		{Name: "clear", Duration: Duration(10 * time.Second), Clouds: 3, CloudSpeed: 20},
+		{Name: "storm", Duration: Duration(10 * time.Second), Rain: 10, Dim: .8},
+	}}
+	w := NewWeather(th)
+	w.Update(time.Second, 800, 600, 100)
+	if p := w.Phase(); p.Name != "clear" || len(w.Clouds()) != 3 || len(w.Streaks()) != 0 {
+		t.Fatalf("got %q with %d clouds and %d streaks, want clear with 3 clouds", p.Name, len(w.Clouds()), len(w.Streaks()))
+	}
+	w.Update(8*time.Second, 800, 600, 100)
+	w.Update(time.Second, 800, 600, 100)
+	if p := w.Phase(); p.Name != "storm" {
+		t.Fatalf("got %q after the clear phase, want storm", p.Name)
+	}
+	if d := w.Dim(); d != .5 {
+		t.Fatalf("got dim %v, want .5 on the way to .8", d)
+	}
+	w.Update(time.Second, 800, 600, 100)
+	if n := len(w.Streaks()); n != 10 {
+		t.Fatalf("got %d streaks, want the 10 of the last second", n)
+	}
+	if d := w.Dim(); d != .8 {
+		t.Fatalf("got dim %v, want .8", d)
+	}
+}
+
+func TestWeatherNone(t *testing.T) {
+	w := NewWeather(&Theme{})
+	w.Update(time.Second, 800, 600, 100)
+	if p := w.Phase(); p.Name != "" || w.Dim() != 0 || len(w.Clouds()) != 0 {
+		t.Fatalf("got phase %+v, want none", p)
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	f, err := os.Open("../" + DefaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := Parse(f); err != nil {
+		t.Fatal(err)
+	}
+}
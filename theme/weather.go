@@ -0,0 +1,161 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package theme
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Rain streaks fall this fast, in pixels per second.
+const rainSpeed = 900
+
+// dimSpeed is how fast the background dims between phases, per second.
+const dimSpeed = .5
+
+// Cloud is a cloud drifting in the sky.
+type Cloud struct {
+	X, Y  float64 // position, in pixels
+	Scale float64 // size relative to the cloud image
+	Speed float64 // lateral speed, in pixels per second
+}
+
+// Streak is a rain streak.
+type Streak struct {
+	X, Y   float64 // top position, in pixels
+	VX, VY float64 // velocity, in pixels per second
+	Len    float64 // length, in pixels
+}
+
+// Weather simulates the weather phases of a theme.
+type Weather struct {
+	phases  []Phase
+	cur     int
+	elapsed time.Duration
+	dim     float64
+	rain    float64 // streaks to spawn, with fractions left over
+	clouds  []Cloud
+	streaks []Streak
+}
+
+// NewWeather creates the weather of a theme.
+func NewWeather(t *Theme) *Weather {
+	return &Weather{phases: t.Weather}
+}
+
+// Phase returns the current phase, or the zero phase for themes
+// without weather.
+func (w *Weather) Phase() Phase {
+	if len(w.phases) == 0 {
+		return Phase{}
+	}
+	return w.phases[w.cur]
+}
+
+// Dim returns how dark the background is, from 0 to 1. It changes
+// gradually between phases.
+func (w *Weather) Dim() float64 {
+	return w.dim
+}
+
+// Clouds returns the clouds in the sky.
+func (w *Weather) Clouds() []Cloud {
+	return w.clouds
+}
+
+// Streaks returns the rain streaks.
+func (w *Weather) Streaks() []Streak {
+	return w.streaks
+}
+
+// Update advances the weather by dt, in a viewport of size vw, vh.
+// Clouds of width cw, scaled, drift across the viewport.
+func (w *Weather) Update(dt time.Duration, vw, vh, cw float64) {
+	if len(w.phases) == 0 {
+		return
+	}
+	w.elapsed += dt
+	if w.elapsed >= time.Duration(w.phases[w.cur].Duration) {
+		w.elapsed = 0
+		w.cur = (w.cur + 1) % len(w.phases)
+	}
+	p := w.phases[w.cur]
+	sec := dt.Seconds()
+	// dim toward the phase
+	if d := p.Dim - w.dim; math.Abs(d) <= dimSpeed*sec {
+		w.dim = p.Dim
+	} else {
+		w.dim += math.Copysign(dimSpeed*sec, d)
+	}
+	w.updateClouds(p, sec, vw, vh, cw)
+	w.updateRain(p, sec, vw, vh)
+}
+
+// updateClouds drifts the clouds, drains those that left the
+// viewport, and adds clouds up to the number of the phase.
+func (w *Weather) updateClouds(p Phase, sec, vw, vh, cw float64) {
+	kept := w.clouds[:0]
+	for _, c := range w.clouds {
+		// clouds speed up or slow down toward the phase
+		c.Speed += (p.CloudSpeed*c.Scale - c.Speed) * math.Min(sec, 1)
+		c.X += c.Speed * sec
+		// drain clouds that drifted past the viewport
+		if c.Speed >= 0 && c.X > vw || c.Speed < 0 && c.X+cw*c.Scale < 0 {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	w.clouds = kept
+	first := w.elapsed == 0 && len(w.clouds) == 0
+	for len(w.clouds) < p.Clouds {
+		c := Cloud{
+			Y:     rand.Float64() * vh * .4,
+			Scale: .5 + rand.Float64()*.8,
+		}
+		c.Speed = p.CloudSpeed * c.Scale
+		c.X = -cw * c.Scale
+		if p.CloudSpeed < 0 {
+			c.X = vw
+		}
+		if first {
+			// start with clouds in the sky
+			c.X = rand.Float64() * vw
+		} else {
+			// stagger new clouds off-screen
+			c.X -= math.Copysign(rand.Float64()*vw*.5, p.CloudSpeed)
+		}
+		w.clouds = append(w.clouds, c)
+	}
+}
+
+// updateRain moves the rain streaks, drains those that hit the
+// floor, and spawns new streaks at the rate of the phase.
+func (w *Weather) updateRain(p Phase, sec, vw, vh float64) {
+	kept := w.streaks[:0]
+	for _, s := range w.streaks {
+		s.X += s.VX * sec
+		s.Y += s.VY * sec
+		if s.Y > vh {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	w.streaks = kept
+	w.rain += p.Rain * sec
+	for ; w.rain >= 1; w.rain-- {
+		vy := rainSpeed * (.8 + rand.Float64()*.4)
+		// spread the rain wider than the viewport, upwind
+		x := rand.Float64()*vw*1.4 - vw*.2 - p.Wind*vh/2
+		w.streaks = append(w.streaks, Streak{
+			X:   x,
+			Y:   -20,
+			VX:  vy * p.Wind,
+			VY:  vy,
+			Len: 12 + rand.Float64()*14,
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package tween
+
+import "math"
+
+// Ease is an easing function. It maps the progress of a tween, from
+// 0 to 1, to the progress of its value, which may overshoot.
+type Ease func(t float64) float64
+
+// Easing functions.
+var (
+	Linear    Ease = func(t float64) float64 { return t }
+	InQuad    Ease = func(t float64) float64 { return t * t }
+	OutQuad   Ease = func(t float64) float64 { return t * (2 - t) }
+	InOutQuad Ease = func(t float64) float64 {
+		if t < .5 {
+			return 2 * t * t
+		}
+		return -1 + (4-2*t)*t
+	}
+	OutCubic Ease = func(t float64) float64 {
+		t--
+		return t*t*t + 1
+	}
+	InOutCubic Ease = func(t float64) float64 {
+		if t < .5 {
+			return 4 * t * t * t
+		}
+		t = 2*t - 2
+		return t*t*t/2 + 1
+	}
+	// OutBack overshoots the end value a little and settles back.
+	OutBack Ease = func(t float64) float64 {
+		const s = 1.70158
+		t--
+		return t*t*((s+1)*t+s) + 1
+	}
+	// OutElastic overshoots the end value and wobbles around it.
+	OutElastic Ease = func(t float64) float64 {
+		if t == 0 || t == 1 {
+			return t
+		}
+		return math.Pow(2, -10*t)*math.Sin((t-.075)*(2*math.Pi)/.3) + 1
+	}
+	// OutBounce bounces off the end value like a ball.
+	OutBounce Ease = func(t float64) float64 {
+		switch {
+		case t < 1/2.75:
+			return 7.5625 * t * t
+		case t < 2/2.75:
+			t -= 1.5 / 2.75
+			return 7.5625*t*t + .75
+		case t < 2.5/2.75:
+			t -= 2.25 / 2.75
+			return 7.5625*t*t + .9375
+		}
+		t -= 2.625 / 2.75
+		return 7.5625*t*t + .984375
+	}
+)
@@ -0,0 +1,219 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package tween provides tweens: values interpolated over time with
+// easing functions, that can be chained in sequences and cancelled.
+// Tweens are driven by the game clock, and shared by the SDL and wasm
+// versions of the game.
+package tween
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Tweener is anything driven by the game clock, such as tweens and
+// sequences of tweens.
+type Tweener interface {
+	// Update advances the tweener by dt, and returns true while it
+	// is running.
+	Update(dt time.Duration) bool
+
+	// Cancel stops the tweener without calling its callbacks.
+	Cancel()
+}
+
+// Tween interpolates a value from From to To over Duration.
+type Tween struct {
+	From, To float64
+	Duration time.Duration
+	Ease     Ease
+
+	// OnUpdate is called with the value on every update.
+	OnUpdate func(v float64)
+
+	// OnDone is called once the tween has finished, unless it
+	// has been cancelled.
+	OnDone func()
+
+	elapsed time.Duration
+	done    bool
+}
+
+// New creates a tween. The nil ease is Linear.
+func New(from, to float64, d time.Duration, ease Ease) *Tween {
+	return &Tween{From: from, To: to, Duration: d, Ease: ease}
+}
+
+// Wait creates a tween that does nothing for d, e.g. to delay the
+// next tween of a sequence.
+func Wait(d time.Duration) *Tween {
+	return New(0, 0, d, nil)
+}
+
+// Call creates a tween that calls f and finishes right away, e.g. to
+// run code between the tweens of a sequence.
+func Call(f func()) *Tween {
+	return &Tween{OnDone: f}
+}
+
+// Update implements the Tweener interface.
+func (t *Tween) Update(dt time.Duration) bool {
+	if t.done {
+		return false
+	}
+	t.elapsed += dt
+	if t.elapsed >= t.Duration {
+		t.elapsed = t.Duration
+		t.done = true
+	}
+	if t.OnUpdate != nil {
+		t.OnUpdate(t.Value())
+	}
+	if t.done && t.OnDone != nil {
+		t.OnDone()
+	}
+	return !t.done
+}
+
+// Cancel implements the Tweener interface.
+func (t *Tween) Cancel() {
+	t.done = true
+}
+
+// Done returns true if the tween has finished or was cancelled.
+func (t *Tween) Done() bool {
+	return t.done
+}
+
+// Progress returns the progress of the tween in time, from 0 to 1.
+func (t *Tween) Progress() float64 {
+	if t.Duration <= 0 {
+		return 1
+	}
+	return float64(t.elapsed) / float64(t.Duration)
+}
+
+// Value returns the current value of the tween.
+func (t *Tween) Value() float64 {
+	p := t.Progress()
+	if t.Ease != nil {
+		p = t.Ease(p)
+	}
+	return t.From + (t.To-t.From)*p
+}
+
+// Sequence plays tweeners one after another.
+type Sequence struct {
+	// OnDone is called once the last tweener has finished, unless
+	// the sequence has been cancelled.
+	OnDone func()
+
+	steps []Tweener
+	cur   int
+	done  bool
+}
+
+// Seq creates a sequence of tweeners.
+func Seq(steps ...Tweener) *Sequence {
+	return &Sequence{steps: steps}
+}
+
+// Update implements the Tweener interface.
+func (s *Sequence) Update(dt time.Duration) bool {
+	if s.done {
+		return false
+	}
+	for s.cur < len(s.steps) {
+		if s.steps[s.cur].Update(dt) {
+			return true
+		}
+		// the next step starts on this update, but with no time
+		// left over, to keep the previous step's end value
+		s.cur++
+		dt = 0
+	}
+	s.done = true
+	if s.OnDone != nil {
+		s.OnDone()
+	}
+	return false
+}
+
+// Cancel implements the Tweener interface.
+func (s *Sequence) Cancel() {
+	if s.cur < len(s.steps) {
+		s.steps[s.cur].Cancel()
+	}
+	s.done = true
+}
+
+// Done returns true if the sequence has finished or was cancelled.
+func (s *Sequence) Done() bool {
+	return s.done
+}
+
+// Group drives a set of tweeners, and drops them once they finish.
+type Group struct {
+	tweens []Tweener
+}
+
+// Add adds a tweener to the group, and returns it.
+func (g *Group) Add(t Tweener) Tweener {
+	g.tweens = append(g.tweens, t)
+	return t
+}
+
+// Update advances all tweeners of the group by dt.
+func (g *Group) Update(dt time.Duration) {
+	kept := g.tweens[:0]
+	for _, t := range g.tweens {
+		if t.Update(dt) {
+			kept = append(kept, t)
+		}
+	}
+	for i := len(kept); i < len(g.tweens); i++ {
+		g.tweens[i] = nil
+	}
+	g.tweens = kept
+}
+
+// Cancel cancels all tweeners of the group.
+func (g *Group) Cancel() {
+	for _, t := range g.tweens {
+		t.Cancel()
+	}
+	g.tweens = g.tweens[:0]
+}
+
+// Len returns the number of running tweeners.
+func (g *Group) Len() int {
+	return len(g.tweens)
+}
+
+// Shake is a random offset that decays over time, e.g. to shake the
+// camera. The zero value is at rest.
+type Shake struct {
+	amp *Tween
+}
+
+// Start shakes with the given amplitude in pixels, decaying to rest
+// over d.
+func (s *Shake) Start(amplitude float64, d time.Duration) {
+	s.amp = New(amplitude, 0, d, OutQuad)
+}
+
+// Update advances the shake by dt, and returns the current offset.
+func (s *Shake) Update(dt time.Duration) (x, y float64) {
+	if s.amp == nil {
+		return 0, 0
+	}
+	if !s.amp.Update(dt) {
+		s.amp = nil
+		return 0, 0
+	}
+	a := s.amp.Value()
+	return (rand.Float64()*2 - 1) * a, (rand.Float64()*2 - 1) * a
+}
@@ -0,0 +1,151 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package tween
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEases(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ease Ease
+	}{
+		{"linear", Linear},
+		{"in quad", InQuad},
+		{"out quad", OutQuad},
+		{"in out quad", InOutQuad},
+		{"out cubic", OutCubic},
+		{"in out cubic", InOutCubic},
+		{"out back", OutBack},
+		{"out elastic", OutElastic},
+		{"out bounce", OutBounce},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if v := tc.ease(0); math.Abs(v) > 1e-9 {
+				t.Fatalf("got %v at the start, want 0", v)
+			}
+			if v := tc.ease(1); math.Abs(v-1) > 1e-9 {
+				t.Fatalf("got %v at the end, want 1", v)
+			}
+		})
+	}
+}
+
+func TestTween(t *testing.T) {
+	ms := time.Millisecond
+	for _, tc := range []struct {
+		name    string
+		ease    Ease
+		updates []time.Duration
+		value   float64
+		running bool
+	}{
+		{"start", nil, []time.Duration{0}, 10, true},
+		{"half", nil, []time.Duration{50 * ms}, 15, true},
+		{"eased", InQuad, []time.Duration{50 * ms}, 12.5, true},
+		{"end", nil, []time.Duration{60 * ms, 60 * ms}, 20, false},
+		{"past the end", nil, []time.Duration{time.Second}, 20, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tw := New(10, 20, 100*ms, tc.ease)
+			var got float64
+			tw.OnUpdate = func(v float64) { got = v }
+			running := true
+			for _, dt := range tc.updates {
+				running = tw.Update(dt)
+			}
+			if running != tc.running || got != tc.value || tw.Value() != tc.value {
+				t.Fatalf("got %v running %v, want %v running %v", got, running, tc.value, tc.running)
+			}
+		})
+	}
+}
+
+func TestTweenDone(t *testing.T) {
+	done := 0
+	tw := New(0, 1, time.Second, nil)
+	tw.OnDone = func() { done++ }
+	tw.Update(time.Second)
+	tw.Update(time.Second)
+	if done != 1 || !tw.Done() {
+		t.Fatalf("got done %d times, want once", done)
+	}
+
+	tw = New(0, 1, time.Second, nil)
+	tw.OnDone = func() { done++ }
+	tw.Cancel()
+	if tw.Update(time.Second) || done != 1 {
+		t.Fatalf("got a cancelled tween running or done, want neither")
+	}
+}
+
+func TestSequence(t *testing.T) {
+	var steps []string
+	var v float64
+	a := New(0, 10, time.Second, nil)
+	a.OnUpdate = func(x float64) { v = x }
+	s := Seq(
+		a,
+		Call(func() { steps = append(steps, "call") }),
+		Wait(time.Second),
+	)
+	s.OnDone = func() { steps = append(steps, "done") }
+	if !s.Update(1500*time.Millisecond) || v != 10 {
+		t.Fatalf("got %v, want 10 with the sequence running", v)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("got steps %q, want the call only", steps)
+	}
+	if s.Update(time.Second) || !s.Done() {
+		t.Fatal("got the sequence running after its last step")
+	}
+	if len(steps) != 2 || steps[1] != "done" {
+		t.Fatalf("got steps %q, want call and done", steps)
+	}
+}
+
+func TestSequenceCancel(t *testing.T) {
+	called := false
+	s := Seq(Wait(time.Second), Call(func() { called = true }))
+	s.OnDone = func() { called = true }
+	s.Update(100 * time.Millisecond)
+	s.Cancel()
+	if s.Update(time.Hour) || called {
+		t.Fatal("got a cancelled sequence running or calling back")
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var g Group
+	g.Add(New(0, 1, time.Second, nil))
+	g.Add(New(0, 1, 2*time.Second, nil))
+	g.Update(1500 * time.Millisecond)
+	if g.Len() != 1 {
+		t.Fatalf("got %d tweens, want 1 still running", g.Len())
+	}
+	g.Cancel()
+	if g.Len() != 0 {
+		t.Fatalf("got %d tweens after cancel, want none", g.Len())
+	}
+}
+
+func TestShake(t *testing.T) {
+	var s Shake
+	if x, y := s.Update(time.Millisecond); x != 0 || y != 0 {
+		t.Fatalf("got %v,%v at rest, want 0,0", x, y)
+	}
+	s.Start(10, time.Second)
+	x, y := s.Update(100 * time.Millisecond)
+	if math.Abs(x) > 10 || math.Abs(y) > 10 {
+		t.Fatalf("got %v,%v, want within the amplitude", x, y)
+	}
+	if x, y := s.Update(time.Second); x != 0 || y != 0 {
+		t.Fatalf("got %v,%v after the shake, want 0,0", x, y)
+	}
+}
@@ -0,0 +1,385 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+// Package ui provides the immediate-mode UI toolkit of the game menus:
+// labels, buttons, toggles, sliders and lists, laid out in a column and
+// drawn every frame by the code that handles them, e.g.
+//
+//	u.Begin(r)
+//	u.Layout(x, y, w)
+//	if u.Button("Play") {
+//		play()
+//	}
+//	u.End()
+//
+// One of the widgets has the focus, moved with the keyboard or a
+// gamepad (up and down), or with the mouse. The widget focused takes
+// left and right, e.g. to change a slider, and activate, e.g. to press
+// a button. The SDL and wasm versions of the game draw the widgets
+// with their Renderer.
+package ui
+
+import "github.com/fiorix/cat-o-licious/locale"
+
+// Color is a color, with alpha.
+type Color struct{ R, G, B, A uint8 }
+
+// Colors of the widgets.
+var (
+	White     = Color{255, 255, 255, 255}
+	Gold      = Color{255, 215, 0, 255}
+	Gray      = Color{128, 128, 128, 255}
+	Red       = Color{255, 60, 60, 255}
+	highlight = Color{255, 255, 255, 40} // background of the widget focused
+)
+
+// Rect is a rectangle.
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Contains returns true if x, y is in the rectangle.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
+// Size is the size of text.
+type Size int
+
+// Sizes of text.
+const (
+	Normal Size = iota
+	Small
+	Large // titles
+)
+
+// Align is the alignment of text in the column of the layout.
+type Align int
+
+// Alignments of text.
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// Renderer draws the widgets.
+type Renderer interface {
+	// MeasureText returns the size of the text drawn.
+	MeasureText(text string, size Size) (w, h int)
+
+	// DrawText draws the text with its top left corner at x, y.
+	DrawText(text string, size Size, c Color, x, y int)
+
+	// FillRect fills the rectangle.
+	FillRect(r Rect, c Color)
+}
+
+// Nav is a navigation of the widgets, from the keyboard or a gamepad.
+type Nav int
+
+// Navigations of the widgets.
+const (
+	NavNone Nav = iota
+	NavUp
+	NavDown
+	NavLeft
+	NavRight
+	NavActivate
+)
+
+// pad is the padding of the widgets, above and below their text.
+const pad = 4
+
+// UI is the state of the widgets between frames: the widget focused,
+// and the input for the next frame. The zero value has the first
+// widget focused.
+type UI struct {
+	// input for the next frame
+	nextNav   Nav
+	nextMouse bool // moved or clicked
+	nextClick bool
+	nextX     int
+	nextY     int
+
+	// input of the frame
+	nav          Nav
+	mouse, click bool
+	mx, my       int
+
+	r     Renderer
+	focus int // widget focused
+	n     int // focusable widgets so far in the frame
+	count int // focusable widgets in the last frame
+
+	x, y, w int // column of the layout, and where the next widget goes
+}
+
+// Nav navigates the widgets in the next frame.
+func (u *UI) Nav(n Nav) {
+	u.nextNav = n
+}
+
+// Mouse moves the mouse to x, y in the next frame, and clicks there
+// if click.
+func (u *UI) Mouse(x, y int, click bool) {
+	u.nextMouse = true
+	u.nextClick = u.nextClick || click
+	u.nextX, u.nextY = x, y
+}
+
+// Focus focuses the widget i, in the order drawn from 0.
+func (u *UI) Focus(i int) {
+	u.focus = i
+}
+
+// Begin begins a frame of widgets drawn with r.
+func (u *UI) Begin(r Renderer) {
+	u.r = r
+	u.nav, u.nextNav = u.nextNav, NavNone
+	u.mouse, u.click = u.nextMouse, u.nextClick
+	u.mx, u.my = u.nextX, u.nextY
+	u.nextMouse, u.nextClick = false, false
+	u.n = 0
+}
+
+// End ends the frame, and moves the focus up or down if the widget
+// focused didn't take the navigation.
+func (u *UI) End() {
+	u.count = u.n
+	if u.count == 0 {
+		u.focus = 0
+		return
+	}
+	switch u.nav {
+	case NavUp:
+		u.focus--
+	case NavDown:
+		u.focus++
+	}
+	u.focus = (u.focus%u.count + u.count) % u.count
+	u.nav = NavNone
+}
+
+// Layout lays out the next widgets in a column from x, y of width w.
+func (u *UI) Layout(x, y, w int) {
+	u.x, u.y, u.w = x, y, w
+}
+
+// Y returns where the next widget goes.
+func (u *UI) Y() int {
+	return u.y
+}
+
+// Space leaves h pixels of space before the next widget.
+func (u *UI) Space(h int) {
+	u.y += h
+}
+
+// row returns the rectangle of the next widget, of height h.
+func (u *UI) row(h int) Rect {
+	r := Rect{X: u.x, Y: u.y, W: u.w, H: h}
+	u.y += h
+	return r
+}
+
+// lineHeight returns the height of a line of text in the size.
+func (u *UI) lineHeight(size Size) int {
+	_, h := u.r.MeasureText("Mg", size)
+	return h
+}
+
+// text draws text aligned in r, vertically centered.
+func (u *UI) text(text string, size Size, c Color, r Rect, align Align) {
+	if text == "" {
+		return
+	}
+	w, h := u.r.MeasureText(text, size)
+	x := r.X
+	switch align {
+	case AlignCenter:
+		x += (r.W - w) / 2
+	case AlignRight:
+		x += r.W - w
+	}
+	u.r.DrawText(text, size, c, x, r.Y+(r.H-h)/2)
+}
+
+// focusable adds a focusable widget in r, focused by the mouse over
+// it, and returns true if it has the focus.
+func (u *UI) focusable(r Rect) bool {
+	i := u.n
+	u.n++
+	if u.mouse && r.Contains(u.mx, u.my) {
+		u.focus = i
+	}
+	if i != u.focus {
+		return false
+	}
+	u.r.FillRect(r, highlight)
+	return true
+}
+
+// take returns true, and takes the navigation, if it's n.
+func (u *UI) take(n Nav) bool {
+	if u.nav != n {
+		return false
+	}
+	u.nav = NavNone
+	return true
+}
+
+// clicked returns true if r was clicked.
+func (u *UI) clicked(r Rect) bool {
+	return u.click && r.Contains(u.mx, u.my)
+}
+
+// Label draws text, which can't be focused.
+func (u *UI) Label(text string, size Size, c Color, align Align) {
+	r := u.row(u.lineHeight(size))
+	u.text(text, size, c, r, align)
+}
+
+// Row draws text on the left and on the right of a row, which can't
+// be focused, e.g. a row of a table.
+func (u *UI) Row(left, right string, c Color) {
+	r := u.row(u.lineHeight(Normal))
+	u.text(left, Normal, c, r, AlignLeft)
+	u.text(right, Normal, c, r, AlignRight)
+}
+
+// Button draws a button, and returns true if pressed.
+func (u *UI) Button(text string) bool {
+	r := u.row(u.lineHeight(Normal) + 2*pad)
+	c := White
+	focused := u.focusable(r)
+	if focused {
+		c = Gold
+	}
+	u.text(text, Normal, c, r, AlignCenter)
+	return focused && (u.take(NavActivate) || u.clicked(r))
+}
+
+// Toggle draws a toggle of on, and returns true if toggled.
+func (u *UI) Toggle(text string, on *bool) bool {
+	r := u.row(u.lineHeight(Normal) + 2*pad)
+	c := White
+	focused := u.focusable(r)
+	if focused {
+		c = Gold
+	}
+	state := locale.T("Off")
+	if *on {
+		state = locale.T("On")
+	}
+	u.text(text, Normal, c, r, AlignLeft)
+	u.text(state, Normal, c, r, AlignRight)
+	if focused && (u.take(NavActivate) || u.take(NavLeft) || u.take(NavRight) || u.clicked(r)) {
+		*on = !*on
+		return true
+	}
+	return false
+}
+
+// Slider draws a slider of v, from 0 to 1, moved by step with left and
+// right, and returns true if moved.
+func (u *UI) Slider(text string, v *float64, step float64) bool {
+	r := u.row(u.lineHeight(Normal) + 2*pad)
+	c := White
+	focused := u.focusable(r)
+	if focused {
+		c = Gold
+	}
+	u.text(text, Normal, c, r, AlignLeft)
+	bar := Rect{X: r.X + r.W/2, Y: r.Y + r.H/2 - pad, W: r.W / 2, H: 2 * pad}
+	old := *v
+	switch {
+	case !focused:
+	case u.take(NavLeft):
+		*v -= step
+	case u.take(NavRight):
+		*v += step
+	case u.click && Rect{X: bar.X, Y: r.Y, W: bar.W, H: r.H}.Contains(u.mx, u.my):
+		*v = float64(u.mx-bar.X) / float64(bar.W-1)
+	}
+	*v = min(max(*v, 0), 1)
+	u.r.FillRect(bar, Gray)
+	bar.W = int(float64(bar.W) * *v)
+	u.r.FillRect(bar, c)
+	return *v != old
+}
+
+// Choice draws a choice of one of the options, changed with left and
+// right or activate, and returns true if changed.
+func (u *UI) Choice(text string, options []string, i *int) bool {
+	r := u.row(u.lineHeight(Normal) + 2*pad)
+	c := White
+	focused := u.focusable(r)
+	if focused {
+		c = Gold
+	}
+	u.text(text, Normal, c, r, AlignLeft)
+	if len(options) == 0 {
+		return false
+	}
+	old := *i
+	switch {
+	case !focused:
+	case u.take(NavLeft):
+		*i--
+	case u.take(NavRight), u.take(NavActivate), u.clicked(r):
+		*i++
+	}
+	*i = (*i%len(options) + len(options)) % len(options)
+	u.text("< "+options[*i]+" >", Normal, c, r, AlignRight)
+	return *i != old
+}
+
+// Item is an item of a list, with text on its left and on its right,
+// e.g. a name and a score.
+type Item struct {
+	Left, Right string
+}
+
+// List draws a list of items, rows of them at a time, with the item
+// sel selected, moved with up and down, and returns true if the item
+// selected is activated. Up and down move the focus out of the list
+// from its first and last items.
+func (u *UI) List(items []Item, sel *int, rows int) bool {
+	h := u.lineHeight(Normal) + 2*pad
+	r := u.row(h * rows)
+	focused := u.focusable(r)
+	switch {
+	case !focused:
+	case *sel > 0 && u.take(NavUp):
+		*sel--
+	case *sel < len(items)-1 && u.take(NavDown):
+		*sel++
+	}
+	*sel = min(max(*sel, 0), max(len(items)-1, 0))
+	// scroll the list to keep the item selected in view
+	first := 0
+	if *sel >= rows {
+		first = *sel - rows + 1
+	}
+	activated := focused && u.take(NavActivate)
+	for i := first; i < len(items) && i < first+rows; i++ {
+		ir := Rect{X: r.X, Y: r.Y + (i-first)*h, W: r.W, H: h}
+		if u.clicked(ir) {
+			*sel, activated = i, true
+		}
+		c := White
+		if i == *sel {
+			c = Gold
+			if !focused {
+				c = Gray
+			}
+		}
+		tr := Rect{X: ir.X + pad, Y: ir.Y, W: ir.W - 2*pad, H: ir.H}
+		u.text(items[i].Left, Normal, c, tr, AlignLeft)
+		u.text(items[i].Right, Normal, c, tr, AlignRight)
+	}
+	return activated
+}
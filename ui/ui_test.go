@@ -0,0 +1,211 @@
+// Copyright 2017  The cat-o-licious authors.
+//
+// Licensed under GNU General Public License 3.0.
+// Some rights reserved. See LICENSE, AUTHORS.
+
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+// textRenderer is a renderer of text 10 pixels per character wide and
+// 20 high, which records the text drawn.
+type textRenderer struct {
+	drawn []string
+}
+
+func (tr *textRenderer) MeasureText(text string, size Size) (w, h int) {
+	return 10 * len(text), 20
+}
+
+func (tr *textRenderer) DrawText(text string, size Size, c Color, x, y int) {
+	tr.drawn = append(tr.drawn, text)
+}
+
+func (tr *textRenderer) FillRect(r Rect, c Color) {}
+
+// rowHeight is the height of the rows of buttons, of the textRenderer.
+const rowHeight = 20 + 2*pad
+
+// menu draws a title and three buttons in a column at 0, 0, and
+// returns the button pressed, or -1.
+func menu(u *UI) int {
+	u.Begin(&textRenderer{})
+	defer u.End()
+	u.Layout(0, 0, 100)
+	u.Label("Title", Large, Gold, AlignCenter)
+	pressed := -1
+	for i, text := range []string{"A", "B", "C"} {
+		if u.Button(text) {
+			pressed = i
+		}
+	}
+	return pressed
+}
+
+func TestFocus(t *testing.T) {
+	var u UI
+	for _, tc := range []struct {
+		name    string
+		nav     Nav
+		focus   int
+		pressed int
+	}{
+		{"first", NavNone, 0, -1},
+		{"down", NavDown, 1, -1},
+		{"down again", NavDown, 2, -1},
+		{"wraps down", NavDown, 0, -1},
+		{"wraps up", NavUp, 2, -1},
+		{"up", NavUp, 1, -1},
+		{"left", NavLeft, 1, -1},
+		{"activate", NavActivate, 1, 1},
+		{"once", NavNone, 1, -1},
+	} {
+		u.Nav(tc.nav)
+		if pressed := menu(&u); pressed != tc.pressed || u.focus != tc.focus {
+			t.Fatalf("%s: got focus %d and %d pressed, want %d and %d", tc.name, u.focus, pressed, tc.focus, tc.pressed)
+		}
+	}
+}
+
+func TestMouse(t *testing.T) {
+	var u UI
+	menu(&u)
+	// the title is a row of 20, then the buttons
+	u.Mouse(50, 20+rowHeight*2+1, false)
+	if pressed := menu(&u); pressed != -1 || u.focus != 2 {
+		t.Fatalf("got focus %d and %d pressed, want 2 and none", u.focus, pressed)
+	}
+	u.Mouse(50, 20+rowHeight+1, true)
+	if pressed := menu(&u); pressed != 1 || u.focus != 1 {
+		t.Fatalf("got focus %d and %d pressed, want 1 and 1", u.focus, pressed)
+	}
+	u.Mouse(50, 1, true)
+	if pressed := menu(&u); pressed != -1 || u.focus != 1 {
+		t.Fatalf("got focus %d and %d pressed after clicking the title, want 1 and none", u.focus, pressed)
+	}
+}
+
+func TestFocusFewerWidgets(t *testing.T) {
+	var u UI
+	u.Focus(5)
+	menu(&u)
+	if u.focus != 2 {
+		t.Fatalf("got focus %d, want 2", u.focus)
+	}
+	u.Begin(&textRenderer{})
+	u.End()
+	if u.focus != 0 {
+		t.Fatalf("got focus %d without widgets, want 0", u.focus)
+	}
+}
+
+func TestWidgets(t *testing.T) {
+	var (
+		u   UI
+		on  bool
+		v   = .5
+		opt int
+	)
+	frame := func(n Nav) (toggled, moved, changed bool) {
+		u.Nav(n)
+		u.Begin(&textRenderer{})
+		defer u.End()
+		toggled = u.Toggle("Toggle", &on)
+		moved = u.Slider("Slider", &v, .25)
+		changed = u.Choice("Choice", []string{"x", "y", "z"}, &opt)
+		return toggled, moved, changed
+	}
+	for _, tc := range []struct {
+		name                    string
+		nav                     Nav
+		toggled, moved, changed bool
+		on                      bool
+		v                       float64
+		opt                     int
+	}{
+		{"toggle", NavActivate, true, false, false, true, .5, 0},
+		{"toggle right", NavRight, true, false, false, false, .5, 0},
+		{"to the slider", NavDown, false, false, false, false, .5, 0},
+		{"slider right", NavRight, false, true, false, false, .75, 0},
+		{"slider right again", NavRight, false, true, false, false, 1, 0},
+		{"slider at the end", NavRight, false, false, false, false, 1, 0},
+		{"slider left", NavLeft, false, true, false, false, .75, 0},
+		{"slider activate", NavActivate, false, false, false, false, .75, 0},
+		{"to the choice", NavDown, false, false, false, false, .75, 0},
+		{"choice left wraps", NavLeft, false, false, true, false, .75, 2},
+		{"choice activate wraps", NavActivate, false, false, true, false, .75, 0},
+		{"choice right", NavRight, false, false, true, false, .75, 1},
+	} {
+		toggled, moved, changed := frame(tc.nav)
+		if toggled != tc.toggled || moved != tc.moved || changed != tc.changed {
+			t.Fatalf("%s: got toggled %v moved %v changed %v, want %v %v %v",
+				tc.name, toggled, moved, changed, tc.toggled, tc.moved, tc.changed)
+		}
+		if on != tc.on || v != tc.v || opt != tc.opt {
+			t.Fatalf("%s: got on %v v %v opt %d, want %v %v %d", tc.name, on, v, opt, tc.on, tc.v, tc.opt)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	var u UI
+	items := []Item{{"a", "1"}, {"b", "2"}, {"c", "3"}, {"d", "4"}}
+	sel := 0
+	var r *textRenderer
+	frame := func(n Nav) (activated, back bool) {
+		u.Nav(n)
+		r = &textRenderer{}
+		u.Begin(r)
+		defer u.End()
+		u.Layout(0, 0, 100)
+		activated = u.List(items, &sel, 2)
+		back = u.Button("Back")
+		return activated, back
+	}
+	for _, tc := range []struct {
+		name      string
+		nav       Nav
+		sel       int
+		focus     int
+		activated bool
+		drawn     []string
+	}{
+		{"first", NavNone, 0, 0, false, []string{"a", "1", "b", "2", "Back"}},
+		{"down", NavDown, 1, 0, false, []string{"a", "1", "b", "2", "Back"}},
+		{"scrolls down", NavDown, 2, 0, false, []string{"b", "2", "c", "3", "Back"}},
+		{"activate", NavActivate, 2, 0, true, []string{"b", "2", "c", "3", "Back"}},
+		{"last", NavDown, 3, 0, false, []string{"c", "3", "d", "4", "Back"}},
+		{"out of the list", NavDown, 3, 1, false, []string{"c", "3", "d", "4", "Back"}},
+		{"back in", NavUp, 3, 0, false, []string{"c", "3", "d", "4", "Back"}},
+		{"up", NavUp, 2, 0, false, []string{"b", "2", "c", "3", "Back"}},
+	} {
+		activated, back := frame(tc.nav)
+		if sel != tc.sel || u.focus != tc.focus || activated != tc.activated || back {
+			t.Fatalf("%s: got sel %d focus %d activated %v back %v, want %d %d %v false",
+				tc.name, sel, u.focus, activated, back, tc.sel, tc.focus, tc.activated)
+		}
+		if !reflect.DeepEqual(r.drawn, tc.drawn) {
+			t.Fatalf("%s: got %q drawn, want %q", tc.name, r.drawn, tc.drawn)
+		}
+	}
+	// click the first row shown, b
+	u.Mouse(50, 1, true)
+	if activated, _ := frame(NavNone); !activated || sel != 1 {
+		t.Fatalf("got sel %d activated %v after a click, want 1 and true", sel, activated)
+	}
+}
+
+func TestListEmpty(t *testing.T) {
+	var u UI
+	sel := 3
+	u.Nav(NavDown)
+	u.Begin(&textRenderer{})
+	u.List(nil, &sel, 2)
+	u.End()
+	if sel != 0 {
+		t.Fatalf("got sel %d, want 0", sel)
+	}
+}
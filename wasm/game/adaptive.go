@@ -0,0 +1,43 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Adaptive ...
+type Adaptive interface {
+	Record(now time.Time, o difficulty.Outcome)
+	// Apply updates the adjustment and returns p adjusted to the player.
+	Apply(now time.Time, p difficulty.Params) difficulty.Params
+	Draw(now time.Time, canvas media.Canvas)
+	Reset()
+}
+
+type adaptive struct {
+	*difficulty.Adaptive
+	show bool
+}
+
+// NewAdaptive ...
+func NewAdaptive(show bool) Adaptive {
+	return &adaptive{Adaptive: difficulty.NewAdaptive(), show: show}
+}
+
+func (a *adaptive) Apply(now time.Time, p difficulty.Params) difficulty.Params {
+	if a.Update(now) && a.show {
+		log.Println(a.Status(now))
+	}
+	return a.Adaptive.Apply(p)
+}
+
+func (a *adaptive) Draw(now time.Time, canvas media.Canvas) {
+	if !a.show {
+		return
+	}
+	canvas.SetFont(font(24), "white")
+	canvas.DrawText(a.Status(now), int(float64(canvas.ClientW())*.05), canvas.ClientH()-12)
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"log"
+
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/sound"
+	"github.com/fiorix/cat-o-licious/store"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// sfxVoices is the number of voices of the sound effects that can
+// overlap, like the player's.
+const sfxVoices = 4
+
+// Audio controls the volume of the music and the sound effects, and
+// keeps the audio settings between runs.
+type Audio interface {
+	Settings() settings.Audio
+	Set(a settings.Audio)
+	ToggleMute()
+	Step(n int)
+}
+
+type audio struct {
+	scene Scene
+	st    store.Store
+	s     *settings.Settings
+	saved *settings.Settings
+}
+
+// NewAudio applies the audio settings of s to the scene. Setting the
+// audio sets it in s and saved, and saves saved to the store.
+func NewAudio(scene Scene, st store.Store, s, saved *settings.Settings) Audio {
+	a := &audio{scene: scene, st: st, s: s, saved: saved}
+	a.apply()
+	return a
+}
+
+func (a *audio) Settings() settings.Audio {
+	return a.s.Audio
+}
+
+func (a *audio) Set(s settings.Audio) {
+	a.s.Audio = s
+	a.saved.Audio = s
+	a.apply()
+	if err := a.saved.Save(a.st); err != nil {
+		log.Println("failed to save settings:", err)
+	}
+}
+
+func (a *audio) ToggleMute() {
+	s := a.s.Audio
+	s.Muted = !s.Muted
+	a.Set(s)
+}
+
+func (a *audio) Step(n int) {
+	s := a.s.Audio
+	s.Step(n)
+	a.Set(s)
+}
+
+func (a *audio) apply() {
+	a.scene.Music().SetVolume(a.s.Audio.MusicVolume())
+	a.scene.SetSFXVolume(a.s.Audio.SFXVolume())
+}
+
+// loadSound loads the sound effect from uri, or synthesizes the named
+// sound preset if it fails to load.
+func loadSound(uri, preset string, voices int) (*media.Sound, error) {
+	s, err := media.NewSound(uri, voices)
+	if err == nil {
+		return s, nil
+	}
+	p, ok := sound.Presets[preset]
+	if !ok {
+		return nil, err
+	}
+	log.Printf("%v, using the synthesized %q sound", err, preset)
+	return media.NewSound(media.DataURI("audio/wav", p.WAV()), voices)
+}
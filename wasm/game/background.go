@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/theme"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Background draws the layers and the weather of a theme behind the
+// rest of the scene.
+type Background interface {
+	// Draw draws the background. The focus is the lateral position
+	// of the player, for the parallax of the layers.
+	Draw(now time.Time, canvas media.Canvas, focus int)
+}
+
+type background struct {
+	theme   *theme.Theme
+	layers  []media.Image
+	cloud   *media.Image // nil for themes without clouds
+	weather *theme.Weather
+	start   time.Time
+	last    time.Time
+}
+
+// NewBackground ...
+func NewBackground(t *theme.Theme) (Background, error) {
+	b := &background{theme: t, weather: theme.NewWeather(t)}
+	for i, l := range t.Layers {
+		img, err := media.NewImage(l.Image, fmt.Sprintf("layer %d", i))
+		if err != nil {
+			return nil, err
+		}
+		b.layers = append(b.layers, img)
+	}
+	if t.Cloud != "" {
+		img, err := media.NewImage(t.Cloud, "cloud")
+		if err != nil {
+			return nil, err
+		}
+		b.cloud = &img
+	}
+	return b, nil
+}
+
+func (b *background) Draw(now time.Time, canvas media.Canvas, focus int) {
+	if b.start.IsZero() {
+		b.start = now
+	}
+	dt := frameTime(b.last, now)
+	b.last = now
+	t := now.Sub(b.start)
+	vw, vh := canvas.ClientW(), canvas.ClientH()
+	for i, l := range b.theme.Layers {
+		b.drawLayer(canvas, b.layers[i], l, t, float64(focus-vw/2))
+	}
+	var cw, ch int
+	if b.cloud != nil {
+		cw, ch = b.cloud.W(), b.cloud.H()
+	}
+	b.weather.Update(dt, float64(vw), float64(vh), float64(cw))
+	for _, c := range b.weather.Clouds() {
+		canvas.DrawImage(*b.cloud, media.Rect{
+			X: int(c.X),
+			Y: int(c.Y),
+			W: int(float64(cw) * c.Scale),
+			H: int(float64(ch) * c.Scale),
+		})
+	}
+	for _, s := range b.weather.Streaks() {
+		v := math.Hypot(s.VX, s.VY)
+		x2 := s.X + s.VX/v*s.Len
+		y2 := s.Y + s.VY/v*s.Len
+		canvas.DrawLine(int(s.X), int(s.Y), int(x2), int(y2), "rgba(180,200,255,.6)")
+	}
+	if dim := b.weather.Dim(); dim > 0 {
+		canvas.SetAlpha(dim)
+		canvas.FillRect(media.Rect{W: vw, H: vh}, "black")
+		canvas.SetAlpha(1)
+	}
+}
+
+// drawLayer draws the layer scrolled after time t, repeating its
+// image across the canvas.
+func (b *background) drawLayer(canvas media.Canvas, img media.Image, l theme.Layer, t time.Duration, focus float64) {
+	vw := float64(canvas.ClientW())
+	y, h := l.Rect(float64(canvas.ClientH()))
+	w := float64(img.W()) * h / float64(img.H())
+	if l.Stretch {
+		w = vw
+	}
+	off := math.Mod(l.Offset(t, focus), w)
+	if off < 0 {
+		off += w
+	}
+	for x := -off; x < vw; x += w {
+		canvas.DrawImage(img, media.Rect{
+			X: int(x),
+			Y: int(y),
+			W: int(math.Ceil(w)),
+			H: int(math.Ceil(h)),
+		})
+	}
+}
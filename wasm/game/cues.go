@@ -0,0 +1,53 @@
+package game
+
+import (
+	"github.com/fiorix/cat-o-licious/sound"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// cueVolume is the volume of the cues, relative to the other sound
+// effects, so they stay in the background.
+const cueVolume = 1. / 3
+
+// Cues play subtle sounds for the drops approaching the player,
+// panned to where they fall.
+type Cues interface {
+	Play(drops []Drop, canvas media.Canvas)
+	SetVolume(v float64)
+}
+
+type cues struct {
+	good *media.Sound
+	bad  *media.Sound
+}
+
+// NewCues ...
+func NewCues() (Cues, error) {
+	good, err := loadSound("assets/snd/cue_good.wav", "cue_good", sfxVoices)
+	if err != nil {
+		return nil, err
+	}
+	bad, err := loadSound("assets/snd/cue_bad.wav", "cue_bad", sfxVoices)
+	if err != nil {
+		return nil, err
+	}
+	c := &cues{good: good, bad: bad}
+	c.SetVolume(1)
+	return c, nil
+}
+
+func (c *cues) Play(drops []Drop, canvas media.Canvas) {
+	for _, d := range drops {
+		cue := c.good
+		if d.Points() < 0 {
+			cue = c.bad
+		}
+		pos := d.Pos()
+		cue.PlayAt(sound.Pan(float64(pos.X+pos.W/2), float64(canvas.ClientW())))
+	}
+}
+
+func (c *cues) SetVolume(v float64) {
+	c.good.SetVolume(v * cueVolume)
+	c.bad.SetVolume(v * cueVolume)
+}
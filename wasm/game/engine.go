@@ -1,9 +1,21 @@
 package game
 
 import (
+	"log"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/screen"
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
 	"github.com/fiorix/cat-o-licious/wasm/media"
 )
 
@@ -15,12 +27,29 @@ type Engine interface {
 func (e *engine) unlockAudio() {
 	if atomic.CompareAndSwapInt32(&e.audioUnlocked, 0, 1) {
 		e.s.EnableAudio()
+		e.toasts.EnableAudio()
 	}
 }
 
 type engine struct {
 	c             media.Canvas
 	s             Scene
+	a             Audio
+	m             Menu
+	st            store.Store
+	picker        *profile.Picker
+	prof          *profile.Profile
+	stats         *stats.Stats
+	last          *stats.Session // last game of the profile, nil if none
+	defs          achievement.Defs
+	ach           *achievement.Achievements
+	toasts        Toasts
+	set           *settings.Settings   // saved, overridden by the query
+	saved         *settings.Settings   // saved, with the changes of the player
+	adaptive      Adaptive             // enabled in the settings
+	cues          Cues                 // enabled in the settings
+	screens       screen.Stack[Screen] // the one on top gets the input
+	holding       int32                // side of the screen held down
 	audioUnlocked int32
 }
 
@@ -28,62 +57,295 @@ type engine struct {
 func NewEngine(canvasID string) (Engine, error) {
 	canvas := media.GetCanvas(canvasID)
 
-	canvas.SetFont("40px Score", "red")
-	canvas.DrawText("Loading assets, please wait...", 20, 50)
+	setLocale()
+	canvas.SetFont(font(40), "red")
+	canvas.DrawText(locale.T("Loading assets, please wait..."), 20, 50)
 
-	canvas.SetFont("80px Score", "red")
+	canvas.SetFont(font(80), "red")
 
-	scene, err := NewScene()
+	st := media.LocalStorage("cat-o-licious/")
+	saved, err := settings.Load(st)
+	if err != nil {
+		log.Println("failed to load settings, using defaults:", err)
+	}
+	set := new(settings.Settings)
+	*set = *saved
+	overrideSettings(set)
+	if err = set.Validate(); err != nil {
+		return nil, err
+	}
+	name := set.Gameplay.Difficulty
+	if name == "" {
+		name = difficulty.Default
+	}
+	d, err := difficulty.Lookup(name)
 	if err != nil {
 		return nil, err
 	}
 
-	e := &engine{
-		c: canvas,
-		s: scene,
+	a := NewAdaptive(media.QueryParam("adaptive") == "show")
+	cues, err := NewCues()
+	if err != nil {
+		return nil, err
+	}
+
+	scene, err := NewScene(d, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	scene.SetReduceMotion(set.Accessibility.ReduceMotion)
+	if set.Accessibility.AudioCues {
+		scene.SetCues(cues)
 	}
 
+	ps, err := profile.Load(st)
+	if err != nil {
+		log.Println("failed to load profiles, starting over:", err)
+	}
+	ss, err := stats.Load(st)
+	if err != nil {
+		log.Println("failed to load stats, starting over:", err)
+	}
+	defs, err := loadAchievements(achievement.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	if err = defs.Check(scene.Names()); err != nil {
+		return nil, err
+	}
+	ach, err := achievement.Load(st)
+	if err != nil {
+		log.Println("failed to load achievements, starting over:", err)
+	}
+	toasts, err := NewToasts()
+	if err != nil {
+		return nil, err
+	}
+	e := &engine{
+		c:        canvas,
+		s:        scene,
+		a:        NewAudio(scene, st, set, saved),
+		m:        NewMenu(st),
+		st:       st,
+		picker:   profile.NewPicker(ps),
+		stats:    ss,
+		defs:     defs,
+		ach:      ach,
+		toasts:   toasts,
+		set:      set,
+		saved:    saved,
+		adaptive: a,
+		cues:     cues,
+	}
+	e.subscribe()
+	base := baseScreen{e: e}
+	e.screens.Push(&titleScreen{uiScreen: uiScreen{baseScreen: base}})
+	e.screens.Push(&profileScreen{uiScreen: uiScreen{baseScreen: base}, startup: true})
 	return e, nil
 }
 
+// setLocale sets the locale of the game to the language of the
+// browser, or of the query of the page, e.g. ?lang=pt, and loads its
+// font.
+func setLocale() {
+	name := media.QueryParam("lang")
+	if name == "" {
+		name = media.Language()
+	}
+	l, err := locale.Load(name, func(name string) ([]byte, error) {
+		return media.Fetch(locale.DefaultDir + "/" + name + ".json")
+	})
+	if err != nil {
+		log.Println("failed to load locale:", err)
+	}
+	locale.Set(l)
+	loadFont(l)
+}
+
+// subscribe subscribes the stats and achievements of the profile to
+// the events of the game.
+func (e *engine) subscribe() {
+	bus := e.s.Events()
+	event.Subscribe(bus, func(DropCaught) { e.achieve(time.Now()) })
+	event.Subscribe(bus, func(ScoreChanged) { e.achieve(time.Now()) })
+	event.Subscribe(bus, func(ev StateChanged) {
+		now := time.Now()
+		switch ev.State {
+		case GameStarted:
+			e.ach.Profile(e.prof.Name).Play(now)
+			e.achieve(now)
+			e.saveAchievements()
+		case GameOver:
+			e.achieve(now)
+			e.last = e.s.Stats()
+			e.stats.Profile(e.prof.Name).Add(e.last)
+			if err := e.stats.Save(e.st); err != nil {
+				log.Println("failed to save stats:", err)
+			}
+		}
+	})
+}
+
 func (e *engine) Run() {
 	const fps = 30
-	const playerSpeed = 20
 
 	media.OnKey(media.KeyDown, func(key string) {
 		e.unlockAudio()
-		switch key {
-		case "a", "A", "ArrowLeft":
-			e.s.Player().Move(Left, playerSpeed)
-		case "d", "D", "ArrowRight":
-			e.s.Player().Move(Right, playerSpeed)
-		}
+		e.key(key)
+	})
+
+	// pause the music while the page is hidden, and keep it paused
+	// with the game
+	media.OnVisibility(func(hidden bool) {
+		e.s.Music().SetPaused(hidden || e.s.Paused())
 	})
 
-	var clicking int32
 	handleTouch := true
 	e.c.OnMouse(handleTouch, func(click media.MouseClick, x, y int) {
 		switch click {
 		case media.MouseDown:
 			e.unlockAudio()
-			side := Left
-			if x > e.c.ClientW()/2 {
-				side = Right
+			if top, ok := e.screens.Top(); ok {
+				top.Click(x, y)
 			}
-			atomic.StoreInt32(&clicking, int32(side))
 		case media.MouseUp:
-			atomic.StoreInt32(&clicking, int32(Center))
+			atomic.StoreInt32(&e.holding, int32(Center))
+		case media.MouseMove:
+			if top, ok := e.screens.Top(); ok {
+				top.Hover(x, y)
+			}
 		}
 	})
 
+	var pad []bool // gamepad buttons pressed
 	for {
-		movingSide := Direction(atomic.LoadInt32(&clicking))
-		switch movingSide {
-		case Left, Right:
-			e.s.Player().Move(movingSide, playerSpeed)
+		// gamepad buttons press keys as they're pressed down
+		buttons := media.GamepadButtons()
+		for i := range gamepadKeys {
+			if i < len(buttons) && buttons[i] && (i >= len(pad) || !pad[i]) {
+				e.button(i)
+			}
 		}
-
-		e.s.Draw(e.c)
+		pad = buttons
+		e.draw(time.Now())
 		time.Sleep(1 * time.Second / fps)
 	}
 }
+
+// button handles a gamepad button pressed, by the screen on top if it
+// handles buttons, or else as the key of the button.
+func (e *engine) button(i int) {
+	if top, ok := e.screens.Top(); ok {
+		if bs, ok := top.(buttonScreen); ok && bs.Button(i) {
+			return
+		}
+	}
+	e.key(gamepadKeys[i])
+}
+
+// key handles a key pressed, by the screen on top, or else the keys of
+// all screens.
+func (e *engine) key(key string) {
+	if top, ok := e.screens.Top(); ok && top.Key(key) {
+		return
+	}
+	switch key {
+	case "m", "M":
+		e.a.ToggleMute()
+	case "-":
+		e.a.Step(-1)
+	case "=", "+":
+		e.a.Step(1)
+	}
+}
+
+// draw draws the visible screens, and the toasts on top.
+func (e *engine) draw(now time.Time) {
+	e.c.FillRect(media.Rect{W: e.c.ClientW(), H: e.c.ClientH()}, "black")
+	for _, s := range e.screens.Visible() {
+		s.Draw(now, e.c)
+	}
+	e.toasts.Draw(now, e.c)
+}
+
+// gameOver ends the game, and replaces it with the name entry if the
+// player made it to the high scores, or with the high scores.
+func (e *engine) gameOver() {
+	event.Publish(e.s.Events(), StateChanged{GameOver})
+	base := baseScreen{e: e}
+	if e.m.GameOver(e.s.Points(), e.s.Level()) {
+		e.screens.Replace(&nameScreen{baseScreen: base})
+		return
+	}
+	e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: base}})
+}
+
+// keyName returns the SDL name of the key, which the profiles use.
+func keyName(key string) string {
+	switch key {
+	case "ArrowLeft":
+		return "Left"
+	case "ArrowRight":
+		return "Right"
+	case "ArrowUp":
+		return "Up"
+	case "ArrowDown":
+		return "Down"
+	case "Enter":
+		return "Return"
+	case " ":
+		return "Space"
+	}
+	if len([]rune(key)) == 1 {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// pick starts playing as the given profile, with its difficulty and
+// the color of its cat, unless the difficulty is set in the settings,
+// and with the adaptive difficulty if enabled in the settings, afresh.
+func (e *engine) pick(p *profile.Profile) {
+	name := e.set.Gameplay.Difficulty
+	if name == "" {
+		name = p.Difficulty
+	}
+	d, err := difficulty.Lookup(name)
+	if err != nil {
+		log.Printf("profile %q: %v", p.Name, err)
+		name = difficulty.Default
+		d, _ = difficulty.Lookup(name)
+	}
+	if e.prof != p {
+		e.last = nil
+	}
+	e.prof = p
+	e.s.SetDifficulty(d)
+	e.s.SetAdaptive(nil)
+	e.adaptive.Reset()
+	if e.set.Gameplay.Adaptive {
+		e.s.SetAdaptive(e.adaptive)
+	}
+	e.s.Player().SetColor(profile.LookupColor(p.Color))
+	e.m.SetProfile(p, highscore.Mode(name, e.set.Gameplay.Adaptive))
+}
+
+// achieve unlocks the achievements of the profile met in the game so
+// far, and shows a toast for each.
+func (e *engine) achieve(now time.Time) {
+	p := e.ach.Profile(e.prof.Name)
+	unlocked := p.Check(e.defs, now, e.s.Stats(), e.stats.Profile(e.prof.Name))
+	for _, d := range unlocked {
+		e.toasts.SetVolume(e.a.Settings().SFXVolume())
+		e.toasts.Show(locale.Sprintf("Achievement: %s", locale.T(d.Name)), locale.T(d.Description))
+	}
+	if len(unlocked) > 0 {
+		e.saveAchievements()
+	}
+}
+
+func (e *engine) saveAchievements() {
+	if err := e.ach.Save(e.st); err != nil {
+		log.Println("failed to save achievements:", err)
+	}
+}
@@ -0,0 +1,34 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// fontFamily is the CSS font family of the text: the font of the game,
+// then the font of the locale if any, for the characters the font of
+// the game doesn't have, e.g. accents and non-Latin scripts, then the
+// fonts of the browser.
+var fontFamily = "Score, sans-serif"
+
+// font returns the CSS font of the text in the size, in pixels.
+func font(px int) string {
+	return fmt.Sprintf("%dpx %s", px, fontFamily)
+}
+
+// loadFont loads the font of the locale, if any, as a fallback of the
+// font of the game.
+func loadFont(l *locale.Locale) {
+	name := l.Font()
+	if name == "" {
+		return
+	}
+	if err := media.LoadFont("Locale", "assets/fonts/"+name); err != nil {
+		log.Println("failed to load the font of the locale:", err)
+		return
+	}
+	fontFamily = "Score, Locale, sans-serif"
+}
@@ -0,0 +1,136 @@
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/level"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/tween"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+const (
+	levelCompleteTime = 3 * time.Second
+	levelSlideTime    = 500 * time.Millisecond
+)
+
+// Levels plays the levels in order on top of the rain, which goes on
+// endlessly after the last level.
+type Levels interface {
+	Score(points int64)
+	Update(now time.Time, rain Rain)
+	Current() int
+	Reset()
+	Draw(now time.Time, canvas media.Canvas)
+}
+
+type levels struct {
+	levels level.Levels
+	cur    int
+	run    *level.Run      // nil before the first and after the last level
+	screen *tween.Sequence // level complete screen, nil unless shown
+	slide  float64         // position of the screen, 0 out to 1 in
+	last   time.Time
+}
+
+// NewLevels ...
+func NewLevels(ls level.Levels) Levels {
+	return &levels{levels: ls}
+}
+
+func (lv *levels) Score(points int64) {
+	if lv.run != nil && lv.screen == nil {
+		lv.run.Score(points)
+	}
+}
+
+func (lv *levels) Update(now time.Time, rain Rain) {
+	dt := frameTime(lv.last, now)
+	lv.last = now
+	if lv.cur >= len(lv.levels) {
+		return
+	}
+	if lv.run == nil {
+		lv.run = level.NewRun(lv.levels[lv.cur], now)
+	}
+	if lv.screen != nil {
+		if lv.screen.Update(dt) {
+			return
+		}
+		lv.cur++
+		lv.screen = nil
+		lv.run = nil
+		rain.SetPool(nil)
+		if lv.cur < len(lv.levels) {
+			lv.run = level.NewRun(lv.levels[lv.cur], now)
+		}
+		return
+	}
+	if lv.run.Done(now) {
+		// slide the screen in, and out before the next level
+		lv.screen = tween.Seq(
+			lv.slideTo(0, 1, levelSlideTime, tween.OutBack),
+			tween.Wait(levelCompleteTime-2*levelSlideTime),
+			lv.slideTo(1, 0, levelSlideTime, tween.InQuad),
+		)
+		rain.SetPool([]string{}) // no drops while the screen is up
+		return
+	}
+	for _, sp := range lv.run.Spawns(now) {
+		rain.Spawn(sp.Name, sp.X)
+	}
+	rain.SetPool(lv.run.Pool(now))
+}
+
+func (lv *levels) Reset() {
+	lv.cur = 0
+	lv.run = nil
+	lv.screen = nil
+	lv.slide = 0
+	lv.last = time.Time{}
+}
+
+func (lv *levels) Current() int {
+	return lv.cur
+}
+
+// slideTo returns a tween of the level complete screen, between the
+// given positions.
+func (lv *levels) slideTo(from, to float64, d time.Duration, ease tween.Ease) tween.Tweener {
+	t := tween.New(from, to, d, ease)
+	t.OnUpdate = func(v float64) { lv.slide = v }
+	return t
+}
+
+func (lv *levels) Draw(now time.Time, canvas media.Canvas) {
+	if lv.run == nil {
+		return
+	}
+	cx := canvas.ClientW() / 2
+	if lv.screen == nil {
+		canvas.SetFont(font(28), "white")
+		drawCentered(canvas, locale.Sprintf("Level %d: %s", lv.cur+1, locale.T(lv.run.Level.Name)), cx, 32)
+		drawCentered(canvas, lv.run.Status(now), cx, 64)
+		return
+	}
+	canvas.SetAlpha(.5 * math.Min(math.Max(lv.slide, 0), 1))
+	canvas.FillRect(media.Rect{W: canvas.ClientW(), H: canvas.ClientH()}, "black")
+	canvas.SetAlpha(1)
+	// slide down from above the canvas
+	ch := canvas.ClientH()
+	y := int(float64(ch/3+ch/2)*lv.slide) - ch/2
+	canvas.SetFont(font(72), "#ffd700")
+	drawCentered(canvas, locale.Sprintf("Level %d complete!", lv.cur+1), cx, y)
+	next := locale.T("Endless rain, good luck!")
+	if lv.cur+1 < len(lv.levels) {
+		next = locale.Sprintf("Next: %s", locale.T(lv.levels[lv.cur+1].Name))
+	}
+	canvas.SetFont(font(32), "white")
+	drawCentered(canvas, next, cx, y+56)
+}
+
+// drawCentered draws text centered at x, with its baseline at y.
+func drawCentered(canvas media.Canvas, text string, x, y int) {
+	canvas.DrawText(text, x-canvas.MeasureTextWidth(text)/2, y)
+}
@@ -0,0 +1,395 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/highscore"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/store"
+	"github.com/fiorix/cat-o-licious/ui"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Action is what the player picked in the menu.
+type Action int
+
+// Actions of the menu.
+const (
+	NoAction Action = iota
+	PlayAction
+	HighScoresAction
+	StatsAction
+	AchievementsAction
+	ProfileAction
+	ResumeAction
+	EndAction // end the game
+	BackAction
+	SettingsAction
+	ApplyAction // apply the settings changed
+	KeysAction  // change the keys of the profile
+)
+
+// Menu draws the screens around the game: the profile picker, the
+// title, the pause overlay, the settings, the name entry of a new high
+// score, the high scores, the stats and the achievements. It keeps the high-score
+// table of the game mode.
+type Menu interface {
+	SetProfile(p *profile.Profile, mode string)
+	GameOver(points int64, level int) bool
+	Type(text string)
+	Erase()
+	Enter()
+	DrawProfiles(canvas media.Canvas, u *ui.UI, p *profile.Picker) bool
+	DrawTitle(canvas media.Canvas, u *ui.UI) Action
+	DrawPause(canvas media.Canvas, u *ui.UI) Action
+	DrawSettings(canvas media.Canvas, u *ui.UI, f *settingsForm) Action
+	DrawNameEntry(canvas media.Canvas)
+	DrawHighScores(canvas media.Canvas, u *ui.UI) Action
+	DrawStats(canvas media.Canvas, e *stats.Export, msg string)
+	DrawAchievements(canvas media.Canvas, st []achievement.Status, selected int)
+}
+
+type menu struct {
+	st     store.Store
+	scores *highscore.Table
+	mode   string
+	prof   *profile.Profile
+	name   []rune // name entered, kept for the next high score
+	points int64  // points of the last game, -1 if none
+	level  int
+	rank   int // rank of the last game in the high scores, or -1
+	sel    int // high score selected
+}
+
+// NewMenu ...
+func NewMenu(st store.Store) Menu {
+	scores, err := highscore.Load(st)
+	if err != nil {
+		log.Println("failed to load high scores, starting over:", err)
+	}
+	return &menu{st: st, scores: scores, points: -1, rank: -1}
+}
+
+func (m *menu) SetProfile(p *profile.Profile, mode string) {
+	if m.prof != p {
+		m.name = []rune(p.Name)
+		m.points, m.rank, m.sel = -1, -1, 0
+	}
+	m.prof = p
+	m.mode = mode
+}
+
+func (m *menu) GameOver(points int64, level int) bool {
+	m.points, m.level, m.rank, m.sel = points, level, -1, 0
+	return m.scores.Qualifies(m.mode, points)
+}
+
+func (m *menu) Type(text string) {
+	for _, c := range text {
+		if len(m.name) < highscore.MaxName {
+			m.name = append(m.name, c)
+		}
+	}
+}
+
+func (m *menu) Erase() {
+	if len(m.name) > 0 {
+		m.name = m.name[:len(m.name)-1]
+	}
+}
+
+func (m *menu) Enter() {
+	e := highscore.Entry{
+		Name:    string(m.name),
+		Profile: m.prof.Name,
+		Points:  m.points,
+		Level:   m.level,
+		Date:    time.Now(),
+	}
+	m.rank = m.scores.Add(m.mode, e)
+	m.sel = max(m.rank, 0)
+	m.name = []rune(highscore.CleanName(string(m.name)))
+	if err := m.scores.Save(m.st); err != nil {
+		log.Println("failed to save high scores:", err)
+	}
+}
+
+func (m *menu) DrawTitle(canvas media.Canvas, u *ui.UI) Action {
+	const w = 400 // width of the column
+	u.Begin(canvasUI{canvas})
+	defer u.End()
+	u.Layout((canvas.ClientW()-w)/2, canvas.ClientH()/8, w)
+	u.Label("cat-o-licious", ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	action := NoAction
+	for _, it := range []struct {
+		text   string
+		action Action
+	}{
+		{"Play", PlayAction},
+		{"High scores", HighScoresAction},
+		{"Stats", StatsAction},
+		{"Achievements", AchievementsAction},
+		{"Settings", SettingsAction},
+		{"Change player", ProfileAction},
+	} {
+		if u.Button(locale.T(it.text)) {
+			action = it.action
+		}
+	}
+	u.Space(20)
+	u.Label(locale.Sprintf("Player: %s", m.prof.Name), ui.Normal, ui.White, ui.AlignCenter)
+	u.Label(locale.Sprintf("Difficulty: %s", modeText(m.mode)), ui.Normal, ui.White, ui.AlignCenter)
+	u.Space(20)
+	u.Label(locale.T("M to mute, - and + for the volume"), ui.Small, ui.Gray, ui.AlignCenter)
+	return action
+}
+
+func (m *menu) DrawPause(canvas media.Canvas, u *ui.UI) Action {
+	const w = 400 // width of the column
+	cu := canvasUI{canvas}
+	cu.FillRect(ui.Rect{W: canvas.ClientW(), H: canvas.ClientH()}, ui.Color{A: 160})
+	u.Begin(cu)
+	defer u.End()
+	u.Layout((canvas.ClientW()-w)/2, canvas.ClientH()/3, w)
+	u.Label(locale.T("Paused"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	action := NoAction
+	if u.Button(locale.T("Resume")) {
+		action = ResumeAction
+	}
+	if u.Button(locale.T("Settings")) {
+		action = SettingsAction
+	}
+	if u.Button(locale.T("End the game")) {
+		action = EndAction
+	}
+	return action
+}
+
+func (m *menu) DrawSettings(canvas media.Canvas, u *ui.UI, f *settingsForm) Action {
+	const w = 500 // width of the column
+	u.Begin(canvasUI{canvas})
+	defer u.End()
+	u.Layout((canvas.ClientW()-w)/2, canvas.ClientH()/10, w)
+	u.Label(locale.T("Settings"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Space(20)
+	u.Choice(locale.T("Show"), translate(settingsSections), &f.section)
+	u.Space(10)
+	action := NoAction
+	changed := func(ok bool) {
+		if ok {
+			action = ApplyAction
+		}
+	}
+	switch f.section {
+	case gameplaySection:
+		changed(u.Slider(locale.T("Player speed"), &f.speed, speedStep))
+		changed(u.Choice(locale.T("Difficulty"), translate(difficultyOptions()), &f.difficulty))
+		changed(u.Toggle(locale.T("Adaptive difficulty"), &f.Gameplay.Adaptive))
+		u.Label(locale.T("Difficulty applies from the next game"), ui.Small, ui.Gray, ui.AlignCenter)
+	case audioSection:
+		changed(u.Slider(locale.T("Volume"), &f.Audio.Master, settings.VolumeStep))
+		changed(u.Slider(locale.T("Music"), &f.Audio.Music, settings.VolumeStep))
+		changed(u.Slider(locale.T("Sound effects"), &f.Audio.SFX, settings.VolumeStep))
+		changed(u.Toggle(locale.T("Mute"), &f.Audio.Muted))
+	case controlsSection:
+		u.Row(locale.T("Keys"), f.keys, ui.White)
+		if u.Button(locale.T("Change keys")) {
+			action = KeysAction
+		}
+		u.Label(locale.T("Gamepads: D-pad, A to pick, B back, Start to pause"), ui.Small, ui.Gray, ui.AlignCenter)
+	case accessibilitySection:
+		changed(u.Toggle(locale.T("Audio cues"), &f.Accessibility.AudioCues))
+		changed(u.Toggle(locale.T("Reduce motion"), &f.Accessibility.ReduceMotion))
+	}
+	u.Space(20)
+	if u.Button(locale.T("Back")) {
+		action = BackAction
+	}
+	return action
+}
+
+func (m *menu) DrawProfiles(canvas media.Canvas, u *ui.UI, p *profile.Picker) bool {
+	const w = 600 // width of the list
+	u.Begin(canvasUI{canvas})
+	defer u.End()
+	u.Layout((canvas.ClientW()-w)/2, canvas.ClientH()/12, w)
+	u.Label(locale.T("Who's playing?"), ui.Large, ui.Gold, ui.AlignCenter)
+	u.Label(p.Help(), ui.Normal, ui.White, ui.AlignCenter)
+	u.Label(p.Message, ui.Normal, ui.Red, ui.AlignCenter)
+	picked := u.List(profileItems(p.Profiles), &p.Selected, max(len(p.Profiles.Profiles), 1))
+	if p.Mode == profile.Naming {
+		u.Space(20)
+		u.Label(string(p.Name)+cursor(), ui.Large, ui.Gold, ui.AlignCenter)
+	}
+	return picked && p.Mode == profile.Browse
+}
+
+// profileItems returns the items of the list of profiles: their name,
+// and their color, difficulty and keys.
+func profileItems(ps *profile.Profiles) []ui.Item {
+	items := make([]ui.Item, len(ps.Profiles))
+	for i, pr := range ps.Profiles {
+		keys := strings.Join(pr.Bindings.Left, "/") + " " + strings.Join(pr.Bindings.Right, "/")
+		items[i] = ui.Item{
+			Left:  pr.Name,
+			Right: locale.T(pr.Color) + ", " + locale.T(pr.Difficulty) + "  " + keys,
+		}
+	}
+	return items
+}
+
+func (m *menu) DrawNameEntry(canvas media.Canvas) {
+	x, y := canvas.ClientW()/2, canvas.ClientH()/4
+	canvas.SetFont(font(72), "#ffd700")
+	drawCentered(canvas, locale.T("New high score!"), x, y)
+	canvas.SetFont(font(32), "white")
+	drawCentered(canvas, locale.Pluralf(m.points, "%d points", m.points), x, y+48)
+	drawCentered(canvas, locale.T("Type your name and press Enter"), x, y+128)
+	canvas.SetFont(font(72), "#ffd700")
+	drawCentered(canvas, string(m.name)+cursor(), x, y+208)
+}
+
+// cursor returns the blinking cursor of text entry.
+func cursor() string {
+	if time.Now().UnixNano()/int64(500*time.Millisecond)%2 == 1 {
+		return " "
+	}
+	return "_"
+}
+
+// modeText returns the mode of the game in the locale of the game,
+// e.g. "normal/adaptive".
+func modeText(mode string) string {
+	parts := strings.Split(mode, "/")
+	for i, p := range parts {
+		parts[i] = locale.T(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// translate returns the translations of the messages, e.g. the options
+// of a choice.
+func translate(ids []string) []string {
+	t := make([]string, len(ids))
+	for i, id := range ids {
+		t[i] = locale.T(id)
+	}
+	return t
+}
+
+func (m *menu) DrawHighScores(canvas media.Canvas, u *ui.UI) Action {
+	const w = 400 // width of the table
+	u.Begin(canvasUI{canvas})
+	defer u.End()
+	u.Layout((canvas.ClientW()-w)/2, canvas.ClientH()/12, w)
+	u.Label(locale.T("High scores"), ui.Large, ui.Gold, ui.AlignCenter)
+	if m.points >= 0 {
+		text := locale.Pluralf(m.points, "Game over: %d points", m.points)
+		u.Label(text, ui.Normal, ui.White, ui.AlignCenter)
+	}
+	u.Label(locale.Sprintf("Difficulty: %s", modeText(m.mode)), ui.Normal, ui.White, ui.AlignCenter)
+	u.Space(10)
+	top := m.scores.Top(m.mode)
+	if len(top) == 0 {
+		u.Space(20)
+		u.Label(locale.T("No high scores yet"), ui.Normal, ui.White, ui.AlignCenter)
+	}
+	back := len(top) > 0 && u.List(scoreItems(top), &m.sel, len(top))
+	u.Space(20)
+	if u.Button(locale.T("Back")) || back {
+		return BackAction
+	}
+	return NoAction
+}
+
+func scoreItems(top []highscore.Entry) []ui.Item {
+	items := make([]ui.Item, len(top))
+	for i, e := range top {
+		items[i] = ui.Item{Left: fmt.Sprintf("%d. %s", i+1, e.Name), Right: fmt.Sprint(e.Points)}
+	}
+	return items
+}
+
+func (m *menu) DrawStats(canvas media.Canvas, e *stats.Export, msg string) {
+	x, y := canvas.ClientW()/2, canvas.ClientH()/8
+	canvas.SetFont(font(72), "#ffd700")
+	drawCentered(canvas, locale.Sprintf("Stats of %s", e.Profile), x, y)
+	y += 16
+	drawRows(canvas, e.Summary(), x-370, y)
+	drawRows(canvas, e.Drops(), x+20, y)
+	if msg == "" {
+		msg = locale.T("E to export, Enter or click to go back")
+	}
+	canvas.SetFont(font(32), "white")
+	drawCentered(canvas, msg, x, canvas.ClientH()-canvas.ClientH()/16)
+}
+
+// drawRows draws the rows of stats in columns from x, under a header
+// of the columns.
+func drawRows(canvas media.Canvas, rows []stats.Row, x, y int) {
+	const label, game, all = 0, 250, 350 // columns, relative to x
+	right := func(s string, x, y int) {
+		canvas.DrawText(s, x-canvas.MeasureTextWidth(s), y)
+	}
+	canvas.SetFont(font(32), "#ffd700")
+	y += 36
+	right(locale.T("Game"), x+game, y)
+	right(locale.T("All"), x+all, y)
+	canvas.SetFont(font(32), "white")
+	for _, r := range rows {
+		y += 36
+		canvas.DrawText(r.Label, x+label, y)
+		right(r.Game, x+game, y)
+		right(r.All, x+all, y)
+	}
+}
+
+func (m *menu) DrawAchievements(canvas media.Canvas, st []achievement.Status, selected int) {
+	x, y := canvas.ClientW()/2, canvas.ClientH()/10
+	n := 0
+	for _, s := range st {
+		if !s.Unlocked.IsZero() {
+			n++
+		}
+	}
+	canvas.SetFont(font(72), "#ffd700")
+	drawCentered(canvas, locale.Sprintf("Achievements %d/%d", n, len(st)), x, y)
+	const w, lineH = 370, 36 // half the width of the list, and line height
+	// scroll the list to keep the selected achievement in view
+	bottom := canvas.ClientH() - canvas.ClientH()/6
+	first := 0
+	if rows := (bottom - y) / lineH; selected >= rows {
+		first = selected - rows + 1
+	}
+	for i := first; i < len(st) && y+lineH <= bottom; i++ {
+		s := st[i]
+		color, name, status := "gray", locale.T(s.Name), ""
+		switch {
+		case !s.Unlocked.IsZero():
+			color, status = "#ffd700", s.Unlocked.Format("2006-01-02")
+		case s.Cumulative():
+			status = fmt.Sprintf("%d/%d", s.Count, s.Goal)
+		}
+		if i == selected {
+			name = "> " + name
+		}
+		canvas.SetFont(font(32), color)
+		y += lineH
+		canvas.DrawText(name, x-w, y)
+		canvas.DrawText(status, x+w-canvas.MeasureTextWidth(status), y)
+	}
+	canvas.SetFont(font(32), "white")
+	if selected < len(st) {
+		drawCentered(canvas, locale.T(st[selected].Description), x, bottom+36)
+	}
+	drawCentered(canvas, locale.T("Up and down to browse, Enter or click to go back"), x, bottom+80)
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/music"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Music plays the playlist, switching tracks on score and level
+// milestones with a crossfade.
+type Music interface {
+	Update(now time.Time, points int64, level int)
+	Reset()
+	SetPaused(paused bool)
+	SetVolume(v float64)
+	Volume() float64
+	EnableAudio()
+}
+
+type musicPlayer struct {
+	mx     *music.Mixer
+	tracks []media.Audio
+	cur    int // track playing, -1 if none
+	prev   int // track fading out, -1 if none
+	last   time.Time
+
+	audioEnabled bool
+}
+
+// NewMusic creates the music of the playlist, skipping the tracks that
+// fail to load.
+func NewMusic(pl *music.Playlist) (Music, error) {
+	m := &musicPlayer{cur: -1, prev: -1}
+	found := *pl
+	found.Tracks = nil
+	for _, t := range pl.Tracks {
+		a, err := media.NewAudio(t.File)
+		if err != nil {
+			log.Printf("skipping music track %q: %v", t.Name, err)
+			continue
+		}
+		m.tracks = append(m.tracks, a)
+		found.Tracks = append(found.Tracks, t)
+	}
+	m.mx = music.NewMixer(&found)
+	return m, nil
+}
+
+func (m *musicPlayer) EnableAudio() {
+	m.audioEnabled = true
+}
+
+func (m *musicPlayer) Update(now time.Time, points int64, level int) {
+	dt := frameTime(m.last, now)
+	m.last = now
+	if !m.audioEnabled || m.mx.Paused() || len(m.tracks) == 0 {
+		return
+	}
+	m.mx.Score(points)
+	m.mx.Level(level)
+	m.mx.Update(dt)
+	if cur := m.mx.Current(); cur != m.cur {
+		// let the current track fade out while the new one starts
+		if m.prev >= 0 {
+			m.tracks[m.prev].Pause()
+		}
+		m.prev = m.cur
+		m.cur = cur
+		m.tracks[cur].PlayLoop()
+	}
+	vcur, vprev := m.mx.Volumes()
+	m.tracks[m.cur].SetVolume(vcur)
+	if m.prev < 0 {
+		return
+	}
+	if m.mx.Previous() < 0 {
+		m.tracks[m.prev].Pause()
+		m.prev = -1
+		return
+	}
+	m.tracks[m.prev].SetVolume(vprev)
+}
+
+func (m *musicPlayer) Reset() {
+	m.mx.Reset()
+}
+
+func (m *musicPlayer) SetPaused(paused bool) {
+	m.mx.SetPaused(paused)
+	for _, i := range []int{m.cur, m.prev} {
+		if i < 0 || !m.audioEnabled {
+			continue
+		}
+		if paused {
+			m.tracks[i].Pause()
+		} else {
+			m.tracks[i].Resume()
+		}
+	}
+}
+
+func (m *musicPlayer) SetVolume(v float64) {
+	m.mx.SetVolume(v)
+}
+
+func (m *musicPlayer) Volume() float64 {
+	return m.mx.Volume()
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/fx"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+const maxFrameTime = 100 * time.Millisecond
+
+// frameTime returns the time step between the last and current frame,
+// capped so effects don't jump ahead when the browser tab is hidden.
+func frameTime(last, now time.Time) time.Duration {
+	if last.IsZero() {
+		return 0
+	}
+	dt := now.Sub(last)
+	if dt > maxFrameTime {
+		return maxFrameTime
+	}
+	return dt
+}
+
+// Particles draws particle effects such as sparkles and crumbs.
+type Particles interface {
+	// Emit emits a burst of particles at x, y. The sprite of the
+	// emitter, if set, must be a media.Image.
+	Emit(e fx.Emitter, x, y int)
+	Draw(now time.Time, canvas media.Canvas)
+}
+
+type particles struct {
+	sys  fx.System
+	last time.Time
+}
+
+// NewParticles ...
+func NewParticles() Particles {
+	return &particles{}
+}
+
+func (ps *particles) Emit(e fx.Emitter, x, y int) {
+	ps.sys.Emit(e, float64(x), float64(y))
+}
+
+func (ps *particles) Draw(now time.Time, canvas media.Canvas) {
+	ps.sys.Update(frameTime(ps.last, now))
+	ps.last = now
+	for _, p := range ps.sys.Particles() {
+		w := int(p.Size)
+		if w < 1 {
+			w = 1
+		}
+		h := w
+		img, ok := p.Sprite.(media.Image)
+		if ok {
+			h = w * img.H() / img.W()
+		}
+		r := media.Rect{X: int(p.X) - w/2, Y: int(p.Y) - h/2, W: w, H: h}
+		canvas.SetAlpha(p.Alpha())
+		if ok {
+			canvas.DrawImage(img, r)
+		} else {
+			canvas.FillRect(r, cssColor(p.Color))
+		}
+	}
+	canvas.SetAlpha(1)
+}
+
+// cssColor returns c in CSS format.
+func cssColor(c fx.Color) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", c.R, c.G, c.B, float64(c.A)/255)
+}
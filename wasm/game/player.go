@@ -1,9 +1,15 @@
 package game
 
 import (
-	"errors"
+	"fmt"
+	"math"
 	"sync/atomic"
+	"time"
 
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/sound"
+	"github.com/fiorix/cat-o-licious/sprite"
+	"github.com/fiorix/cat-o-licious/tween"
 	"github.com/fiorix/cat-o-licious/wasm/media"
 )
 
@@ -20,16 +26,6 @@ const (
 // DefaultPlayerSide configures the default facing side of the player.
 var DefaultPlayerSide = Right
 
-// DefaultPlayerHitSquare defines the hit square within the
-// player image, in percentages.
-var DefaultPlayerHitSquare = struct{ X, Y, W, H, FlipOffset float32 }{
-	X:          .4,  // left offset
-	Y:          .8,  // top offset
-	W:          .3,  // pct of the img width
-	H:          .15, // pct of the img height
-	FlipOffset: .1,  // X offset for the horizontal flip
-}
-
 // Player is a game player.
 type Player interface {
 	// Move moves the player.
@@ -39,66 +35,124 @@ type Player interface {
 	// with the player's hit area. This is when you make points.
 	Hit(d Drop) bool
 
+	// Catch plays the animation of catching the drop: eating good
+	// drops, and disgust for bad ones.
+	Catch(d Drop)
+
+	// HitArea returns the player's hit area in the canvas.
+	HitArea() media.Rect
+
 	// Draw draws the player.
-	Draw(canvas media.Canvas)
+	Draw(now time.Time, canvas media.Canvas)
 
 	// EnableAudio enables audio playback (SFX gating under browser policies).
 	EnableAudio()
+
+	// SetVolume sets the volume of the player's sfx, from 0 to 1.
+	SetVolume(v float64)
+
+	// SetColor sets the color of the cat.
+	SetColor(c profile.Color)
 }
 
-// players need at least 3 images, loaded in the following sequence:
-const (
-	moving  = 0
-	winning = 1
-	losing  = 2
-)
+const walkTime = 200 * time.Millisecond
+
+// playerMoveTime is how long the player takes to glide to where
+// it has been moved.
+const playerMoveTime = 120 * time.Millisecond
+
+var playerClips = []string{"idle", "walk", "eat", "disgust"}
 
 type player struct {
-	imgs []media.Image // available images
-	img  media.Image   // current player image
-	x    int32         // lateral movement
-	y    int32         // vertical position relative to viewport
-	d    Direction     // facing side
-	hitP media.Rect    // hit area of the player
-	hitC int           // hit counter to swap image for N frames
-	sfx  []media.Audio // available sfx
+	imgs  []media.Image           // available images
+	hbs   []sprite.Hitbox         // hit squares of the available images
+	anim  sprite.Animation        // current animation
+	move  *tween.Tween            // lateral movement drawn
+	last  time.Time               // time of the last frame drawn
+	moved int64                   // time of the last move, in unix nanoseconds
+	x     int32                   // lateral movement target
+	y     int32                   // vertical position relative to viewport
+	d     Direction               // facing side
+	hitP  media.Rect              // hit area of the player
+	hitX  int                     // where the last drop was caught
+	sfx   map[string]*media.Sound // sfx played on animation events
+	color profile.Color           // color of the cat
 
 	audioEnabled bool
 }
 
-// NewPlayer creates and initializes a new player.
-func NewPlayer() (Player, error) {
+// NewPlayer creates and initializes a new player. Hit squares and
+// animation clips of the player images are taken from the sprite
+// catalog.
+func NewPlayer(sprites sprite.Catalog) (Player, error) {
 	imgs, err := media.NewImageSet("assets/img/player_frame_")
 	if err != nil {
 		return nil, err
 	}
-	if len(imgs) < 3 {
-		return nil, errors.New("need at least 3 player frames")
+	clips := sprites.Clips("player_frame")
+	if err := checkClips(clips, playerClips, len(imgs)); err != nil {
+		return nil, fmt.Errorf("player: %v", err)
 	}
-	sfxwin, err := media.NewAudio("assets/snd/score_win_1.wav")
+	sfxwin, err := loadSound("assets/snd/score_win_1.wav", "win", sfxVoices)
 	if err != nil {
 		return nil, err
 	}
-	sfxlose, err := media.NewAudio("assets/snd/score_lose_1.wav")
+	sfxlose, err := loadSound("assets/snd/score_lose_1.wav", "lose", sfxVoices)
 	if err != nil {
 		return nil, err
 	}
+	hbs := make([]sprite.Hitbox, len(imgs))
+	for i := range imgs {
+		hbs[i] = sprites.Hitbox("player_frame", i+1)
+	}
 	p := &player{
-		imgs: imgs,
-		img:  imgs[moving],
-		d:    Center,
-		sfx:  []media.Audio{{}, sfxwin, sfxlose},
+		imgs:  imgs,
+		hbs:   hbs,
+		anim:  sprite.NewAnimation(clips),
+		d:     Center,
+		sfx:   map[string]*media.Sound{"win": sfxwin, "lose": sfxlose},
+		color: profile.Colors[0],
 	}
+	p.anim.Play("idle")
 	return p, nil
 }
 
+// checkClips returns an error if any of the named clips is missing,
+// or if any clip shows a frame past the given number of frames.
+func checkClips(clips map[string]*sprite.Clip, names []string, frames int) error {
+	for _, name := range names {
+		if _, ok := clips[name]; !ok {
+			return fmt.Errorf("missing animation clip %q", name)
+		}
+	}
+	for name, c := range clips {
+		for _, k := range c.Keys {
+			if k.Frame > frames {
+				return fmt.Errorf("clip %q: frame %d of %d", name, k.Frame, frames)
+			}
+		}
+	}
+	return nil
+}
+
 func (p *player) EnableAudio() {
 	p.audioEnabled = true
 }
 
+func (p *player) SetVolume(v float64) {
+	for _, sfx := range p.sfx {
+		sfx.SetVolume(v)
+	}
+}
+
+func (p *player) SetColor(c profile.Color) {
+	p.color = c
+}
+
 // Move implements the Player interface.
 func (p *player) Move(d Direction, steps int32) {
 	p.d = d
+	atomic.StoreInt64(&p.moved, time.Now().UnixNano())
 	switch d {
 	case Left:
 		atomic.AddInt32(&p.x, -steps)
@@ -107,19 +161,50 @@ func (p *player) Move(d Direction, steps int32) {
 	}
 }
 
+// animate switches between the idle and walk clips, advances the
+// animation, and plays the sfx of its events panned to where the
+// last drop was caught, within the canvas width w.
+func (p *player) animate(now time.Time, dt time.Duration, w int) {
+	moving := time.Since(time.Unix(0, atomic.LoadInt64(&p.moved))) < walkTime
+	switch {
+	case moving && p.anim.Playing() == "idle":
+		p.anim.Play("walk")
+	case !moving && p.anim.Playing() == "walk":
+		p.anim.Play("idle")
+	}
+	for _, ev := range p.anim.Update(dt) {
+		if sfx, ok := p.sfx[ev]; ok && p.audioEnabled {
+			sfx.PlayAt(sound.Pan(float64(p.hitX), float64(w)))
+		}
+	}
+}
+
+// glide moves the player smoothly toward x, and returns the lateral
+// position to draw the player at.
+func (p *player) glide(x int32, dt time.Duration) int32 {
+	switch {
+	case p.move == nil:
+		p.move = tween.New(float64(x), float64(x), 0, nil)
+	case p.move.To != float64(x):
+		p.move = tween.New(p.move.Value(), float64(x), playerMoveTime, tween.OutQuad)
+	}
+	p.move.Update(dt)
+	return int32(math.Round(p.move.Value()))
+}
+
 // Draw implements the Player interface.
-func (p *player) Draw(canvas media.Canvas) {
+func (p *player) Draw(now time.Time, canvas media.Canvas) {
+	dt := frameTime(p.last, now)
+	p.last = now
+	p.animate(now, dt, canvas.ClientW())
 	x := atomic.LoadInt32(&p.x)
-	if p.hitC > 0 {
-		// reset player image from hit to moving after 10 frames
-		if p.hitC == 10 {
-			p.hitC = 0
-			p.img = p.imgs[moving]
-		} else {
-			p.hitC++
-		}
+	pose := p.anim.Pose()
+	frame := pose.Frame
+	if frame == 0 {
+		frame = 1
 	}
-	w, h := p.img.W(), p.img.H()
+	img := p.imgs[frame-1]
+	w, h := img.W(), img.H()
 	if p.d == Center {
 		x = int32((canvas.ClientW() / 2) - (w / 2))
 		p.d = DefaultPlayerSide
@@ -137,48 +222,49 @@ func (p *player) Draw(canvas media.Canvas) {
 		}
 	}
 	atomic.StoreInt32(&p.x, x)
+	x = p.glide(x, dt)
 	p.y = int32(float32(canvas.ClientH())/1.5) - int32(h/2)
-	r := media.Rect{X: int(x), Y: int(p.y), W: w, H: h}
-	hs := DefaultPlayerHitSquare
+	flipped := p.d != DefaultPlayerSide
 	// save player's current hit square position
-	p.hitP = media.Rect{
-		X: int(x) + int(float32(w)*hs.X),
-		Y: int(p.y) + int(float32(h)*hs.Y),
-		W: int(float32(w) * hs.W),
-		H: int(float32(h) * hs.H),
-	}
-	// render player facing default side
-	if p.d == DefaultPlayerSide {
-		canvas.DrawImage(p.img, r)
+	hx, hy, hw, hh := p.hbs[frame-1].Rect(int(x), int(p.y), w, h, flipped)
+	p.hitP = media.Rect{X: hx, Y: hy, W: hw, H: hh}
+	// render the pose of the animation, scaled from the center
+	// and anchored at the bottom
+	sw, sh := int(float64(w)*pose.Scale), int(float64(h)*pose.Scale)
+	r := media.Rect{
+		X: int(x) - (sw-w)/2,
+		Y: int(p.y) + h - sh + int(pose.DY*float64(h)),
+		W: sw,
+		H: sh,
+	}
+	canvas.SetFilter(p.color.Filter)
+	defer canvas.SetFilter("none")
+	if !flipped {
+		canvas.DrawImageRotated(img, r, pose.Angle, false)
 		return
 	}
-	// render player facing the opposide side, then adjust
-	// the hit square X position
-	canvas.DrawImageFlipHorizontal(p.img, r)
-	p.hitP.X = p.hitP.X - int(float32(w)*hs.FlipOffset)
+	// render player facing the opposide side
+	canvas.DrawImageRotated(img, r, -pose.Angle, true)
 }
 
 // Hit implements the Player interface.
 func (p *player) Hit(d Drop) bool {
-	hit := p.hitP
-	area := d.Pos()
-	if (area.Y+area.H) < hit.Y || area.Y > hit.Y {
-		return false
-	}
-	if (area.X+area.W) < hit.X || area.X > (hit.X+hit.W) {
-		return false
-	}
-	p.hitC = 1
-	if d.Points() > 0 {
-		p.img = p.imgs[winning]
-		if p.audioEnabled {
-			p.sfx[winning].Play()
-		}
+	area := d.HitArea()
+	return p.hitP.Intersects(area)
+}
+
+// Catch implements the Player interface.
+func (p *player) Catch(d Drop) {
+	area := d.HitArea()
+	p.hitX = area.X + area.W/2
+	if d.Points() >= 0 {
+		p.anim.Play("eat")
 	} else {
-		p.img = p.imgs[losing]
-		if p.audioEnabled {
-			p.sfx[losing].Play()
-		}
+		p.anim.Play("disgust")
 	}
-	return true
+}
+
+// HitArea implements the Player interface.
+func (p *player) HitArea() media.Rect {
+	return p.hitP
 }
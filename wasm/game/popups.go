@@ -0,0 +1,40 @@
+package game
+
+import (
+	"time"
+
+	"github.com/fiorix/cat-o-licious/fx"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Popups draws score popups, e.g. "+15", where drops are caught.
+type Popups interface {
+	Spawn(points int64, x, y int)
+	Draw(now time.Time, canvas media.Canvas)
+}
+
+type popups struct {
+	ps   fx.Popups
+	last time.Time
+}
+
+// NewPopups ...
+func NewPopups() Popups {
+	return &popups{}
+}
+
+func (pp *popups) Spawn(points int64, x, y int) {
+	pp.ps.Spawn(points, float64(x), float64(y))
+}
+
+func (pp *popups) Draw(now time.Time, canvas media.Canvas) {
+	pp.ps.Update(frameTime(pp.last, now))
+	pp.last = now
+	for _, p := range pp.ps.List() {
+		canvas.SetFont(font(40), cssColor(p.Color))
+		canvas.SetAlpha(p.Alpha())
+		w := canvas.MeasureTextWidth(p.Text)
+		canvas.DrawText(p.Text, int(p.X)-w/2, int(p.Y))
+	}
+	canvas.SetAlpha(1)
+}
@@ -0,0 +1,82 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/powerup"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// PowerUps tracks the active power-ups and draws their timers.
+type PowerUps interface {
+	Activate(k powerup.Kind, now time.Time)
+	Deactivate(k powerup.Kind)
+	Active(k powerup.Kind, now time.Time) bool
+	TimeScale(now time.Time) float64
+	Reset()
+	Draw(now time.Time, canvas media.Canvas)
+	EnableAudio()
+	SetVolume(v float64)
+}
+
+type powerups struct {
+	powerup.Effects
+	imgs []media.Image
+	sfx  *media.Sound
+
+	audioEnabled bool
+}
+
+// NewPowerUps ...
+func NewPowerUps() (PowerUps, error) {
+	imgs, err := media.NewImageSet("assets/img/powerup_")
+	if err != nil {
+		return nil, err
+	}
+	sfx, err := loadSound("assets/snd/powerup_1.wav", "powerup", 1)
+	if err != nil {
+		return nil, err
+	}
+	return &powerups{imgs: imgs, sfx: sfx}, nil
+}
+
+func (pu *powerups) Activate(k powerup.Kind, now time.Time) {
+	pu.Effects.Activate(k, now)
+	if pu.audioEnabled {
+		pu.sfx.Play()
+	}
+}
+
+func (pu *powerups) Reset() {
+	pu.Effects = powerup.Effects{}
+}
+
+func (pu *powerups) EnableAudio() {
+	pu.audioEnabled = true
+}
+
+func (pu *powerups) SetVolume(v float64) {
+	pu.sfx.SetVolume(v)
+}
+
+func (pu *powerups) Draw(now time.Time, canvas media.Canvas) {
+	const size = 40
+	x := int(float64(canvas.ClientW()) * .05)
+	y := int(float64(canvas.ClientH()) * .1)
+	canvas.SetFont(font(28), "white")
+	for i, k := range powerup.Kinds {
+		left := pu.Remaining(k, now)
+		if left == 0 || i >= len(pu.imgs) {
+			continue
+		}
+		// blink for the last 2 seconds
+		if left < 2*time.Second && left%(250*time.Millisecond) < 125*time.Millisecond {
+			y += size + 8
+			continue
+		}
+		canvas.DrawImage(pu.imgs[i], media.Rect{X: x, Y: y, W: size, H: size})
+		canvas.DrawText(fmt.Sprintf("%d", int(left.Seconds())+1), x+size+8, y+size-10)
+		y += size + 8
+	}
+}
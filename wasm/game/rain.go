@@ -1,37 +1,72 @@
 package game
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
 	"time"
 
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/motion"
+	"github.com/fiorix/cat-o-licious/powerup"
+	"github.com/fiorix/cat-o-licious/sprite"
 	"github.com/fiorix/cat-o-licious/wasm/media"
 )
 
 // Rain ...
 type Rain interface {
-	SetRate(n int)
+	SetParams(p difficulty.Params)
+	// SetTimeScale sets the speed of the rain, where 1 is normal.
+	SetTimeScale(f float64)
+	// Attract moves good drops toward x at the given speed.
+	Attract(x, speed int)
+	Names() []string
+	// SetPool restricts new random drops to the named drops. The nil
+	// pool allows all drops, and an empty pool none.
+	SetPool(names []string)
+	// Spawn adds the named drop at x, from 0 (left) to 1 (right).
+	Spawn(name string, x float64)
 	Drops() []Drop
-	Draw(canvas media.Canvas)
+	// Landed returns the drops that hit the floor during the last Draw.
+	Landed() []Drop
+	// Approaching returns the drops that fell past the middle of the
+	// canvas, toward the player, during the last Draw.
+	Approaching() []Drop
+	// Reset removes all drops for a new game.
+	Reset()
+	// SetPaused freezes the drops where they are, or lets them fall
+	// again. Paused rain draws no new drops, and none land or approach.
+	SetPaused(paused bool)
+	Draw(now time.Time, canvas media.Canvas)
 }
 
 // Drop ...
 type Drop interface {
 	Pos() media.Rect
+	HitArea() media.Rect
+	Image() media.Image
+	Name() string
 	Points() int64
+	PowerUp() powerup.Kind
 	Consume()
 	Consumed() bool
 }
 
 // NewRain ...
-func NewRain() (Rain, error) {
-	var rd []*raindrop
+func NewRain(sprites sprite.Catalog, drops catalog.Catalog, bus *event.Bus) (Rain, error) {
+	var good, bad []*raindrop
 	imgs, err := media.NewImageSet("assets/img/drop_good_")
 	if err != nil {
 		return nil, err
 	}
 	for i, img := range imgs {
-		rd = append(rd, &raindrop{
+		good = append(good, &raindrop{
 			img:    img,
+			hb:     sprites.Hitbox("drop_good", i+1),
+			clips:  sprites.Clips("drop_good"),
+			def:    drops.Lookup(fmt.Sprintf("drop_good_%d", i+1)),
 			points: int64(i+1) * 5,
 		})
 	}
@@ -40,81 +75,237 @@ func NewRain() (Rain, error) {
 		return nil, err
 	}
 	for i, img := range imgs {
-		rd = append(rd, &raindrop{
+		bad = append(bad, &raindrop{
 			img:    img,
+			hb:     sprites.Hitbox("drop_bad", i+1),
+			clips:  sprites.Clips("drop_bad"),
+			def:    drops.Lookup(fmt.Sprintf("drop_bad_%d", i+1)),
 			points: -(int64(i+1) * 10),
 		})
 	}
+	var pu []*raindrop
+	imgs, err = media.NewImageSet("assets/img/powerup_")
+	if err != nil {
+		return nil, err
+	}
+	for i, img := range imgs {
+		if i >= len(powerup.Kinds) {
+			break
+		}
+		pu = append(pu, &raindrop{
+			img:     img,
+			hb:      sprites.Hitbox("powerup", i+1),
+			clips:   sprites.Clips("powerup"),
+			def:     drops.Lookup(fmt.Sprintf("powerup_%d", i+1)),
+			powerup: powerup.Kinds[i],
+		})
+	}
 	ra := &rain{
-		lastdrop:  time.Now(),
-		available: rd,
-		delay:     time.Second,
+		bus:      bus,
+		lastdrop: time.Now(),
+		good:     good,
+		bad:      bad,
+		powerups: pu,
+		byName:   make(map[string]*raindrop),
+		scale:    1,
+	}
+	for _, set := range [][]*raindrop{good, bad, pu} {
+		for _, rd := range set {
+			ra.byName[rd.def.Name] = rd
+		}
 	}
 	return ra, nil
 }
 
 type rain struct {
-	canvas    media.Canvas
-	lastdrop  time.Time
-	available []*raindrop
-	drops     []*drop
-	delay     time.Duration
+	canvas   media.Canvas
+	bus      *event.Bus
+	lastdrop time.Time
+	good     []*raindrop
+	bad      []*raindrop
+	powerups []*raindrop
+	byName   map[string]*raindrop
+	pool     []*raindrop
+	pending  []spawn
+	drops    []*drop
+	landed   []Drop
+	near     []Drop
+	params   difficulty.Params
+	scale    float64
+	last     time.Time
+	paused   bool
+}
+
+type spawn struct {
+	rd *raindrop
+	x  float64
 }
 
 type raindrop struct {
-	img    media.Image
-	points int64
+	img     media.Image
+	hb      sprite.Hitbox
+	clips   map[string]*sprite.Clip
+	def     *catalog.Def
+	points  int64
+	powerup powerup.Kind
+}
+
+func (r *rain) SetParams(p difficulty.Params) {
+	r.params = p
 }
 
-func (r *rain) SetRate(n int) {
-	const min = 100 * time.Millisecond
-	r.delay = time.Duration(1000-(n-1)*100) * time.Millisecond
-	if r.delay < min {
-		r.delay = min
+func (r *rain) SetTimeScale(f float64) {
+	r.scale = f
+}
+
+func (r *rain) Attract(x, speed int) {
+	for _, d := range r.drops {
+		if d.src.points <= 0 {
+			continue
+		}
+		dx := float64(x) - (d.st.X + d.st.W/2)
+		if dx > float64(speed) {
+			dx = float64(speed)
+		} else if dx < -float64(speed) {
+			dx = -float64(speed)
+		}
+		d.st.X += dx
 	}
 }
 
-func (r *rain) Draw(canvas media.Canvas) {
-	t := time.Now()
-	if t.Sub(r.lastdrop) >= r.delay {
+func (r *rain) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (r *rain) SetPool(names []string) {
+	if names == nil {
+		r.pool = nil
+		return
+	}
+	r.pool = make([]*raindrop, 0, len(names))
+	for _, name := range names {
+		if rd, ok := r.byName[name]; ok {
+			r.pool = append(r.pool, rd)
+		}
+	}
+}
+
+func (r *rain) Spawn(name string, x float64) {
+	if rd, ok := r.byName[name]; ok {
+		r.pending = append(r.pending, spawn{rd, x})
+	}
+}
+
+func (r *rain) SetPaused(paused bool) {
+	r.paused = paused
+}
+
+func (r *rain) Draw(now time.Time, canvas media.Canvas) {
+	if r.paused {
+		r.last = now
+		r.landed = r.landed[:0]
+		r.near = r.near[:0]
+		for _, d := range r.drops {
+			if !d.consumed {
+				d.draw(canvas)
+			}
+		}
+		return
+	}
+	for _, sp := range r.pending {
+		r.add(sp.rd, sp.x, canvas)
+	}
+	r.pending = r.pending[:0]
+	delay := time.Duration(float64(r.params.Interval) / r.scale)
+	if now.Sub(r.lastdrop) >= delay {
 		r.newDrop(canvas)
-		r.lastdrop = t
+		r.lastdrop = now
 	}
-	r.drawAndDrain(canvas)
+	dt := time.Duration(float64(frameTime(r.last, now)) * r.scale)
+	r.last = now
+	r.drawAndDrain(canvas, dt)
 }
 
 func (r *rain) newDrop(canvas media.Canvas) {
+	var rd *raindrop
+	switch {
+	case r.pool != nil && len(r.pool) == 0:
+		return
+	case r.pool != nil:
+		rd = r.pool[rand.Intn(len(r.pool))]
+	default:
+		set := r.good
+		if rand.Float64() < r.params.BadRatio {
+			set = r.bad
+		}
+		rd = set[rand.Intn(len(set))]
+		if len(r.powerups) > 0 && rand.Float64() < powerup.Chance {
+			rd = r.powerups[rand.Intn(len(r.powerups))]
+		}
+	}
+	r.add(rd, rand.Float64(), canvas)
+}
+
+func (r *rain) add(rd *raindrop, x float64, canvas media.Canvas) {
 	border := int(float64(canvas.ClientW()) * .05)
-	idx := rand.Intn(len(r.available))
-	rd := r.available[idx]
 	w, h := rd.img.W(), rd.img.H()
 	lim := canvas.ClientW() - w - (border * 2)
 	pos := media.Rect{
-		X: border + rand.Intn(lim),
+		X: border + int(x*float64(lim)),
 		Y: -h,
 		W: w,
 		H: h,
 	}
 	d := &drop{
-		src:   rd,
-		pos:   pos,
-		speed: 5 + rand.Intn(10),
+		src:  rd,
+		pos:  pos,
+		anim: sprite.NewAnimation(rd.clips),
+		st: motion.State{
+			X:     float64(pos.X),
+			Y:     float64(pos.Y),
+			W:     float64(w),
+			H:     float64(h),
+			VY:    float64(r.params.Speed(rand.Float64())),
+			Width: float64(canvas.ClientW()),
+			Floor: float64(canvas.ClientH()),
+		},
 	}
+	motion.Start(&d.st, rd.def.Motions())
+	d.anim.Play("fall")
 	r.drops = append(r.drops, d)
+	event.Publish(r.bus, DropSpawned{Drop: d})
 }
 
-func (r *rain) drawAndDrain(canvas media.Canvas) {
+func (r *rain) drawAndDrain(canvas media.Canvas, dt time.Duration) {
 	// Use a single-pass, in-place filter to draw and drain drops.
 	// This avoids allocating a map and a new slice on every frame.
 	// Important: clear the tail so drained drops aren't retained by the backing array.
 	orig := r.drops
 	kept := orig[:0]
+	r.landed = r.landed[:0]
+	r.near = r.near[:0]
+	var halves []*drop
 
 	for _, d := range orig {
 		if d.pos.Y > canvas.ClientH() || d.consumed {
 			continue // Drop is off-screen or consumed, drain it.
 		}
-		d.Draw(canvas)
+		d.Draw(canvas, r.scale, dt)
+		if !d.near && d.pos.Y+d.pos.H/2 > canvas.ClientH()/2 {
+			d.near = true
+			r.near = append(r.near, d)
+		}
+		if !d.landed && d.st.Landed() {
+			d.landed = true
+			r.landed = append(r.landed, d)
+		}
+		if h := d.split(); h != nil {
+			halves = append(halves, h)
+		}
 		kept = append(kept, d)
 	}
 
@@ -123,7 +314,15 @@ func (r *rain) drawAndDrain(canvas media.Canvas) {
 		orig[i] = nil
 	}
 
-	r.drops = kept
+	r.drops = append(kept, halves...)
+}
+
+func (r *rain) Reset() {
+	r.drops = nil
+	r.pending = nil
+	r.pool = nil
+	r.landed = r.landed[:0]
+	r.near = r.near[:0]
 }
 
 func (r *rain) Drops() []Drop {
@@ -134,31 +333,99 @@ func (r *rain) Drops() []Drop {
 	return drops
 }
 
+func (r *rain) Landed() []Drop {
+	return r.landed
+}
+
+func (r *rain) Approaching() []Drop {
+	return r.near
+}
+
 type drop struct {
 	src      *raindrop
 	pos      media.Rect
-	speed    int
+	st       motion.State
 	consumed bool
+	landed   bool
+	near     bool             // past the middle of the canvas
+	halved   bool             // split in two already
+	anim     sprite.Animation // e.g. spinning or pulsing
+}
+
+func (d *drop) Draw(canvas media.Canvas, scale float64, dt time.Duration) {
+	d.st.Width = float64(canvas.ClientW())
+	d.st.Floor = float64(canvas.ClientH())
+	motion.Step(&d.st, d.src.def.Motions(), scale)
+	d.pos.X = int(math.Round(d.st.X))
+	d.pos.Y = int(math.Round(d.st.Y))
+	d.anim.Update(dt)
+	d.draw(canvas)
 }
 
-func (d *drop) Draw(canvas media.Canvas) {
-	d.pos.Y = d.pos.Y + d.speed
-	canvas.DrawImage(d.src.img, media.Rect{
-		X: d.pos.X,
-		Y: d.pos.Y,
-		W: d.src.img.W(),
-		H: d.src.img.H(),
-	})
+// draw draws the drop where it is, in the pose of its animation.
+func (d *drop) draw(canvas media.Canvas) {
+	pose := d.anim.Pose()
+	w := int(float64(d.pos.W) * pose.Scale)
+	h := int(float64(d.pos.H) * pose.Scale)
+	canvas.DrawImageRotated(d.src.img, media.Rect{
+		X: d.pos.X - (w-d.pos.W)/2,
+		Y: d.pos.Y - (h-d.pos.H)/2 + int(pose.DY*float64(d.pos.H)),
+		W: w,
+		H: h,
+	}, pose.Angle, false)
+}
+
+// split returns the right half of the drop when its motion splits
+// it in two, and turns the drop into the left half.
+func (d *drop) split() *drop {
+	if !d.st.Split || d.halved {
+		return nil
+	}
+	d.halved = true
+	for _, m := range d.src.def.Motions() {
+		sp, ok := m.(motion.Split)
+		if !ok {
+			continue
+		}
+		left, right := sp.Halves(d.st)
+		d.st = left
+		d.pos.W, d.pos.H = int(left.W), int(left.H)
+		h := *d
+		h.st = right
+		return &h
+	}
+	return nil
 }
 
 func (d *drop) Pos() media.Rect {
 	return d.pos
 }
 
+func (d *drop) HitArea() media.Rect {
+	x, y, w, h := d.src.hb.Rect(d.pos.X, d.pos.Y, d.pos.W, d.pos.H, false)
+	return media.Rect{X: x, Y: y, W: w, H: h}
+}
+
+func (d *drop) Image() media.Image {
+	return d.src.img
+}
+
+func (d *drop) Name() string {
+	return d.src.def.Name
+}
+
+// Points returns half the points for each half of a split drop.
 func (d *drop) Points() int64 {
+	if d.halved {
+		return d.src.points / 2
+	}
 	return d.src.points
 }
 
+func (d *drop) PowerUp() powerup.Kind {
+	return d.src.powerup
+}
+
 func (d *drop) Consume() {
 	d.consumed = true
 }
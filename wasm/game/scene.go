@@ -1,86 +1,331 @@
 package game
 
 import (
+	"bytes"
 	"time"
 
+	"github.com/fiorix/cat-o-licious/achievement"
+	"github.com/fiorix/cat-o-licious/catalog"
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/fx"
+	"github.com/fiorix/cat-o-licious/level"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/music"
+	"github.com/fiorix/cat-o-licious/powerup"
+	"github.com/fiorix/cat-o-licious/sprite"
+	"github.com/fiorix/cat-o-licious/stats"
+	"github.com/fiorix/cat-o-licious/theme"
+	"github.com/fiorix/cat-o-licious/tween"
 	"github.com/fiorix/cat-o-licious/wasm/media"
 )
 
+// Camera shake on bad catches.
+const (
+	cameraShake     = 12 // pixels
+	cameraShakeTime = 300 * time.Millisecond
+)
+
 // Scene ...
 type Scene interface {
 	Player() Player
-	Draw(canvas media.Canvas)
+	Music() Music
+	Draw(now time.Time, canvas media.Canvas)
 	EnableAudio()
+	SetSFXVolume(v float64)
+	Points() int64
+	Level() int
+	Events() *event.Bus
+	Names() []string
+	Stats() *stats.Session
+	SetDifficulty(d difficulty.Preset)
+	SetAdaptive(a Adaptive)
+	SetCues(c Cues)
+	SetReduceMotion(on bool)
+	Reset()
+	// SetPaused freezes the game, or unfreezes it, and pauses or
+	// resumes its music. A paused scene is drawn as it is: the drops
+	// don't move, the player catches none, and no events are published.
+	SetPaused(paused bool)
+	Paused() bool
+}
+
+func (s *scene) SetSFXVolume(v float64) {
+	s.player.SetVolume(v)
+	s.score.SetVolume(v)
+	s.pu.SetVolume(v)
+	if s.cues != nil {
+		s.cues.SetVolume(v)
+	}
 }
 
 func (s *scene) EnableAudio() {
 	s.audioEnabled = true
 	s.player.EnableAudio()
-}
-
-func (s *scene) handleMusic() {
-	if !s.audioEnabled {
-		return
-	}
-	if s.musicStarted {
-		if s.mus.Playing() {
-			return
-		}
-		s.musicStarted = false
-	}
-	s.mus.PlayLoop()
-	s.musicStarted = true
+	s.score.EnableAudio()
+	s.pu.EnableAudio()
+	s.music.EnableAudio()
 }
 
 func (s *scene) drawAudioPrompt(canvas media.Canvas) {
-	canvas.SetFont("32px Score", "#ffd700")
-	canvas.DrawText("Click or press to enable sound", 40, 80)
+	canvas.SetFont(font(32), "#ffd700")
+	canvas.DrawText(locale.T("Click or press to enable sound"), 40, 80)
 }
 
 type scene struct {
 	lastUpdate   time.Time
-	bg           media.Image
+	last         time.Time
+	shake        tween.Shake
+	start        time.Time
+	difficulty   difficulty.Preset
+	adaptive     Adaptive // nil unless enabled
+	cues         Cues     // nil unless enabled
+	calm         bool     // no camera shake
+	paused       bool     // frozen, see SetPaused
+	bg           Background
 	rain         Rain
 	player       Player
 	score        Scoreboard
-	mus          media.Audio
+	fx           Particles
+	popups       Popups
+	pu           PowerUps
+	levels       Levels
+	music        Music
+	stats        *stats.Session
+	bus          *event.Bus
 	audioEnabled bool
-	musicStarted bool
 }
 
 // NewScene ...
-func NewScene() (Scene, error) {
-	bg, err := media.NewImage("assets/img/background.png", "background")
+func NewScene(d difficulty.Preset, a Adaptive, cues Cues) (Scene, error) {
+	th, err := loadTheme(theme.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	bg, err := NewBackground(th)
+	if err != nil {
+		return nil, err
+	}
+	sprites, err := loadSprites(sprite.DefaultFile)
 	if err != nil {
 		return nil, err
 	}
-	rain, err := NewRain()
+	drops, err := loadDrops(catalog.DefaultFile)
 	if err != nil {
 		return nil, err
 	}
-	player, err := NewPlayer()
+	bus := event.NewBus()
+	rain, err := NewRain(sprites, drops, bus)
 	if err != nil {
 		return nil, err
 	}
-	mus, err := media.NewAudio("assets/snd/music_1.wav")
+	ls, err := loadLevels(level.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	if err = ls.Check(rain.Names()); err != nil {
+		return nil, err
+	}
+	player, err := NewPlayer(sprites)
+	if err != nil {
+		return nil, err
+	}
+	score, err := NewScoreboard()
+	if err != nil {
+		return nil, err
+	}
+	pu, err := NewPowerUps()
+	if err != nil {
+		return nil, err
+	}
+	pl, err := loadPlaylist(music.DefaultFile)
+	if err != nil {
+		return nil, err
+	}
+	mus, err := NewMusic(pl)
 	if err != nil {
 		return nil, err
 	}
 	s := &scene{
-		bg:     bg,
-		rain:   rain,
-		player: player,
-		score:  NewScoreboard(),
-		mus:    mus,
+		difficulty: d,
+		adaptive:   a,
+		cues:       cues,
+		bg:         bg,
+		rain:       rain,
+		player:     player,
+		score:      score,
+		fx:         NewParticles(),
+		popups:     NewPopups(),
+		pu:         pu,
+		levels:     NewLevels(ls),
+		music:      mus,
+		stats:      stats.NewSession(),
+		bus:        bus,
 	}
+	s.subscribe()
+	event.Publish(bus, RateChanged{d.At(difficulty.Progress{})})
 	return s, nil
 }
 
+// loadSprites fetches and validates the sprite metadata.
+func loadSprites(uri string) (sprite.Catalog, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return sprite.Parse(bytes.NewReader(b))
+}
+
+// loadDrops fetches and validates the drop catalog.
+func loadDrops(uri string) (catalog.Catalog, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Parse(bytes.NewReader(b))
+}
+
+// loadAchievements fetches and validates the achievements.
+func loadAchievements(uri string) (achievement.Defs, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return achievement.Parse(bytes.NewReader(b))
+}
+
+// loadLevels fetches and validates the levels.
+func loadLevels(uri string) (level.Levels, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return level.Parse(bytes.NewReader(b))
+}
+
+// loadTheme fetches and validates the background theme.
+func loadTheme(uri string) (*theme.Theme, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return theme.Parse(bytes.NewReader(b))
+}
+
+// loadPlaylist fetches and validates the music playlist.
+func loadPlaylist(uri string) (*music.Playlist, error) {
+	b, err := media.Fetch(uri)
+	if err != nil {
+		return nil, err
+	}
+	return music.Parse(bytes.NewReader(b))
+}
+
 func (s *scene) Player() Player {
 	return s.player
 }
 
-func (s *scene) Draw(canvas media.Canvas) {
+func (s *scene) Points() int64 {
+	return s.score.Points()
+}
+
+func (s *scene) Level() int {
+	return s.levels.Current() + 1
+}
+
+func (s *scene) Events() *event.Bus {
+	return s.bus
+}
+
+func (s *scene) Names() []string {
+	return s.rain.Names()
+}
+
+func (s *scene) Stats() *stats.Session {
+	s.stats.Points = s.score.Points()
+	return s.stats
+}
+
+func (s *scene) SetDifficulty(d difficulty.Preset) {
+	s.difficulty = d
+}
+
+func (s *scene) SetAdaptive(a Adaptive) {
+	s.adaptive = a
+}
+
+// SetCues sets the audio cues, nil to disable them. Their volume is
+// set with the sound effects.
+func (s *scene) SetCues(c Cues) {
+	s.cues = c
+}
+
+func (s *scene) SetReduceMotion(on bool) {
+	s.calm = on
+	if on {
+		s.shake = tween.Shake{}
+	}
+}
+
+func (s *scene) Reset() {
+	s.score.Reset()
+	s.rain.Reset()
+	s.pu.Reset()
+	s.levels.Reset()
+	s.music.Reset()
+	if s.adaptive != nil {
+		s.adaptive.Reset()
+	}
+	s.shake = tween.Shake{}
+	s.start = time.Time{}
+	s.lastUpdate = time.Time{}
+	s.last = time.Time{}
+	s.stats = stats.NewSession()
+	event.Publish(s.bus, RateChanged{s.difficulty.At(difficulty.Progress{})})
+}
+
+// play plays a frame of the game: the rain falls, and the player
+// catches the drops or lets them hit the floor.
+func (s *scene) play(now time.Time, canvas media.Canvas) {
+	s.rain.SetTimeScale(s.pu.TimeScale(now))
+	if s.pu.Active(powerup.Magnet, now) {
+		area := s.player.HitArea()
+		s.rain.Attract(area.X+area.W/2, powerup.MagnetSpeed)
+	}
+	s.levels.Update(now, s.rain)
+	s.rain.Draw(now, canvas)
+	if s.cues != nil && s.audioEnabled {
+		s.cues.Play(s.rain.Approaching(), canvas)
+	}
+	for _, drop := range s.rain.Drops() {
+		if drop.Consumed() {
+			continue
+		}
+		if s.player.Hit(drop) {
+			drop.Consume()
+			event.Publish(s.bus, DropCaught{Drop: drop, Now: now})
+		}
+	}
+	for _, drop := range s.rain.Landed() {
+		event.Publish(s.bus, DropMissed{Drop: drop, Now: now, Floor: canvas.ClientH()})
+	}
+}
+
+func (s *scene) SetPaused(paused bool) {
+	s.paused = paused
+	s.rain.SetPaused(paused)
+	s.music.SetPaused(paused)
+}
+
+func (s *scene) Paused() bool {
+	return s.paused
+}
+
+func (s *scene) Music() Music {
+	return s.music
+}
+
+func (s *scene) Draw(now time.Time, canvas media.Canvas) {
 	r := media.Rect{
 		X: 0,
 		Y: 0,
@@ -88,32 +333,170 @@ func (s *scene) Draw(canvas media.Canvas) {
 		H: canvas.ClientH(),
 	}
 	canvas.ClearRect(r)
-	canvas.DrawImage(s.bg, r)
-	s.handleMusic()
+	// shake the camera by moving the canvas
+	dt := frameTime(s.last, now)
+	s.last = now
+	if s.paused {
+		dt = 0
+	}
+	s.stats.Play(dt)
+	dx, dy := s.shake.Update(dt)
+	if dx != 0 || dy != 0 {
+		canvas.FillRect(r, "black")
+		canvas.SetOffset(int(dx), int(dy))
+		defer canvas.SetOffset(0, 0)
+	}
+	area := s.player.HitArea()
+	s.bg.Draw(now, canvas, area.X+area.W/2)
+	s.music.Update(now, s.score.Points(), s.levels.Current())
 	if !s.audioEnabled {
 		s.drawAudioPrompt(canvas)
 	}
-	s.player.Draw(canvas)
-	s.rain.Draw(canvas)
-	canvas.SetFont("80px Score", "red")
-	s.score.Draw(canvas)
-	hit := false
-	for _, drop := range s.rain.Drops() {
-		if drop.Consumed() {
-			continue
+	s.pu.Draw(now, canvas)
+	s.player.Draw(now, canvas)
+	if s.paused {
+		s.rain.Draw(now, canvas)
+	} else {
+		s.play(now, canvas)
+	}
+	canvas.SetFont(font(80), "red")
+	s.score.Draw(now, canvas)
+	s.fx.Draw(now, canvas)
+	s.popups.Draw(now, canvas)
+	s.levels.Draw(now, canvas)
+	if s.adaptive != nil {
+		s.adaptive.Draw(now, canvas)
+	}
+	if s.paused {
+		return
+	}
+	if s.start.IsZero() {
+		s.start = now
+	}
+	if now.Sub(s.lastUpdate) < time.Second {
+		return
+	}
+	s.lastUpdate = now
+	p := s.difficulty.At(difficulty.Progress{
+		Points:  s.score.Points(),
+		Elapsed: now.Sub(s.start),
+	})
+	if s.adaptive != nil {
+		p = s.adaptive.Apply(now, p)
+	}
+	event.Publish(s.bus, RateChanged{p})
+}
+
+// subscribe subscribes the subsystems of the scene to its events: the
+// rules of the game, the player, the effects, the adaptive difficulty
+// and the stats.
+func (s *scene) subscribe() {
+	event.Subscribe(s.bus, s.catch)
+	event.Subscribe(s.bus, s.miss)
+	event.Subscribe(s.bus, func(e RateChanged) { s.rain.SetParams(e.Params) })
+	event.Subscribe(s.bus, func(e ScoreChanged) { s.levels.Score(e.Delta) })
+	event.Subscribe(s.bus, func(e DropCaught) { s.player.Catch(e.Drop) })
+	event.Subscribe(s.bus, s.caughtFX)
+	event.Subscribe(s.bus, s.scoredFX)
+	event.Subscribe(s.bus, s.missedFX)
+	event.Subscribe(s.bus, s.recordScored)
+	event.Subscribe(s.bus, s.recordMissed)
+	event.Subscribe(s.bus, func(e DropCaught) {
+		if e.Drop.PowerUp() != powerup.None {
+			s.stats.CatchPowerUp(e.Drop.Name())
+			return
 		}
-		if s.player.Hit(drop) {
-			drop.Consume()
-			s.score.Add(drop.Points())
-			hit = true
+		s.stats.Catch(e.Drop.Name(), e.Drop.Points() < 0)
+	})
+	event.Subscribe(s.bus, func(e ScoreChanged) { s.stats.SetCombo(e.BestCombo) })
+	event.Subscribe(s.bus, func(e RateChanged) { s.stats.SetInterval(e.Params.Interval) })
+	event.Subscribe(s.bus, func(e DropMissed) {
+		if e.Drop.Points() > 0 {
+			s.stats.Miss(e.Drop.Name())
 		}
+	})
+}
+
+// catch activates the power-up of the drop caught by the player, or
+// scores it.
+func (s *scene) catch(e DropCaught) {
+	if k := e.Drop.PowerUp(); k != powerup.None {
+		s.pu.Activate(k, e.Now)
+		return
+	}
+	area := e.Drop.HitArea()
+	sc := ScoreChanged{Now: e.Now, X: area.X + area.W/2, Y: area.Y + area.H/2}
+	points := e.Drop.Points()
+	switch {
+	case points < 0 && s.pu.Active(powerup.Shield, e.Now):
+		s.pu.Deactivate(powerup.Shield)
+		sc.Blocked = true
+	case points > 0 && s.pu.Active(powerup.DoublePoints, e.Now):
+		points *= 2
 	}
-	t := time.Now()
-	if !hit || t.Sub(s.lastUpdate) < time.Second {
+	if !sc.Blocked {
+		sc.Delta = s.score.Add(points)
+	}
+	sc.Points = s.score.Points()
+	sc.BestCombo = s.score.BestCombo()
+	event.Publish(s.bus, sc)
+}
+
+// miss breaks the combo when good drops hit the floor.
+func (s *scene) miss(e DropMissed) {
+	if e.Drop.Points() > 0 {
+		s.score.Miss()
+	}
+}
+
+// caughtFX emits sparkles where power-ups are caught.
+func (s *scene) caughtFX(e DropCaught) {
+	if e.Drop.PowerUp() != powerup.None {
+		area := e.Drop.HitArea()
+		s.fx.Emit(fx.Sparkles, area.X+area.W/2, area.Y+area.H/2)
+	}
+}
+
+// scoredFX emits the effects of the drops scored, and pops up their
+// points.
+func (s *scene) scoredFX(e ScoreChanged) {
+	switch {
+	case e.Blocked:
+		s.fx.Emit(fx.Shielded, e.X, e.Y)
 		return
+	case e.Delta > 0:
+		s.fx.Emit(fx.Sparkles, e.X, e.Y)
+	default:
+		s.fx.Emit(fx.Splat, e.X, e.Y)
+		if !s.calm {
+			s.shake.Start(cameraShake, cameraShakeTime)
+		}
+	}
+	s.popups.Spawn(e.Delta, e.X, e.Y)
+}
+
+// missedFX crumbles the drops that hit the floor.
+func (s *scene) missedFX(e DropMissed) {
+	pos := e.Drop.Pos()
+	s.fx.Emit(fx.Crumbs.WithSprite(e.Drop.Image()), pos.X+pos.W/2, e.Floor)
+}
+
+// recordScored records the outcome of the drops scored for the
+// adaptive difficulty, if enabled.
+func (s *scene) recordScored(e ScoreChanged) {
+	switch {
+	case s.adaptive == nil, e.Blocked:
+	case e.Delta > 0:
+		s.adaptive.Record(e.Now, difficulty.Caught)
+	default:
+		s.adaptive.Record(e.Now, difficulty.BadHit)
+	}
+}
+
+// recordMissed records the good drops missed for the adaptive
+// difficulty, if enabled.
+func (s *scene) recordMissed(e DropMissed) {
+	if s.adaptive != nil && e.Drop.Points() > 0 {
+		s.adaptive.Record(e.Now, difficulty.Missed)
 	}
-	s.lastUpdate = t
-	p := s.score.Points()
-	rate := (int(p) / 1000) + 1
-	s.rain.SetRate(rate)
 }
@@ -2,35 +2,134 @@ package game
 
 import (
 	"fmt"
+	"math"
 	"sync/atomic"
+	"time"
 
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/score"
+	"github.com/fiorix/cat-o-licious/tween"
 	"github.com/fiorix/cat-o-licious/wasm/media"
 )
 
-// Scoreboard tracks the player's score and draws the scoreboard.
+// comboBreakTime is the duration of the combo break animation.
+const comboBreakTime = time.Second
+
+// scoreCountTime is the duration of the score counting up to the points.
+const scoreCountTime = 500 * time.Millisecond
+
+// Scoreboard tracks the player's score and combo, and draws the
+// scoreboard.
 type Scoreboard interface {
-	// Add adds the given delta to the player's score.
-	Add(delta int64)
+	// Add adds the given delta to the player's score. Positive
+	// deltas are good catches that extend the combo and are
+	// multiplied by the combo multiplier, negative deltas break
+	// the combo. Returns the delta added to the score.
+	Add(delta int64) int64
+
+	// Miss records a missed good drop, which breaks the combo.
+	Miss()
 
 	// Points returns the current player's points.
 	Points() int64
 
+	// BestCombo returns the longest combo of the game.
+	BestCombo() int
+
+	// Reset resets the score and combo for a new game.
+	Reset()
+
 	// Draw draws the scoreboard.
-	Draw(canvas media.Canvas)
+	Draw(now time.Time, canvas media.Canvas)
+
+	// EnableAudio enables audio playback.
+	EnableAudio()
+
+	// SetVolume sets the volume of the scoreboard's sfx, from 0 to 1.
+	SetVolume(v float64)
 }
 
 type scoreboard struct {
 	points int64
+	combo  score.Combo
+	lost   int          // length of the last broken combo
+	lostT  time.Time    // time of the last broken combo, set on Draw
+	sfx    *media.Sound // combo break sfx
+	sfxc   *media.Sound // combo multiplier sfx
+	count  *tween.Tween // points shown, counting up to points
+	last   time.Time
+
+	audioEnabled bool
 }
 
 // NewScoreboard creates and initializes a new scoreboard.
-func NewScoreboard() Scoreboard {
-	return &scoreboard{}
+func NewScoreboard() (Scoreboard, error) {
+	sfx, err := loadSound("assets/snd/combo_break_1.wav", "combo_break", 1)
+	if err != nil {
+		return nil, err
+	}
+	sfxc, err := loadSound("assets/snd/combo_1.wav", "combo", 1)
+	if err != nil {
+		return nil, err
+	}
+	return &scoreboard{sfx: sfx, sfxc: sfxc}, nil
+}
+
+func (sb *scoreboard) EnableAudio() {
+	sb.audioEnabled = true
+}
+
+func (sb *scoreboard) SetVolume(v float64) {
+	sb.sfx.SetVolume(v)
+	sb.sfxc.SetVolume(v)
 }
 
 // Add implements the Scoreboard interface.
-func (sb *scoreboard) Add(delta int64) {
-	atomic.AddInt64(&sb.points, delta)
+func (sb *scoreboard) Add(delta int64) int64 {
+	if delta > 0 {
+		m := sb.combo.Multiplier()
+		sb.combo.Catch()
+		if sb.combo.Multiplier() > m && sb.audioEnabled {
+			sb.sfxc.Play()
+		}
+		delta *= sb.combo.Multiplier()
+	} else {
+		sb.breakCombo()
+	}
+	p := atomic.AddInt64(&sb.points, delta)
+	from := 0.0
+	if sb.count != nil {
+		from = sb.count.Value()
+	}
+	sb.count = tween.New(from, float64(p), scoreCountTime, tween.OutCubic)
+	return delta
+}
+
+// Miss implements the Scoreboard interface.
+func (sb *scoreboard) Miss() {
+	sb.breakCombo()
+}
+
+// breakCombo resets the combo, and starts the break animation and
+// sound if the combo was long enough.
+func (sb *scoreboard) breakCombo() {
+	lost := sb.combo.Break()
+	if lost == 0 {
+		return
+	}
+	sb.lost = lost
+	sb.lostT = time.Time{}
+	if sb.audioEnabled {
+		sb.sfx.Play()
+	}
+}
+
+// Reset implements the Scoreboard interface.
+func (sb *scoreboard) Reset() {
+	atomic.StoreInt64(&sb.points, 0)
+	sb.combo = score.Combo{}
+	sb.lost = 0
+	sb.count = nil
 }
 
 // Points implements the Scoreboard interface.
@@ -38,9 +137,18 @@ func (sb *scoreboard) Points() int64 {
 	return atomic.LoadInt64(&sb.points)
 }
 
+func (sb *scoreboard) BestCombo() int {
+	return sb.combo.Best()
+}
+
 // Draw implements the Scoreboard interface.
-func (sb *scoreboard) Draw(canvas media.Canvas) {
+func (sb *scoreboard) Draw(now time.Time, canvas media.Canvas) {
 	p := atomic.LoadInt64(&sb.points)
+	if sb.count != nil {
+		sb.count.Update(frameTime(sb.last, now))
+		p = int64(math.Round(sb.count.Value()))
+	}
+	sb.last = now
 	text := fmt.Sprintf("%d", p)
 
 	// Right-align using current canvas font.
@@ -51,4 +159,35 @@ func (sb *scoreboard) Draw(canvas media.Canvas) {
 		x = margin
 	}
 	canvas.DrawText(text, x, 100)
+	sb.drawCombo(now, canvas, x+w, 140)
+}
+
+// drawCombo draws the combo or the combo break animation, aligned
+// to the right of x.
+func (sb *scoreboard) drawCombo(now time.Time, canvas media.Canvas, x, y int) {
+	if n := sb.combo.Count(); n >= score.MinCombo {
+		canvas.SetFont(font(32), "#ffd700")
+		text := locale.Sprintf("combo %d x%d", n, sb.combo.Multiplier())
+		canvas.DrawText(text, x-canvas.MeasureTextWidth(text), y)
+		return
+	}
+	if sb.lost == 0 {
+		return
+	}
+	if sb.lostT.IsZero() {
+		sb.lostT = now
+	}
+	since := now.Sub(sb.lostT)
+	if since >= comboBreakTime {
+		sb.lost = 0
+		return
+	}
+	// shake and fade out
+	prog := float64(since) / float64(comboBreakTime)
+	shake := int(math.Sin(prog*40) * 10 * (1 - prog))
+	canvas.SetFont(font(32), "#ff3c3c")
+	canvas.SetAlpha(1 - prog)
+	text := locale.Sprintf("combo %d lost", sb.lost)
+	canvas.DrawText(text, x-canvas.MeasureTextWidth(text)+shake, y)
+	canvas.SetAlpha(1)
 }
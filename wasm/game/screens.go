@@ -0,0 +1,503 @@
+package game
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/fiorix/cat-o-licious/event"
+	"github.com/fiorix/cat-o-licious/locale"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/screen"
+	"github.com/fiorix/cat-o-licious/ui"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Screen is a screen of the engine, in its stack of screens: the
+// profile picker, the title, the game and its pause overlay, the
+// settings and the keys of the profile, the name entry of a new high
+// score, the high scores, the stats and the achievements. See the
+// screen package.
+type Screen interface {
+	screen.Screen
+
+	// Key handles a key pressed while the screen is on top, and
+	// returns false to leave it to the engine, e.g. M to mute.
+	Key(key string) bool
+
+	// Click handles a click or touch at x, y while the screen is
+	// on top.
+	Click(x, y int)
+
+	// Hover handles the mouse moved to x, y while the screen is on
+	// top.
+	Hover(x, y int)
+
+	Draw(now time.Time, canvas media.Canvas)
+}
+
+type baseScreen struct {
+	screen.Base
+	e *engine
+}
+
+func (baseScreen) Hover(x, y int) {}
+
+// uiScreen is the base of the screens drawn with the ui package, which
+// navigate their widgets with the keyboard and the mouse.
+type uiScreen struct {
+	baseScreen
+	u ui.UI
+}
+
+// nav navigates the widgets with the key, and returns true if it's a
+// key of navigation.
+func (us *uiScreen) nav(key string) bool {
+	n := uiNav(key)
+	if n == ui.NavNone {
+		return false
+	}
+	us.u.Nav(n)
+	return true
+}
+
+func (us *uiScreen) Click(x, y int) {
+	us.u.Mouse(x, y, true)
+}
+
+func (us *uiScreen) Hover(x, y int) {
+	us.u.Mouse(x, y, false)
+}
+
+// back returns true for the keys that go back from a screen.
+func back(key string) bool {
+	return key == "Enter" || key == " " || key == "Escape"
+}
+
+// profileScreen is the profile picker, on top of the title until a
+// profile is picked. Escape goes back to the title, unless shown at
+// startup.
+type profileScreen struct {
+	uiScreen
+	startup bool
+}
+
+func (ps *profileScreen) Key(key string) bool {
+	e := ps.e
+	if e.picker.Mode == profile.Naming && len([]rune(key)) == 1 {
+		e.picker.Type(key)
+		return true
+	}
+	if e.picker.Mode == profile.Browse && key == "Escape" && !ps.startup {
+		e.screens.Pop()
+		return true
+	}
+	picked := e.picker.Key(keyName(key))
+	if e.picker.Changed {
+		if err := e.picker.Profiles.Save(e.st); err != nil {
+			log.Println("failed to save profiles:", err)
+		}
+		e.picker.Changed = false
+	}
+	if picked {
+		e.pick(e.picker.Current())
+		e.screens.Pop()
+	}
+	return true
+}
+
+// Click picks the profile clicked, or adds the first profile.
+func (ps *profileScreen) Click(x, y int) {
+	if len(ps.e.picker.Profiles.Profiles) == 0 {
+		ps.Key("Enter")
+		return
+	}
+	ps.uiScreen.Click(x, y)
+}
+
+func (ps *profileScreen) Draw(now time.Time, canvas media.Canvas) {
+	if ps.e.m.DrawProfiles(canvas, &ps.u, ps.e.picker) {
+		ps.Key("Enter")
+	}
+}
+
+// titleScreen is the title, at the bottom of the stack. Besides the
+// menu, H, S, G and P go to the high scores, the stats, the
+// achievements and the profile picker.
+type titleScreen struct {
+	uiScreen
+}
+
+func (ts *titleScreen) Key(key string) bool {
+	switch key {
+	case "h", "H":
+		ts.do(HighScoresAction)
+	case "p", "P":
+		ts.do(ProfileAction)
+	case "s", "S":
+		ts.do(StatsAction)
+	case "g", "G":
+		ts.do(AchievementsAction)
+	default:
+		return ts.nav(key)
+	}
+	return true
+}
+
+// do does the action of the menu.
+func (ts *titleScreen) do(a Action) {
+	e, base := ts.e, ts.baseScreen
+	switch a {
+	case PlayAction:
+		e.screens.Push(&playScreen{baseScreen: base})
+	case HighScoresAction:
+		e.screens.Push(&highScoresScreen{uiScreen: uiScreen{baseScreen: base}})
+	case StatsAction:
+		e.screens.Push(&statsScreen{baseScreen: base})
+	case AchievementsAction:
+		e.screens.Push(&achievementsScreen{baseScreen: base})
+	case SettingsAction:
+		e.screens.Push(&settingsScreen{uiScreen: uiScreen{baseScreen: base}})
+	case ProfileAction:
+		e.screens.Push(&profileScreen{uiScreen: uiScreen{baseScreen: base}})
+	}
+}
+
+func (ts *titleScreen) Draw(now time.Time, canvas media.Canvas) {
+	ts.do(ts.e.m.DrawTitle(canvas, &ts.u))
+}
+
+// buttonScreen is a screen that handles the gamepad buttons itself,
+// by their index in the standard layout, rather than as keys.
+type buttonScreen interface {
+	Button(i int) bool
+}
+
+// playScreen is the game, played on its own clock, which stops while
+// the game is paused. The player moves toward the side of the screen
+// held down, at the player speed of the settings per frame.
+type playScreen struct {
+	baseScreen
+	paused time.Time     // when the game was paused, zero if playing
+	offset time.Duration // time paused so far
+}
+
+func (ps *playScreen) Enter() {
+	ps.e.pick(ps.e.prof)
+	ps.e.s.Reset()
+	event.Publish(ps.e.s.Events(), StateChanged{GameStarted})
+}
+
+// Pause freezes the game under the screens on top, and pauses its
+// music.
+func (ps *playScreen) Pause() {
+	ps.paused = time.Now()
+	ps.e.s.SetPaused(true)
+}
+
+func (ps *playScreen) Resume() {
+	ps.offset += time.Since(ps.paused)
+	ps.paused = time.Time{}
+	ps.e.s.SetPaused(false)
+}
+
+// now returns the time of the game's clock at the given time.
+func (ps *playScreen) now(t time.Time) time.Time {
+	if !ps.paused.IsZero() {
+		t = ps.paused
+	}
+	return t.Add(-ps.offset)
+}
+
+func (ps *playScreen) Key(key string) bool {
+	e := ps.e
+	switch key {
+	case "Escape":
+		e.gameOver()
+		return true
+	case "p", "P":
+		e.screens.Push(&pauseScreen{uiScreen: uiScreen{baseScreen: ps.baseScreen}})
+		return true
+	}
+	speed := int32(e.set.Gameplay.PlayerSpeed)
+	switch e.prof.Bindings.Direction(keyName(key)) {
+	case -1:
+		e.s.Player().Move(Left, speed)
+	case 1:
+		e.s.Player().Move(Right, speed)
+	default:
+		return false
+	}
+	return true
+}
+
+// Button moves the player with the D-pad, whatever the keys of the
+// profile.
+func (ps *playScreen) Button(i int) bool {
+	e := ps.e
+	speed := int32(e.set.Gameplay.PlayerSpeed)
+	switch gamepadKeys[i] {
+	case "ArrowLeft":
+		e.s.Player().Move(Left, speed)
+	case "ArrowRight":
+		e.s.Player().Move(Right, speed)
+	default:
+		return false
+	}
+	return true
+}
+
+func (ps *playScreen) Click(x, y int) {
+	side := Left
+	if x > ps.e.c.ClientW()/2 {
+		side = Right
+	}
+	atomic.StoreInt32(&ps.e.holding, int32(side))
+}
+
+func (ps *playScreen) Draw(now time.Time, canvas media.Canvas) {
+	switch side := Direction(atomic.LoadInt32(&ps.e.holding)); side {
+	case Left, Right:
+		if ps.paused.IsZero() {
+			ps.e.s.Player().Move(side, int32(ps.e.set.Gameplay.PlayerSpeed))
+		}
+	}
+	ps.e.s.Draw(ps.now(now), canvas)
+}
+
+// pauseScreen is the pause overlay, on top of the game. Besides the
+// menu, P resumes the game, and Escape ends it.
+type pauseScreen struct {
+	uiScreen
+}
+
+func (ps *pauseScreen) Overlay() bool {
+	return true
+}
+
+func (ps *pauseScreen) Key(key string) bool {
+	switch key {
+	case "p", "P":
+		ps.do(ResumeAction)
+	case "Escape":
+		ps.do(EndAction)
+	default:
+		return ps.nav(key)
+	}
+	return true
+}
+
+// do does the action of the menu.
+func (ps *pauseScreen) do(a Action) {
+	e := ps.e
+	switch a {
+	case ResumeAction:
+		e.screens.Pop()
+	case SettingsAction:
+		e.screens.Push(&settingsScreen{uiScreen: uiScreen{baseScreen: ps.baseScreen}, playing: true})
+	case EndAction:
+		e.screens.Pop()
+		e.gameOver()
+	}
+}
+
+func (ps *pauseScreen) Draw(now time.Time, canvas media.Canvas) {
+	ps.do(ps.e.m.DrawPause(canvas, &ps.u))
+}
+
+// settingsScreen is the settings, from the title or the pause overlay.
+// Settings apply as they change, and are saved. Leaving them from the
+// title applies the gameplay settings to the title, e.g. the
+// difficulty shown.
+type settingsScreen struct {
+	uiScreen
+	section int  // section shown
+	playing bool // from the pause overlay, in the middle of a game
+}
+
+func (ss *settingsScreen) Exit() {
+	if !ss.playing {
+		ss.e.pick(ss.e.prof)
+	}
+}
+
+func (ss *settingsScreen) Key(key string) bool {
+	if key == "Escape" {
+		ss.e.screens.Pop()
+		return true
+	}
+	return ss.nav(key)
+}
+
+func (ss *settingsScreen) Draw(now time.Time, canvas media.Canvas) {
+	e := ss.e
+	f := newSettingsForm(*e.set, ss.section, e.prof)
+	a := e.m.DrawSettings(canvas, &ss.u, f)
+	ss.section = f.section
+	switch a {
+	case ApplyAction:
+		e.configure(f.settings())
+	case KeysAction:
+		e.screens.Push(&keysScreen{uiScreen: uiScreen{baseScreen: ss.baseScreen}})
+	case BackAction:
+		e.screens.Pop()
+	}
+}
+
+// keysScreen binds the keys of the profile playing, from the settings,
+// with the profile picker.
+type keysScreen struct {
+	uiScreen
+}
+
+func (ks *keysScreen) Enter() {
+	ks.e.picker.Select(ks.e.prof)
+	ks.e.picker.Mode = profile.BindLeft
+}
+
+func (ks *keysScreen) Key(key string) bool {
+	e := ks.e
+	e.picker.Key(keyName(key))
+	if e.picker.Changed {
+		if err := e.picker.Profiles.Save(e.st); err != nil {
+			log.Println("failed to save profiles:", err)
+		}
+		e.picker.Changed = false
+	}
+	if e.picker.Mode == profile.Browse {
+		e.screens.Pop()
+	}
+	return true
+}
+
+func (ks *keysScreen) Click(x, y int) {}
+
+func (ks *keysScreen) Draw(now time.Time, canvas media.Canvas) {
+	ks.e.m.DrawProfiles(canvas, &ks.u, ks.e.picker)
+}
+
+// nameScreen is the name entry of a new high score, which replaces
+// the game when it's over.
+type nameScreen struct {
+	baseScreen
+}
+
+func (ns *nameScreen) Key(key string) bool {
+	e := ns.e
+	switch key {
+	case "Backspace":
+		e.m.Erase()
+	case "Enter":
+		e.m.Enter()
+		e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: ns.baseScreen}})
+	case "Escape":
+		e.screens.Replace(&highScoresScreen{uiScreen: uiScreen{baseScreen: ns.baseScreen}})
+	default:
+		if len([]rune(key)) == 1 {
+			e.m.Type(key)
+		}
+	}
+	return true
+}
+
+// Click enters the name typed so far, if any, on touch screens without
+// a keyboard.
+func (ns *nameScreen) Click(x, y int) {
+	ns.Key("Enter")
+}
+
+func (ns *nameScreen) Draw(now time.Time, canvas media.Canvas) {
+	ns.e.m.DrawNameEntry(canvas)
+}
+
+// highScoresScreen is the high scores, from the title or when the
+// game is over.
+type highScoresScreen struct {
+	uiScreen
+}
+
+func (hs *highScoresScreen) Key(key string) bool {
+	if key == "Escape" {
+		hs.e.screens.Pop()
+		return true
+	}
+	return hs.nav(key)
+}
+
+func (hs *highScoresScreen) Draw(now time.Time, canvas media.Canvas) {
+	if hs.e.m.DrawHighScores(canvas, &hs.u) == BackAction {
+		hs.e.screens.Pop()
+	}
+}
+
+// statsScreen is the stats of the profile, which can be exported.
+type statsScreen struct {
+	baseScreen
+	msg string // message for the player, e.g. the file exported
+}
+
+func (ss *statsScreen) Key(key string) bool {
+	switch {
+	case back(key):
+		ss.e.screens.Pop()
+	case key == "e" || key == "E":
+		ss.export()
+	default:
+		return false
+	}
+	return true
+}
+
+// export downloads the stats of the profile.
+func (ss *statsScreen) export() {
+	e := ss.e
+	ex := e.stats.Export(e.prof.Name, e.last)
+	b, err := ex.JSON()
+	if err != nil {
+		log.Println("failed to export stats:", err)
+		ss.msg = locale.T("Failed to export the stats")
+		return
+	}
+	media.Download(ex.FileName(), "application/json", b)
+	ss.msg = locale.Sprintf("Exported to %s", ex.FileName())
+}
+
+func (ss *statsScreen) Click(x, y int) {
+	ss.e.screens.Pop()
+}
+
+func (ss *statsScreen) Draw(now time.Time, canvas media.Canvas) {
+	e := ss.e
+	e.m.DrawStats(canvas, e.stats.Export(e.prof.Name, e.last), ss.msg)
+}
+
+// achievementsScreen is the gallery of achievements of the profile.
+type achievementsScreen struct {
+	baseScreen
+	sel int // achievement selected
+}
+
+func (as *achievementsScreen) Key(key string) bool {
+	switch {
+	case back(key):
+		as.e.screens.Pop()
+	case key == "ArrowUp" && as.sel > 0:
+		as.sel--
+	case key == "ArrowDown" && as.sel < len(as.e.defs)-1:
+		as.sel++
+	case key == "ArrowUp" || key == "ArrowDown":
+	default:
+		return false
+	}
+	return true
+}
+
+func (as *achievementsScreen) Click(x, y int) {
+	as.e.screens.Pop()
+}
+
+func (as *achievementsScreen) Draw(now time.Time, canvas media.Canvas) {
+	e := as.e
+	p := e.ach.Profile(e.prof.Name)
+	e.m.DrawAchievements(canvas, p.Status(e.defs, nil, e.stats.Profile(e.prof.Name)), as.sel)
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"math"
+	"strings"
+
+	"github.com/fiorix/cat-o-licious/difficulty"
+	"github.com/fiorix/cat-o-licious/profile"
+	"github.com/fiorix/cat-o-licious/settings"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Sections of the settings screen. The page sizes the game, so there
+// are no video settings.
+const (
+	gameplaySection = iota
+	audioSection
+	controlsSection
+	accessibilitySection
+)
+
+// settingsSections are the names of the sections of the settings
+// screen, in order.
+var settingsSections = []string{"Gameplay", "Audio", "Controls", "Accessibility"}
+
+// settingsForm is the settings edited in the settings screen, with
+// the values of its widgets.
+type settingsForm struct {
+	settings.Settings
+	section    int     // section shown
+	speed      float64 // player speed, from 0 (slowest) to 1
+	difficulty int     // in difficultyOptions, 0 is the profile's
+	keys       string  // keys of the profile, e.g. "Left/A, Right/D"
+}
+
+// speedStep is the step of the player speed slider, 5 pixels.
+const speedStep = 5.0 / (settings.MaxPlayerSpeed - settings.MinPlayerSpeed)
+
+// difficultyOptions returns the options of the difficulty, the first
+// is the difficulty of the profile.
+func difficultyOptions() []string {
+	return append([]string{"profile"}, difficulty.Names()...)
+}
+
+func newSettingsForm(s settings.Settings, section int, p *profile.Profile) *settingsForm {
+	f := &settingsForm{Settings: s, section: section}
+	f.speed = float64(s.Gameplay.PlayerSpeed-settings.MinPlayerSpeed) /
+		(settings.MaxPlayerSpeed - settings.MinPlayerSpeed)
+	for i, name := range difficultyOptions() {
+		if i > 0 && name == s.Gameplay.Difficulty {
+			f.difficulty = i
+		}
+	}
+	if p != nil {
+		f.keys = strings.Join(p.Bindings.Left, "/") + ", " + strings.Join(p.Bindings.Right, "/")
+	}
+	return f
+}
+
+func (f *settingsForm) settings() settings.Settings {
+	s := f.Settings
+	s.Gameplay.PlayerSpeed = settings.MinPlayerSpeed +
+		int(math.Round(f.speed*(settings.MaxPlayerSpeed-settings.MinPlayerSpeed)))
+	s.Gameplay.Difficulty = ""
+	if f.difficulty > 0 {
+		s.Gameplay.Difficulty = difficultyOptions()[f.difficulty]
+	}
+	return s
+}
+
+// overrideSettings overrides the settings with the query of the page,
+// e.g. ?difficulty=easy&adaptive=1&cues=1.
+func overrideSettings(s *settings.Settings) {
+	if name := media.QueryParam("difficulty"); name != "" {
+		s.Gameplay.Difficulty = name
+	}
+	if media.QueryParam("adaptive") != "" {
+		s.Gameplay.Adaptive = true
+	}
+	if media.QueryParam("cues") != "" {
+		s.Accessibility.AudioCues = true
+	}
+}
+
+// configure applies the settings, and saves the changes from the
+// settings in use, but not the query overriding them. The gameplay
+// settings apply from the next game.
+func (e *engine) configure(s settings.Settings) {
+	e.saved.Merge(*e.set, s)
+	e.set.Gameplay = s.Gameplay
+	e.set.Accessibility = s.Accessibility
+	e.s.SetCues(nil)
+	if s.Accessibility.AudioCues {
+		e.s.SetCues(e.cues)
+	}
+	e.s.SetReduceMotion(s.Accessibility.ReduceMotion)
+	// sets the audio, with the volume of the cues, and saves the
+	// settings
+	e.a.Set(s.Audio)
+}
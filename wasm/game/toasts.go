@@ -0,0 +1,98 @@
+package game
+
+import (
+	"time"
+
+	"github.com/fiorix/cat-o-licious/tween"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// Toast timing: how long toasts take to slide in and out, and how long
+// they stay.
+const (
+	toastSlideTime = 300 * time.Millisecond
+	toastTime      = 3 * time.Second
+)
+
+// Toasts draws notifications over the screen, e.g. of achievements
+// unlocked, one at a time sliding in from the top.
+type Toasts interface {
+	Show(title, text string)
+	Draw(now time.Time, canvas media.Canvas)
+	EnableAudio()
+	SetVolume(v float64)
+}
+
+type toast struct {
+	title, text string
+}
+
+type toasts struct {
+	sfx   *media.Sound // played as toasts slide in
+	queue []toast
+	cur   *toast
+	seq   *tween.Sequence
+	pos   float64 // how far the toast is in, from 0 to 1
+	last  time.Time
+
+	audioEnabled bool
+}
+
+// NewToasts ...
+func NewToasts() (Toasts, error) {
+	sfx, err := loadSound("assets/snd/achievement.wav", "achievement", 1)
+	if err != nil {
+		return nil, err
+	}
+	return &toasts{sfx: sfx}, nil
+}
+
+func (ts *toasts) EnableAudio() {
+	ts.audioEnabled = true
+}
+
+func (ts *toasts) SetVolume(v float64) {
+	ts.sfx.SetVolume(v)
+}
+
+func (ts *toasts) Show(title, text string) {
+	ts.queue = append(ts.queue, toast{title, text})
+}
+
+func (ts *toasts) Draw(now time.Time, canvas media.Canvas) {
+	dt := frameTime(ts.last, now)
+	ts.last = now
+	if ts.seq == nil || ts.seq.Done() {
+		if len(ts.queue) == 0 {
+			ts.cur = nil
+			return
+		}
+		ts.cur = &ts.queue[0]
+		ts.queue = ts.queue[1:]
+		ts.seq = tween.Seq(
+			ts.slide(0, 1, tween.OutQuad),
+			tween.Wait(toastTime),
+			ts.slide(1, 0, tween.InQuad),
+		)
+		if ts.audioEnabled {
+			ts.sfx.Play()
+		}
+	}
+	ts.seq.Update(dt)
+	const w, h = 560, 100
+	x := canvas.ClientW()/2 - w/2
+	y := int(float64(h+10)*ts.pos) - h
+	canvas.FillRect(media.Rect{X: x, Y: y, W: w, H: h}, "rgba(0, 0, 0, .8)")
+	canvas.SetFont(font(40), "#ffd700")
+	drawCentered(canvas, ts.cur.title, canvas.ClientW()/2, y+42)
+	canvas.SetFont(font(28), "white")
+	drawCentered(canvas, ts.cur.text, canvas.ClientW()/2, y+84)
+}
+
+// slide returns a tween that moves the toast from and to the given
+// positions.
+func (ts *toasts) slide(from, to float64, ease tween.Ease) *tween.Tween {
+	t := tween.New(from, to, toastSlideTime, ease)
+	t.OnUpdate = func(v float64) { ts.pos = v }
+	return t
+}
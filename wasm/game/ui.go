@@ -0,0 +1,77 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/fiorix/cat-o-licious/ui"
+	"github.com/fiorix/cat-o-licious/wasm/media"
+)
+
+// uiFonts are the sizes of the fonts of each size of text, in pixels.
+var uiFonts = map[ui.Size]int{
+	ui.Normal: 32,
+	ui.Small:  24,
+	ui.Large:  72,
+}
+
+// canvasUI draws the widgets of the ui package on the canvas.
+type canvasUI struct {
+	canvas media.Canvas
+}
+
+func (cu canvasUI) setFont(size ui.Size, c ui.Color) int {
+	px := uiFonts[size]
+	cu.canvas.SetFont(font(px), uiColor(c))
+	return px
+}
+
+func (cu canvasUI) MeasureText(text string, size ui.Size) (w, h int) {
+	px := cu.setFont(size, ui.White)
+	return cu.canvas.MeasureTextWidth(text), px
+}
+
+func (cu canvasUI) DrawText(text string, size ui.Size, c ui.Color, x, y int) {
+	px := cu.setFont(size, c)
+	// text is drawn on its baseline, about 4/5 of the font down
+	cu.canvas.DrawText(text, x, y+px*4/5)
+}
+
+func (cu canvasUI) FillRect(r ui.Rect, c ui.Color) {
+	cu.canvas.FillRect(media.Rect{X: r.X, Y: r.Y, W: r.W, H: r.H}, uiColor(c))
+}
+
+// uiColor returns c in CSS format.
+func uiColor(c ui.Color) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", c.R, c.G, c.B, float64(c.A)/255)
+}
+
+// uiNav returns the navigation of the widgets of the key, if any.
+func uiNav(key string) ui.Nav {
+	switch key {
+	case "ArrowUp":
+		return ui.NavUp
+	case "ArrowDown":
+		return ui.NavDown
+	case "ArrowLeft":
+		return ui.NavLeft
+	case "ArrowRight":
+		return ui.NavRight
+	case "Enter", " ":
+		return ui.NavActivate
+	}
+	return ui.NavNone
+}
+
+// gamepadKeys are the keys of the buttons of gamepads, by their index
+// in the standard layout, so gamepads navigate the menus and play like
+// the keyboard.
+var gamepadKeys = map[int]string{
+	0:  "Enter",  // A
+	1:  "Escape", // B
+	8:  "Escape", // back
+	9:  "p",      // start
+	12: "ArrowUp",
+	13: "ArrowDown",
+	14: "ArrowLeft",
+	15: "ArrowRight",
+}
@@ -74,6 +74,21 @@ func (a Audio) Playing() bool {
 	return !a.Value.Get("paused").Bool()
 }
 
+// Pause pauses the audio.
+func (a Audio) Pause() {
+	a.Value.Call("pause")
+}
+
+// Resume resumes the audio from where it was paused.
+func (a Audio) Resume() {
+	ensurePromiseHandled(a.Value.Call("play"))
+}
+
+// SetVolume sets the volume of the audio, from 0 to 1.
+func (a Audio) SetVolume(v float64) {
+	a.Value.Set("volume", v)
+}
+
 // ensurePromiseHandled attaches handlers to Promise-like values returned by play().
 // This prevents unhandled promise rejections from destabilizing the WASM runtime,
 // and ensures js.Func resources are always released on resolve/reject.
@@ -1,6 +1,7 @@
 package media
 
 import (
+	"math"
 	"syscall/js"
 )
 
@@ -10,6 +11,12 @@ type Rect struct {
 	W, H int
 }
 
+// Intersects returns true if r and o overlap.
+func (r Rect) Intersects(o Rect) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W &&
+		r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
 // Canvas represents an HTML5 canvas.
 type Canvas struct {
 	Value js.Value
@@ -61,6 +68,50 @@ func (c Canvas) DrawImageFlipHorizontal(img Image, r Rect) {
 	c.ctx2d.Call("restore")
 }
 
+// DrawImageRotated draws an image into the canvas rotated by angle
+// degrees clockwise around its center, and optionally flipped.
+func (c Canvas) DrawImageRotated(img Image, r Rect, angle float64, flip bool) {
+	c.ctx2d.Call("save")
+	c.ctx2d.Call("translate", r.X+r.W/2, r.Y+r.H/2)
+	c.ctx2d.Call("rotate", angle*math.Pi/180)
+	if flip {
+		c.ctx2d.Call("scale", -1, 1)
+	}
+	c.ctx2d.Call("drawImage", img.Value, -r.W/2, -r.H/2, r.W, r.H)
+	c.ctx2d.Call("restore")
+}
+
+// SetOffset translates everything drawn next by x, y.
+func (c Canvas) SetOffset(x, y int) {
+	c.ctx2d.Call("setTransform", 1, 0, 0, 1, x, y)
+}
+
+// DrawLine draws a line from x1, y1 to x2, y2 with the given style.
+func (c Canvas) DrawLine(x1, y1, x2, y2 int, style string) {
+	c.ctx2d.Set("strokeStyle", style)
+	c.ctx2d.Call("beginPath")
+	c.ctx2d.Call("moveTo", x1, y1)
+	c.ctx2d.Call("lineTo", x2, y2)
+	c.ctx2d.Call("stroke")
+}
+
+// FillRect fills rectangle r with the given style.
+func (c Canvas) FillRect(r Rect, style string) {
+	c.ctx2d.Set("fillStyle", style)
+	c.ctx2d.Call("fillRect", r.X, r.Y, r.W, r.H)
+}
+
+// SetAlpha sets the opacity of subsequent drawing, from 0 to 1.
+func (c Canvas) SetAlpha(a float64) {
+	c.ctx2d.Set("globalAlpha", a)
+}
+
+// SetFilter sets the CSS filter of subsequent drawing, e.g.
+// "grayscale(1)", or "none".
+func (c Canvas) SetFilter(f string) {
+	c.ctx2d.Set("filter", f)
+}
+
 // SetFont ...
 func (c Canvas) SetFont(name, style string) {
 	c.ctx2d.Set("font", name)
@@ -86,11 +137,13 @@ type MouseClick int8
 const (
 	MouseUp MouseClick = iota
 	MouseDown
+	MouseMove
 )
 
 var mouseClickNames = map[MouseClick]string{
 	MouseUp:   "mouseup",
 	MouseDown: "mousedown",
+	MouseMove: "mousemove",
 }
 
 func (m MouseClick) String() string {
@@ -104,7 +157,7 @@ func (c Canvas) OnMouse(mapTouch bool, f func(click MouseClick, x, y int)) {
 		y := ev.Get("clientY").Int()
 		return x, y
 	}
-	for _, ev := range []MouseClick{MouseUp, MouseDown} {
+	for _, ev := range []MouseClick{MouseUp, MouseDown, MouseMove} {
 		ev := ev
 		c.Value.Call("addEventListener", ev.String(), js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 			x, y := pos(args[0])
@@ -0,0 +1,63 @@
+package media
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"syscall/js"
+)
+
+// Fetch retrieves the contents of the given URI. The error of URIs
+// not found is fs.ErrNotExist.
+func Fetch(uri string) ([]byte, error) {
+	xhr := js.Global().Get("XMLHttpRequest").New()
+	xhr.Call("open", "GET", uri)
+	xhr.Set("responseType", "arraybuffer")
+
+	result := make(chan error, 1)
+
+	var once sync.Once
+	var onLoad, onError js.Func
+
+	finalize := func(err error) {
+		once.Do(func() {
+			// Detach handlers BEFORE releasing funcs (avoids JS calling a released func).
+			xhr.Set("onload", js.Null())
+			xhr.Set("onerror", js.Null())
+			onLoad.Release()
+			onError.Release()
+			result <- err
+		})
+	}
+
+	onLoad = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		switch status := xhr.Get("status").Int(); status {
+		case 200:
+		case 404:
+			finalize(fmt.Errorf("fetch failed: %s: %w", uri, fs.ErrNotExist))
+			return nil
+		default:
+			finalize(fmt.Errorf("fetch failed: %s: status %d", uri, status))
+			return nil
+		}
+		finalize(nil)
+		return nil
+	})
+	onError = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		finalize(fmt.Errorf("fetch failed: %s", uri))
+		return nil
+	})
+
+	xhr.Set("onload", onLoad)
+	xhr.Set("onerror", onError)
+	xhr.Call("send")
+
+	if err := <-result; err != nil {
+		return nil, err
+	}
+
+	buf := js.Global().Get("Uint8Array").New(xhr.Get("response"))
+	b := make([]byte, buf.Get("length").Int())
+	js.CopyBytesToGo(b, buf)
+	return b, nil
+}
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// OnVisibility calls f when the page is hidden or shown, e.g. when
+// switching browser tabs.
+func OnVisibility(f func(hidden bool)) {
+	doc := js.Global().Get("document")
+	doc.Call("addEventListener", "visibilitychange", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		f(doc.Get("hidden").Bool())
+		return nil
+	}))
+}
+
+// Language returns the preferred language of the player, from the
+// browser, e.g. "pt-BR".
+func Language() string {
+	v := js.Global().Get("navigator").Get("language")
+	if v.IsUndefined() || v.IsNull() {
+		return ""
+	}
+	return v.String()
+}
+
+// LoadFont loads the font of the given URI as the given font family,
+// for the text drawn in canvases.
+func LoadFont(family, uri string) error {
+	face := js.Global().Get("FontFace").New(family, "url("+uri+")")
+	result := make(chan error, 1)
+	onLoad := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		js.Global().Get("document").Get("fonts").Call("add", face)
+		result <- nil
+		return nil
+	})
+	defer onLoad.Release()
+	onError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result <- fmt.Errorf("font failed: %s", uri)
+		return nil
+	})
+	defer onError.Release()
+	face.Call("load").Call("then", onLoad, onError)
+	return <-result
+}
+
+// Download offers the data to the player as a file download with the
+// given name and MIME type.
+func Download(name, mime string, data []byte) {
+	doc := js.Global().Get("document")
+	a := doc.Call("createElement", "a")
+	a.Set("href", DataURI(mime, data))
+	a.Set("download", name)
+	doc.Get("body").Call("appendChild", a)
+	a.Call("click")
+	a.Call("remove")
+}
+
+// GamepadButtons returns the buttons pressed on any of the gamepads
+// connected, by their index in the standard layout, e.g. 12 for up on
+// the D-pad.
+func GamepadButtons() []bool {
+	var pressed []bool
+	pads := js.Global().Get("navigator").Call("getGamepads")
+	for i := 0; i < pads.Length(); i++ {
+		pad := pads.Index(i)
+		if pad.IsNull() || pad.IsUndefined() {
+			continue
+		}
+		buttons := pad.Get("buttons")
+		for j := 0; j < buttons.Length(); j++ {
+			if j == len(pressed) {
+				pressed = append(pressed, false)
+			}
+			pressed[j] = pressed[j] || buttons.Index(j).Get("pressed").Bool()
+		}
+	}
+	return pressed
+}
@@ -0,0 +1,94 @@
+package media
+
+import (
+	"sync"
+	"syscall/js"
+)
+
+// Sound is a sound effect played on a number of voices, so that it
+// can overlap itself, e.g. when catching drops in a quick row.
+type Sound struct {
+	voices  []Audio
+	panners []js.Value // stereo panners of the voices, once connected
+	next    int
+}
+
+// NewSound creates and initializes a Sound that loads the given URI,
+// with the given number of voices.
+func NewSound(uri string, voices int) (*Sound, error) {
+	a, err := NewAudio(uri)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sound{voices: []Audio{a}}
+	for i := 1; i < voices; i++ {
+		s.voices = append(s.voices, Audio{a.Value.Call("cloneNode")})
+	}
+	return s, nil
+}
+
+// Play plays the sound from the beginning on the next voice.
+func (s *Sound) Play() {
+	s.PlayAt(0)
+}
+
+// PlayAt plays the sound from the beginning on the next voice, panned
+// to the stereo position p from -1 (left) to 1 (right). The first
+// panned sound connects the voices to the Web Audio stereo panners,
+// which requires the audio to be enabled by the user.
+func (s *Sound) PlayAt(p float64) {
+	if s.panners == nil && p != 0 {
+		s.connect()
+	}
+	if s.next < len(s.panners) {
+		s.panners[s.next].Get("pan").Set("value", p)
+	}
+	s.voices[s.next].Play()
+	s.next = (s.next + 1) % len(s.voices)
+}
+
+// SetVolume sets the volume of the sound, from 0 to 1.
+func (s *Sound) SetVolume(v float64) {
+	for _, a := range s.voices {
+		a.SetVolume(v)
+	}
+}
+
+// connect routes the voices through stereo panners, if supported by
+// the browser.
+func (s *Sound) connect() {
+	s.panners = []js.Value{}
+	ctx := audioContext()
+	if ctx.IsUndefined() || ctx.Get("createStereoPanner").Type() != js.TypeFunction {
+		return
+	}
+	for _, a := range s.voices {
+		p := ctx.Call("createStereoPanner")
+		ctx.Call("createMediaElementSource", a.Value).Call("connect", p)
+		p.Call("connect", ctx.Get("destination"))
+		s.panners = append(s.panners, p)
+	}
+}
+
+var audioCtx struct {
+	once sync.Once
+	v    js.Value
+}
+
+// audioContext returns the Web Audio context shared by all sounds, or
+// undefined if not supported by the browser.
+func audioContext() js.Value {
+	audioCtx.once.Do(func() {
+		c := js.Global().Get("AudioContext")
+		if c.IsUndefined() {
+			c = js.Global().Get("webkitAudioContext")
+		}
+		if c.IsUndefined() {
+			audioCtx.v = js.Undefined()
+			return
+		}
+		audioCtx.v = c.New()
+		ensurePromiseHandled(audioCtx.v.Call("resume"))
+	})
+	return audioCtx.v
+}
@@ -0,0 +1,37 @@
+package media
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/fiorix/cat-o-licious/store"
+)
+
+// LocalStorage is a store in the browser's local storage, with names
+// prefixed by the string value, e.g. "cat-o-licious/".
+type LocalStorage string
+
+// Load implements the store.Store interface.
+func (ls LocalStorage) Load(name string) (b []byte, err error) {
+	defer recoverStorage(&err)
+	v := js.Global().Get("localStorage").Call("getItem", string(ls)+name)
+	if v.IsNull() {
+		return nil, store.ErrNotExist
+	}
+	return []byte(v.String()), nil
+}
+
+// Save implements the store.Store interface.
+func (ls LocalStorage) Save(name string, data []byte) (err error) {
+	defer recoverStorage(&err)
+	js.Global().Get("localStorage").Call("setItem", string(ls)+name, string(data))
+	return nil
+}
+
+// recoverStorage turns the exceptions thrown by the local storage, e.g.
+// when disabled or full, into errors.
+func recoverStorage(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("local storage: %v", r)
+	}
+}
@@ -0,0 +1,23 @@
+package media
+
+import (
+	"encoding/base64"
+	"syscall/js"
+)
+
+// QueryParam returns the value of the given parameter from the query
+// string of the page URL, or an empty string if it is not set.
+func QueryParam(name string) string {
+	search := js.Global().Get("location").Get("search")
+	v := js.Global().Get("URLSearchParams").New(search).Call("get", name)
+	if v.IsNull() {
+		return ""
+	}
+	return v.String()
+}
+
+// DataURI returns a data URI of the given MIME type and data, e.g. to
+// load generated sounds like files.
+func DataURI(mime string, data []byte) string {
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+}